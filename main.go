@@ -1,33 +1,74 @@
 package main
 
 import (
+	"context"
 	"crypto/sha256"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
+	"time"
 
 	"chainguard.dev/apko/pkg/apk/apk"
 	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/dlorenc/wolfi-mcp/pkg/apkoconfig"
+	"github.com/dlorenc/wolfi-mcp/pkg/contents"
+	"github.com/dlorenc/wolfi-mcp/pkg/indexaudit"
+	"github.com/dlorenc/wolfi-mcp/pkg/indexcache"
+	"github.com/dlorenc/wolfi-mcp/pkg/indexdiff"
+	"github.com/dlorenc/wolfi-mcp/pkg/indexgen"
+	"github.com/dlorenc/wolfi-mcp/pkg/logging"
+	"github.com/dlorenc/wolfi-mcp/pkg/melange"
+	"github.com/dlorenc/wolfi-mcp/pkg/metrics"
+	"github.com/dlorenc/wolfi-mcp/pkg/query"
+	"github.com/dlorenc/wolfi-mcp/pkg/secdb"
 	"github.com/dlorenc/wolfi-mcp/pkg/server"
+	"github.com/dlorenc/wolfi-mcp/pkg/snapshots"
+	"github.com/dlorenc/wolfi-mcp/pkg/sources"
 	"github.com/dlorenc/wolfi-mcp/pkg/tools"
+	"github.com/dlorenc/wolfi-mcp/pkg/tools/apko"
+	"github.com/dlorenc/wolfi-mcp/pkg/tools/audit"
 	"github.com/dlorenc/wolfi-mcp/pkg/tools/dependencies"
+	"github.com/dlorenc/wolfi-mcp/pkg/tools/diff"
 	"github.com/dlorenc/wolfi-mcp/pkg/tools/graph"
+	"github.com/dlorenc/wolfi-mcp/pkg/tools/history"
 	"github.com/dlorenc/wolfi-mcp/pkg/tools/info"
+	"github.com/dlorenc/wolfi-mcp/pkg/tools/inspect"
+	"github.com/dlorenc/wolfi-mcp/pkg/tools/license"
+	"github.com/dlorenc/wolfi-mcp/pkg/tools/migrate"
+	"github.com/dlorenc/wolfi-mcp/pkg/tools/origin"
+	"github.com/dlorenc/wolfi-mcp/pkg/tools/owner"
+	"github.com/dlorenc/wolfi-mcp/pkg/tools/providers"
+	"github.com/dlorenc/wolfi-mcp/pkg/tools/sbom"
 	"github.com/dlorenc/wolfi-mcp/pkg/tools/search"
+	"github.com/dlorenc/wolfi-mcp/pkg/tools/size"
+	"github.com/dlorenc/wolfi-mcp/pkg/tools/skew"
+	"github.com/dlorenc/wolfi-mcp/pkg/tools/source"
 	"github.com/dlorenc/wolfi-mcp/pkg/tools/versions"
+	"github.com/dlorenc/wolfi-mcp/pkg/tools/vulnerabilities"
+	"github.com/mark3labs/mcp-go/mcp"
 )
 
 const (
-	defaultWolfiURL = "https://packages.wolfi.dev/os/%s/APKINDEX.tar.gz"
-	cacheSubDir     = "wolfi-mcp" // Application-specific subdirectory in the cache
-	cacheFile       = "APKINDEX.tar.gz"
+	defaultWolfiURL  = "https://packages.wolfi.dev/os/%s/APKINDEX.tar.gz"
+	cacheSubDir      = "wolfi-mcp" // Application-specific subdirectory in the cache
+	cacheFile        = "APKINDEX.tar.gz"
+	snapshotsSubDir  = "snapshots"    // Index snapshot history, inside the cache directory
+	indexCacheSubDir = "parsed"       // Parsed repository cache, inside the cache directory
+	contentsFile     = "contents.gob" // File lists of the scanned .apk archives, inside the cache directory
 )
 
+// logger receives progress and diagnostic messages. It never writes to
+// stdout, which carries the MCP protocol and the output of the commands.
+var logger = slog.New(slog.NewTextHandler(os.Stderr, nil))
+
 // multiStringFlag is a flag.Value that allows a flag to be specified multiple times
 type multiStringFlag []string
 
@@ -40,14 +81,7 @@ func (m *multiStringFlag) Set(value string) error {
 	return nil
 }
 
-func downloadFile(url, filepath string) error {
-	// Create the file
-	out, err := os.Create(filepath)
-	if err != nil {
-		return fmt.Errorf("failed to create file %s: %w", filepath, err)
-	}
-	defer out.Close()
-
+func downloadFile(url, path string) error {
 	// Get the data
 	resp, err := http.Get(url)
 	if err != nil {
@@ -59,9 +93,23 @@ func downloadFile(url, filepath string) error {
 		return fmt.Errorf("bad status code: %d", resp.StatusCode)
 	}
 
-	// Write the body to file
-	_, err = io.Copy(out, resp.Body)
+	// Write to a temporary file and rename it into place, so concurrent
+	// downloads of the same index never leave a mixed or truncated file
+	out, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
 	if err != nil {
+		return fmt.Errorf("failed to create file %s: %w", path, err)
+	}
+	if _, err := io.Copy(out, resp.Body); err != nil {
+		out.Close()
+		os.Remove(out.Name())
+		return fmt.Errorf("failed to save downloaded data: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(out.Name())
+		return fmt.Errorf("failed to save downloaded data: %w", err)
+	}
+	if err := os.Rename(out.Name(), path); err != nil {
+		os.Remove(out.Name())
 		return fmt.Errorf("failed to save downloaded data: %w", err)
 	}
 
@@ -138,7 +186,7 @@ func getAPKIndexPath(indexPath string) (string, error) {
 			cacheFilePath := filepath.Join(cacheDir, fmt.Sprintf("APKINDEX_%s.tar.gz", urlHash[:8]))
 
 			// Download the file
-			fmt.Printf("Downloading APKINDEX from %s...\n", indexPath)
+			logger.Info("Downloading APKINDEX", "url", indexPath)
 			if err := downloadFile(indexPath, cacheFilePath); err != nil {
 				return "", fmt.Errorf("error downloading index file from %s: %w", indexPath, err)
 			}
@@ -172,15 +220,9 @@ func getAPKIndexPath(indexPath string) (string, error) {
 	// Default cache file path
 	cacheFilePath := filepath.Join(cacheDir, cacheFile)
 
-	// Detect architecture for download URL (aarch64 or x86_64)
-	arch := "x86_64"
-	if runtime.GOARCH == "arm64" {
-		arch = "aarch64"
-	}
-
 	// Download the index file from the default URL
-	url := fmt.Sprintf(defaultWolfiURL, arch)
-	fmt.Printf("Downloading Wolfi APKINDEX from %s...\n", url)
+	url := defaultIndexURL()
+	logger.Info("Downloading Wolfi APKINDEX", "url", url)
 
 	if err := downloadFile(url, cacheFilePath); err != nil {
 		return "", fmt.Errorf("error downloading index file: %w", err)
@@ -194,115 +236,611 @@ func getAPKIndexPath(indexPath string) (string, error) {
 	return absPath, nil
 }
 
-// mergePackages combines packages from multiple APKINDEX files following Alpine merging semantics:
-// 1. When a package exists in multiple indexes, the highest version wins
-// 2. If versions are equal, the most recently indexed one wins
-func mergePackages(existing []*apk.Package, new []*apk.Package) []*apk.Package {
-	// Create a map for fast lookups of existing packages by name
-	pkgMap := make(map[string]*apk.Package, len(existing))
-	for _, pkg := range existing {
-		pkgMap[pkg.Name] = pkg
+// defaultIndexURL returns the Wolfi APKINDEX URL for the current architecture
+func defaultIndexURL() string {
+	// Detect architecture for download URL (aarch64 or x86_64)
+	arch := "x86_64"
+	if runtime.GOARCH == "arm64" {
+		arch = "aarch64"
+	}
+	return fmt.Sprintf(defaultWolfiURL, arch)
+}
+
+// loadIndex returns the packages of an APKINDEX given as a local path or URL
+func loadIndex(location string) ([]*apk.Package, error) {
+	absPath, err := getAPKIndexPath(location)
+	if err != nil {
+		return nil, err
+	}
+
+	loader := &apkindex.FileIndexLoader{}
+	return loader.LoadIndex(absPath)
+}
+
+// openSnapshotStore opens the index snapshot history in the cache directory
+func openSnapshotStore(retention snapshots.Retention) (*snapshots.Store, error) {
+	cacheDir, err := getUserCacheDir()
+	if err != nil {
+		return nil, fmt.Errorf("error determining cache directory: %w", err)
+	}
+	return snapshots.Open(filepath.Join(cacheDir, snapshotsSubDir), retention)
+}
+
+// splitAsOf splits a "location@date" reference to the snapshot history into
+// its location and time
+func splitAsOf(reference string) (string, time.Time, bool) {
+	idx := strings.LastIndex(reference, "@")
+	if idx == -1 {
+		return reference, time.Time{}, false
+	}
+	at, err := snapshots.ParseTime(reference[idx+1:])
+	if err != nil {
+		return reference, time.Time{}, false
+	}
+	return reference[:idx], at, true
+}
+
+// loadIndexAt is like loadIndex, but also accepts "location@date" references
+// to the snapshot of an index that was current at that date
+func loadIndexAt(store *snapshots.Store, reference string) ([]*apk.Package, error) {
+	location, at, ok := splitAsOf(reference)
+	if !ok || store == nil {
+		return loadIndex(reference)
+	}
+
+	snapshot, found := store.AsOf(location, at)
+	if !found {
+		return nil, fmt.Errorf("no snapshot of %s as of %s", location, at.Format(time.RFC3339))
+	}
+
+	loader := &apkindex.FileIndexLoader{}
+	return loader.LoadIndex(store.Path(snapshot))
+}
+
+// historicalRepositories builds repositories from the snapshots of the loaded
+// indexes, keeping the most recently built one in memory
+type historicalRepositories struct {
+	store     *snapshots.Store
+	locations []string
+
+	mu   sync.Mutex
+	key  string
+	repo *apkindex.Repository
+}
+
+// at returns the merged repository as it was at the given time
+func (h *historicalRepositories) at(t time.Time) (*apkindex.Repository, string, error) {
+	var history []snapshots.Snapshot
+	var digests []string
+	for _, location := range h.locations {
+		// Indexes that were not fetched yet at that time are left out
+		if snapshot, ok := h.store.AsOf(location, t); ok {
+			history = append(history, snapshot)
+			digests = append(digests, snapshot.Digest)
+		}
+	}
+	if len(history) == 0 {
+		return nil, "", fmt.Errorf("no snapshots as old as %s", t.Format(time.RFC3339))
+	}
+	key := strings.Join(digests, ",")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.key == key {
+		return h.repo, key, nil
+	}
+
+	loader := &apkindex.FileIndexLoader{}
+	var allPackages []*apk.Package
+	for _, snapshot := range history {
+		packages, err := loader.LoadIndex(h.store.Path(snapshot))
+		if err != nil {
+			return nil, "", fmt.Errorf("error loading snapshot of %s: %w", snapshot.Location, err)
+		}
+		allPackages = sources.MergePackages(allPackages, packages)
+	}
+
+	h.key = key
+	h.repo = apkindex.NewRepository(allPackages)
+	return h.repo, key, nil
+}
+
+// loadRepository loads the given indexes, or the default Wolfi index when
+// none are given, recording each of them in the snapshot history. When the
+// index cache is given and the indexes did not change, the parsed repository
+// is read from it instead of parsing every index again. Up to workers
+// indexes are fetched and parsed at the same time.
+func loadRepository(indexPaths []string, workers int, store *snapshots.Store, cache *indexcache.Cache, recorder *metrics.Metrics) ([]*apk.Package, []sources.Source, error) {
+	start := time.Now()
+
+	// No indexes specified, download the default one
+	locations := uniqueLocations(indexPaths)
+	if len(locations) == 0 {
+		locations = []string{""}
 	}
 
-	// Process new packages
-	for _, pkg := range new {
-		existing, exists := pkgMap[pkg.Name]
+	// Fetch the indexes concurrently, keeping them in command line order
+	inputs := make([]indexcache.Input, len(locations))
+	err := forEachIndex(len(locations), workers, func(i int) error {
+		indexPath := locations[i]
+		absPath, err := getAPKIndexPath(indexPath)
+		if err != nil {
+			return fmt.Errorf("error getting index path for %s: %w", indexPath, err)
+		}
+		location := indexPath
+		if location == "" {
+			location = defaultIndexURL()
+		}
 
-		if !exists {
-			// New package, add it
-			pkgMap[pkg.Name] = pkg
-			continue
+		digest, err := indexcache.FileDigest(absPath)
+		if err != nil {
+			return fmt.Errorf("error reading %s: %w", absPath, err)
 		}
+		inputs[i] = indexcache.Input{Location: location, Path: absPath, Digest: digest}
 
-		// Package already exists, compare versions with Alpine's version comparison
-		// Since we don't have direct access to apkversion.Compare from the package,
-		// we'll use a simple string comparison for version checking
-		// In a real-world implementation, this should use the actual Alpine versioning rules
-
-		// First check if the versions are equal
-		if existing.Version == pkg.Version {
-			// Same version, take the newer package (the one from the later index)
-			// This follows Alpine's approach where later repositories override earlier ones
-			pkgMap[pkg.Name] = pkg
-			continue
+		if store != nil {
+			if _, err := store.Record(location, absPath, time.Now()); err != nil {
+				logger.Warn("Could not record index snapshot", "index", location, "error", err)
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
 
-		// For different versions, we'll use a simple string comparison
-		// This is not a fully accurate representation of Alpine's version comparison,
-		// but should work for most common cases
-		if pkg.Version > existing.Version {
-			// New package has higher version, replace
-			pkgMap[pkg.Name] = pkg
+	key := indexcache.Key(inputs)
+	if cache != nil {
+		entry, ok, err := cache.Load(key)
+		if err != nil {
+			logger.Warn("Could not read the index cache", "error", err)
+		}
+		recorder.ObserveCacheLookup(metrics.CacheIndex, ok)
+		if ok {
+			logger.Info("Loaded packages from the index cache", "packages", len(entry.Merged), "duration", time.Since(start).Round(time.Millisecond))
+			return entry.Merged, entry.Sources, nil
+		}
+	}
+
+	// Parse the indexes concurrently
+	loader := &apkindex.FileIndexLoader{}
+	loaded := make([]sources.Source, len(inputs))
+	err = forEachIndex(len(inputs), workers, func(i int) error {
+		input := inputs[i]
+		logger.Info("Loading APK index", "path", input.Path)
+		packages, err := loader.LoadIndex(input.Path)
+		if err != nil {
+			return fmt.Errorf("error loading APK index %s: %w", input.Path, err)
 		}
-		// If pkg.Version < existing.Version, keep the existing one
+		logger.Info("Loaded APK index", "path", input.Path, "packages", len(packages))
+		loaded[i] = sources.Source{Location: input.Location, Path: input.Path, Digest: input.Digest, Packages: packages}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Merge packages in command line order, whatever order the parses completed in
+	var allPackages []*apk.Package
+	for _, src := range loaded {
+		allPackages = sources.MergePackages(allPackages, src.Packages)
 	}
+	logger.Info("Merged APK indexes", "packages", len(allPackages), "duration", time.Since(start).Round(time.Millisecond))
 
-	// Convert map back to slice
-	result := make([]*apk.Package, 0, len(pkgMap))
-	for _, pkg := range pkgMap {
-		result = append(result, pkg)
+	if cache != nil {
+		if err := cache.Store(key, &indexcache.Entry{Sources: loaded, Merged: allPackages}); err != nil {
+			logger.Warn("Could not write the index cache", "error", err)
+		}
 	}
 
+	return allPackages, loaded, nil
+}
+
+// uniqueLocations returns the index locations with repeated ones removed, so
+// no index is fetched twice at the same time. Each location keeps its last
+// position, since later indexes take precedence when merging.
+func uniqueLocations(locations []string) []string {
+	last := make(map[string]int, len(locations))
+	for i, location := range locations {
+		last[location] = i
+	}
+	var result []string
+	for i, location := range locations {
+		if last[location] == i {
+			result = append(result, location)
+		}
+	}
 	return result
 }
 
-func main() {
-	// Define command line flags - index can be repeated for multiple indexes
-	var indexPaths multiStringFlag
-	flag.Var(&indexPaths, "index", "Path to APKINDEX.tar.gz file (can be specified multiple times, if not provided, downloads from Wolfi repository)")
-	flag.Parse()
+// packageCounts returns the number of packages of each loaded index, along
+// with the size of the merged repository
+func packageCounts(merged []*apk.Package, loaded []sources.Source) map[string]int {
+	counts := map[string]int{"merged": len(merged)}
+	for _, src := range loaded {
+		counts[src.Location] += len(src.Packages)
+	}
+	return counts
+}
 
-	// Create a new index loader
-	loader := &apkindex.FileIndexLoader{}
+// forEachIndex calls fn for every index from 0 to n-1 using at most workers
+// goroutines. It waits for all of them and returns their errors joined, in
+// index order.
+func forEachIndex(n, workers int, fn func(i int) error) error {
+	if workers < 1 {
+		workers = 1
+	}
 
-	// Keep track of all loaded packages
-	var allPackages []*apk.Package
+	errs := make([]error, n)
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers && w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				errs[i] = fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
 
-	if len(indexPaths) == 0 {
-		// No indexes specified, download the default one
-		absPath, err := getAPKIndexPath("")
-		if err != nil {
-			fmt.Printf("Error: %v\n", err)
-			os.Exit(1)
+	return errors.Join(errs...)
+}
+
+// openIndexCache opens the parsed index cache in the cache directory
+func openIndexCache() (*indexcache.Cache, error) {
+	cacheDir, err := getUserCacheDir()
+	if err != nil {
+		return nil, fmt.Errorf("error determining cache directory: %w", err)
+	}
+	return indexcache.Open(filepath.Join(cacheDir, indexCacheSubDir))
+}
+
+// loadContents indexes the files of the .apk archives in dirs. The index is
+// kept in the cache directory, so only new or changed archives are read again.
+func loadContents(dirs []string, workers int) (*contents.Index, error) {
+	start := time.Now()
+
+	var previous *contents.Index
+	cacheDir, err := getUserCacheDir()
+	if err != nil {
+		logger.Warn("Contents index will not be kept", "error", err)
+	} else if previous, err = contents.Load(filepath.Join(cacheDir, contentsFile)); err != nil {
+		logger.Warn("Could not read the contents index", "error", err)
+	}
+
+	index, err := contents.Scan(dirs, previous, workers, func(path string, err error) {
+		logger.Warn("Skipping unreadable .apk file", "path", path, "error", err)
+	})
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("Indexed .apk contents", "packages", len(index.Packages), "files", index.Files(), "duration", time.Since(start).Round(time.Millisecond))
+
+	if cacheDir != "" {
+		if err := index.Store(filepath.Join(cacheDir, contentsFile)); err != nil {
+			logger.Warn("Could not write the contents index", "error", err)
 		}
+	}
+	return index, nil
+}
 
-		// Load the APK index
-		fmt.Printf("Loading APK index from %s...\n", absPath)
-		packages, err := loader.LoadIndex(absPath)
+// runDiff implements the diff command, which prints the difference between two index snapshots
+func runDiff(args []string) error {
+	if len(args) != 2 || strings.TrimSpace(args[0]) == "" || strings.TrimSpace(args[1]) == "" {
+		return fmt.Errorf("usage: %s diff <old index> <new index>", os.Args[0])
+	}
+
+	// The snapshot history is optional here, it is only needed for location@date references
+	store, err := openSnapshotStore(snapshots.Retention{})
+	if err != nil {
+		logger.Warn("Snapshot history unavailable", "error", err)
+	}
+
+	oldPackages, err := loadIndexAt(store, args[0])
+	if err != nil {
+		return fmt.Errorf("error loading %s: %w", args[0], err)
+	}
+	newPackages, err := loadIndexAt(store, args[1])
+	if err != nil {
+		return fmt.Errorf("error loading %s: %w", args[1], err)
+	}
+
+	fmt.Print(indexdiff.Compare(oldPackages, newPackages).String())
+	return nil
+}
+
+// loadIndexDir reads the .apk files of a directory as if it held an index,
+// so local build output can be used without running apk index
+func loadIndexDir(dir string, workers int) (sources.Source, error) {
+	start := time.Now()
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return sources.Source{}, fmt.Errorf("failed to get absolute path for %s: %w", dir, err)
+	}
+
+	packages, err := indexgen.Scan(absDir, workers)
+	if err != nil {
+		return sources.Source{}, fmt.Errorf("error reading packages in %s: %w", dir, err)
+	}
+	logger.Info("Read packages from directory", "path", absDir, "packages", len(packages), "duration", time.Since(start).Round(time.Millisecond))
+
+	return sources.Source{Location: indexgen.Location(absDir), Path: absDir, Packages: packages}, nil
+}
+
+// runIndex implements the index command, which writes an APKINDEX.tar.gz for
+// the .apk files of a directory
+func runIndex(args []string, workers int) error {
+	fs := flag.NewFlagSet("index", flag.ContinueOnError)
+	output := fs.String("o", "", "Write the index to this file (default: APKINDEX.tar.gz in the directory)")
+	description := fs.String("description", "", "Description stored in the index")
+	signKey := fs.String("sign-key", "", "Sign the index with this PEM encoded RSA private key")
+	keyName := fs.String("key-name", "", "Name of the public key apk verifies the signature with (default: the -sign-key file name with .pub appended)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: %s index [-o file] [-description text] [-sign-key key.rsa [-key-name key.rsa.pub]] <dir>\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected one directory")
+	}
+	if *keyName != "" && *signKey == "" {
+		return fmt.Errorf("-key-name requires -sign-key")
+	}
+
+	dir := fs.Arg(0)
+	packages, err := indexgen.Scan(dir, workers)
+	if err != nil {
+		return err
+	}
+
+	path := *output
+	if path == "" {
+		path = filepath.Join(dir, "APKINDEX.tar.gz")
+	}
+	opts := indexgen.Options{Description: *description, KeyFile: *signKey, KeyName: *keyName}
+	if err := indexgen.WriteFile(path, packages, opts); err != nil {
+		return err
+	}
+	logger.Info("Wrote APK index", "path", path, "packages", len(packages), "signed", *signKey != "")
+	return nil
+}
+
+// runSBOM implements the sbom command, which writes an SBOM of the install
+// closure of a package list or apko configuration
+func runSBOM(repo *apkindex.Repository, loaded []sources.Source, args []string) error {
+	fs := flag.NewFlagSet("sbom", flag.ContinueOnError)
+	format := fs.String("format", "spdx", "SBOM format: spdx or cyclonedx")
+	config := fs.String("config", "", "Path to an apko YAML configuration whose packages are used")
+	output := fs.String("o", "", "Write the SBOM to this file instead of stdout")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: %s sbom [-format spdx|cyclonedx] [-o file] (-config apko.yaml | package...)\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var packages []string
+	for _, arg := range fs.Args() {
+		packages = append(packages, tools.SplitList(arg)...)
+	}
+	name := strings.Join(packages, ",")
+	if *config != "" {
+		if len(packages) > 0 {
+			return fmt.Errorf("packages and -config are mutually exclusive")
+		}
+		cfg, err := apkoconfig.Read("", *config)
 		if err != nil {
-			fmt.Printf("Error loading APK index: %v\n", err)
-			os.Exit(1)
+			return err
 		}
-		fmt.Printf("Loaded %d packages\n", len(packages))
-		allPackages = packages
-	} else {
-		// Load all specified indexes
-		for _, indexPath := range indexPaths {
-			absPath, err := getAPKIndexPath(indexPath)
-			if err != nil {
-				fmt.Printf("Error getting index path for %s: %v\n", indexPath, err)
-				os.Exit(1)
-			}
+		packages = cfg.Contents.Packages
+		name = *config
+	}
 
-			fmt.Printf("Loading APK index from %s...\n", absPath)
-			packages, err := loader.LoadIndex(absPath)
-			if err != nil {
-				fmt.Printf("Error loading APK index: %v\n", err)
-				os.Exit(1)
+	data, err := sbom.Generate(repo, sources.NewIndex(loaded), packages, *format, name, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(*output, data, 0644)
+}
+
+// runAudit implements the audit command, which checks the dependencies of the
+// loaded packages and fails when any of them cannot be resolved
+func runAudit(repo *apkindex.Repository, loaded []sources.Source, args []string) error {
+	fs := flag.NewFlagSet("audit", flag.ContinueOnError)
+	allVersions := fs.Bool("all-versions", false, "Audit every version of each package instead of only the latest one")
+	var only multiStringFlag
+	fs.Var(&only, "only", "Only audit the packages of this -index, while resolving against all of them (can be specified multiple times)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	packages := repo.GetAllPackages()
+	if len(only) > 0 {
+		packages = nil
+		for _, location := range only {
+			found := false
+			for _, src := range loaded {
+				if src.Location == location {
+					packages = append(packages, src.Packages...)
+					found = true
+				}
+			}
+			if !found {
+				return fmt.Errorf("%s is not one of the loaded indexes", location)
 			}
-			fmt.Printf("Loaded %d packages from %s\n", len(packages), absPath)
+		}
+	}
+	if !*allVersions {
+		packages = indexaudit.Latest(packages)
+	}
 
-			// Merge packages into allPackages with proper semantics
-			allPackages = mergePackages(allPackages, packages)
+	report := indexaudit.Run(repo, packages)
+	fmt.Print(report.String())
+	if !report.Empty() {
+		return fmt.Errorf("the audit found unresolvable dependencies")
+	}
+	return nil
+}
+
+// serveMetrics serves the metrics at /metrics on addr in the background
+func serveMetrics(addr string, recorder *metrics.Metrics) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", recorder.Handler())
+	go func() {
+		logger.Info("Serving metrics", "address", addr)
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			logger.Error("Metrics server stopped", "error", err)
 		}
-		fmt.Printf("Total of %d packages loaded after merging\n", len(allPackages))
+	}()
+}
+
+// setupLogging replaces the logger according to the logging flags. The
+// returned forwarder passes the messages at or above mcpLevel to the MCP
+// clients once its sink is set.
+func setupLogging(level, format, file, mcpLevel string) (*logging.Forwarder, error) {
+	minLevel, err := logging.ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+	minMCPLevel, err := logging.ParseLevel(mcpLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	var w io.Writer = os.Stderr
+	if file != "" {
+		f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("error opening log file: %w", err)
+		}
+		w = f
+	}
+	handler, err := logging.NewHandler(w, minLevel, format)
+	if err != nil {
+		return nil, err
+	}
+
+	forwarder := logging.NewForwarder(minMCPLevel)
+	logger = slog.New(logging.Tee(handler, forwarder.Handler()))
+	return forwarder, nil
+}
+
+// exitOnError prints the error of a command and exits when it failed
+func exitOnError(err error) {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func main() {
+	// Define command line flags - index can be repeated for multiple indexes
+	var indexPaths multiStringFlag
+	flag.Var(&indexPaths, "index", "Path to APKINDEX.tar.gz file (can be specified multiple times, if not provided, downloads from Wolfi repository)")
+	historyMaxAge := flag.Duration("history-max-age", 30*24*time.Hour, "Remove index snapshots older than this from the history (0 keeps them forever)")
+	historyMaxSnapshots := flag.Int("history-max-snapshots", 30, "Maximum number of snapshots kept in the history for each index (0 keeps all of them)")
+	indexWorkers := flag.Int("index-workers", 4, "Maximum number of indexes downloaded and parsed at the same time")
+	useIndexCache := flag.Bool("index-cache", true, "Keep the parsed indexes in the cache directory and reuse them while the indexes do not change")
+	var secdbPaths multiStringFlag
+	var indexDirs multiStringFlag
+	flag.Var(&indexDirs, "index-dir", "Directory of .apk files, e.g. a melange packages/<arch> output, read like an index and merged after the -index indexes (can be specified multiple times)")
+	var apkDirs multiStringFlag
+	flag.Var(&apkDirs, "apk-dir", "Directory of .apk files, e.g. a melange packages/ output or a local mirror, whose file lists are indexed for who_owns (can be specified multiple times)")
+	melangeDir := flag.String("melange-dir", "", "Checkout of melange package definitions, e.g. a wolfi-dev/os clone, linked to the index packages by origin for package_source (default: disabled)")
+	flag.Var(&secdbPaths, "secdb", "Path to a local secdb JSON file or OSV directory with vulnerability advisories (can be specified multiple times)")
+	rateLimit := flag.Float64("rate-limit", 0, "Maximum tool calls per second for each client (0 disables rate limiting)")
+	rateBurst := flag.Int("rate-burst", 10, "Number of tool calls a client can make at once before -rate-limit applies")
+	resultCacheSize := flag.Int("result-cache-size", 256, "Number of tool results kept in memory and reused for identical calls (0 disables the cache)")
+	resultCacheTTL := flag.Duration("result-cache-ttl", 10*time.Minute, "How long cached tool results are reused (0 keeps them until evicted)")
+	maxOutput := flag.Int("max-output", 4<<20, "Maximum size in bytes of the text returned by a tool call, longer output is truncated (0 disables the limit)")
+	logLevel := flag.String("log-level", "info", "Minimum level of the logged messages: debug, info, warn or error")
+	logFormat := flag.String("log-format", logging.FormatText, "Format of the logged messages: text or json")
+	logFile := flag.String("log-file", "", "Append the logged messages to this file instead of stderr")
+	toolProfile := flag.String("tool-profile", tools.ProfileAll, "Named set of tools to expose: "+strings.Join(tools.ProfileNames(), ", "))
+	enableTools := flag.String("enable-tools", "", "Comma separated list of tools to expose in addition to the profile")
+	disableTools := flag.String("disable-tools", "", "Comma separated list of tools to hide from the profile")
+	metricsAddr := flag.String("metrics-addr", "", "Serve Prometheus metrics on this address, e.g. :9090, at /metrics (default: disabled)")
+	mcpLogLevel := flag.String("mcp-log-level", "warn", "Minimum level of the logged messages also sent to MCP clients as notifications")
+	flag.Parse()
+
+	// Set up logging before anything is logged
+	forwarder, err := setupLogging(*logLevel, *logFormat, *logFile, *mcpLogLevel)
+	exitOnError(err)
+
+	// Run a command instead of the server if one was given
+	command := flag.Arg(0)
+	switch command {
+	case "":
+	case "diff":
+		exitOnError(runDiff(flag.Args()[1:]))
+		return
+	case "index":
+		exitOnError(runIndex(flag.Args()[1:], *indexWorkers))
+		return
+	case "sbom", "audit", "query":
+	default:
+		exitOnError(fmt.Errorf("unknown command %q", command))
 	}
 
+	// Expose metrics while the indexes load, so slow loads can be observed
+	var recorder *metrics.Metrics
+	if *metricsAddr != "" {
+		recorder = metrics.New()
+		serveMetrics(*metricsAddr, recorder)
+	}
+
+	// Keep every fetched index in the snapshot history
+	store, err := openSnapshotStore(snapshots.Retention{MaxAge: *historyMaxAge, MaxSnapshots: *historyMaxSnapshots})
+	if err != nil {
+		logger.Warn("Snapshot history disabled", "error", err)
+	}
+
+	// Keep track of all loaded packages, and of the index each of them came from
+	var cache *indexcache.Cache
+	if *useIndexCache {
+		if cache, err = openIndexCache(); err != nil {
+			logger.Warn("Index cache disabled", "error", err)
+		}
+	}
+	start := time.Now()
+	allPackages, loaded, err := loadRepository(indexPaths, *indexWorkers, store, cache, recorder)
+	exitOnError(err)
+
+	// Local build output overlays the indexes
+	for _, dir := range indexDirs {
+		src, err := loadIndexDir(dir, *indexWorkers)
+		exitOnError(err)
+		allPackages = sources.MergePackages(allPackages, src.Packages)
+		loaded = append(loaded, src)
+	}
+	recorder.ObserveIndexLoad(time.Since(start), packageCounts(allPackages, loaded))
+
 	// Create a new repository with the loaded packages
 	repo := apkindex.NewRepository(allPackages)
 
-	// Create a new server with default configuration
-	srv := server.New(server.DefaultConfig())
+	switch command {
+	case "sbom":
+		exitOnError(runSBOM(repo, loaded, flag.Args()[1:]))
+		return
+	case "audit":
+		exitOnError(runAudit(repo, loaded, flag.Args()[1:]))
+		return
+	}
 
 	// Create all tools
 	allTools := []tools.Tool{
@@ -311,15 +849,133 @@ func main() {
 		dependencies.New(),
 		versions.New(),
 		graph.New(),
+		apko.New(loaded),
+		size.New(),
+		migrate.New(),
+		origin.New(),
+		license.New(),
+		sbom.New(),
+		providers.New(),
+		audit.New(),
+		skew.New(loaded),
+		diff.New(func(location string) ([]*apk.Package, error) {
+			return loadIndexAt(store, location)
+		}),
+		inspect.New(),
+	}
+
+	// Vulnerability matching needs a local advisory feed
+	if len(secdbPaths) > 0 {
+		db := secdb.New()
+		for _, path := range secdbPaths {
+			if err := db.Load(path); err != nil {
+				exitOnError(fmt.Errorf("error loading advisories: %w", err))
+			}
+		}
+		logger.Info("Loaded advisories", "advisories", db.Count())
+		recorder.SetAdvisories(db.Count())
+		allTools = append(allTools, vulnerabilities.New(db))
 	}
 
-	// Register all tools with the server
-	tools.RegisterAll(srv, repo, allTools...)
+	// File ownership needs the file lists of local .apk archives
+	if len(apkDirs) > 0 {
+		files, err := loadContents(apkDirs, *indexWorkers)
+		exitOnError(err)
+		allTools = append(allTools, owner.New(files))
+	}
+
+	// Package sources need a checkout of the melange definitions
+	if *melangeDir != "" {
+		tree, err := melange.Load(*melangeDir, func(path string, err error) {
+			logger.Warn("Skipping file that is not a melange definition", "path", path, "error", err)
+		})
+		exitOnError(err)
+		logger.Info("Loaded melange definitions", "path", *melangeDir, "definitions", len(tree.Definitions))
+		allTools = append(allTools, source.New(tree))
+	}
+
+	// Only cache the results of the tools that depend on their arguments and
+	// the loaded repository alone. The others read local files or fetch
+	// indexes, which can change while the server runs.
+	cacheable := make(map[string]bool)
+	for _, tool := range allTools {
+		switch tool.(type) {
+		case *apko.Tool, *migrate.Tool, *sbom.Tool, *diff.Tool, *inspect.Tool:
+		default:
+			cacheable[tool.GetTool().Name] = true
+		}
+	}
+
+	// Let the repository tools run against the snapshot history
+	if store != nil {
+		historical := &historicalRepositories{store: store}
+		for _, src := range loaded {
+			historical.locations = append(historical.locations, src.Location)
+		}
+		for i, tool := range allTools {
+			switch tool.(type) {
+			case *apko.Tool, *diff.Tool, *skew.Tool, *owner.Tool:
+				// These read their own indexes rather than the repository
+			default:
+				allTools[i] = snapshots.WithAsOf(tool, historical.at)
+			}
+		}
+
+		loader := &apkindex.FileIndexLoader{}
+		allTools = append(allTools, history.New(store, loader.LoadIndex))
+	}
+
+	// Let the repository tools be scoped to one of the loaded repositories
+	index := sources.NewIndex(loaded)
+	for i, tool := range allTools {
+		switch tool.(type) {
+		case *apko.Tool, *diff.Tool, *skew.Tool, *owner.Tool, *history.Tool:
+			// These read their own indexes rather than the repository
+		default:
+			allTools[i] = sources.WithRepository(tool, index)
+		}
+	}
+
+	// Only expose the tools selected by the profile and the enable/disable lists
+	allTools, err = tools.Select(allTools, *toolProfile, tools.SplitList(*enableTools), tools.SplitList(*disableTools))
+	exitOnError(err)
+
+	// Call a single tool from the command line instead of serving them
+	if command == "query" {
+		runner := query.New()
+		tools.RegisterAll(runner, repo, tools.Chain(tools.Recover(logger), sources.Middleware(index)), allTools...)
+		exitOnError(runner.Run(context.Background(), os.Stdout, flag.Args()[1:]))
+		return
+	}
+
+	// Create a new server with default configuration, and forward the
+	// important log messages to its clients
+	config := server.DefaultConfig()
+	config.Logger = logger
+	srv := server.New(config)
+	forwarder.SetSink(srv.Log)
+
+	// Register all tools with the server, behind the configured middleware
+	middleware := []tools.Middleware{tools.Recover(logger), recorder.Middleware(), tools.Logging(logger), sources.Middleware(index)}
+	if *rateLimit > 0 {
+		middleware = append(middleware, tools.RateLimit(*rateLimit, *rateBurst))
+	}
+	if *maxOutput > 0 {
+		middleware = append(middleware, tools.LimitOutput(*maxOutput))
+	}
+	if *resultCacheSize > 0 {
+		middleware = append(middleware, tools.Cache(*resultCacheSize, *resultCacheTTL, func(tool mcp.Tool) bool {
+			return cacheable[tool.Name]
+		}, func(hit bool) {
+			recorder.ObserveCacheLookup(metrics.CacheResult, hit)
+		}))
+	}
+	tools.RegisterAll(srv, repo, tools.Chain(middleware...), allTools...)
 
 	// Start the server
-	fmt.Println("Starting MCP server...")
+	logger.Info("Starting MCP server")
 	if err := srv.Serve(); err != nil {
-		fmt.Printf("Server error: %v\n", err)
+		logger.Error("Server error", "error", err)
 		os.Exit(1)
 	}
 }
@@ -1,16 +1,24 @@
 package main
 
 import (
+	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"chainguard.dev/apko/pkg/apk/apk"
+	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/dlorenc/wolfi-mcp/pkg/indexcache"
+	"github.com/dlorenc/wolfi-mcp/pkg/sources"
 )
 
 func TestDownloadFile(t *testing.T) {
@@ -307,151 +315,301 @@ func TestIntegrationMultipleIndexes(t *testing.T) {
 	// 3. Verify the resulting repository has the correct packages after merging
 }
 
-func TestMergePackages(t *testing.T) {
+// writeTestIndex writes an APKINDEX.tar.gz containing the given packages
+func writeTestIndex(t *testing.T, path string, packages []*apk.Package) {
+	t.Helper()
+
+	archive, err := apk.ArchiveFromIndex(&apk.APKIndex{Packages: packages})
+	if err != nil {
+		t.Fatalf("Failed to create index archive: %v", err)
+	}
+	data, err := io.ReadAll(archive)
+	if err != nil {
+		t.Fatalf("Failed to read index archive: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("Failed to write index archive: %v", err)
+	}
+}
+
+func TestRunDiff(t *testing.T) {
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "old.tar.gz")
+	newPath := filepath.Join(dir, "new.tar.gz")
+	writeTestIndex(t, oldPath, []*apk.Package{{Name: "pkg1", Version: "1.0.0-r0"}})
+	writeTestIndex(t, newPath, []*apk.Package{{Name: "pkg1", Version: "1.0.0-r1"}})
+
+	if err := runDiff([]string{oldPath, newPath}); err != nil {
+		t.Errorf("runDiff failed: %v", err)
+	}
+
+	if err := runDiff([]string{oldPath}); err == nil {
+		t.Error("Expected usage error with a single argument, got nil")
+	}
+
+	if err := runDiff([]string{oldPath, filepath.Join(dir, "missing.tar.gz")}); err == nil {
+		t.Error("Expected error for a missing index, got nil")
+	}
+}
+
+func TestSplitAsOf(t *testing.T) {
 	testCases := []struct {
-		name     string
-		existing []*apk.Package
-		new      []*apk.Package
-		expected []*apk.Package
+		reference string
+		location  string
+		ok        bool
 	}{
-		{
-			name:     "Empty existing packages",
-			existing: []*apk.Package{},
-			new: []*apk.Package{
-				{Name: "pkg1", Version: "1.0.0"},
-				{Name: "pkg2", Version: "2.0.0"},
-			},
-			expected: []*apk.Package{
-				{Name: "pkg1", Version: "1.0.0"},
-				{Name: "pkg2", Version: "2.0.0"},
-			},
-		},
-		{
-			name: "Empty new packages",
-			existing: []*apk.Package{
-				{Name: "pkg1", Version: "1.0.0"},
-				{Name: "pkg2", Version: "2.0.0"},
-			},
-			new: []*apk.Package{},
-			expected: []*apk.Package{
-				{Name: "pkg1", Version: "1.0.0"},
-				{Name: "pkg2", Version: "2.0.0"},
-			},
-		},
-		{
-			name: "New package not in existing",
-			existing: []*apk.Package{
-				{Name: "pkg1", Version: "1.0.0"},
-			},
-			new: []*apk.Package{
-				{Name: "pkg2", Version: "2.0.0"},
-			},
-			expected: []*apk.Package{
-				{Name: "pkg1", Version: "1.0.0"},
-				{Name: "pkg2", Version: "2.0.0"},
-			},
-		},
-		{
-			name: "Higher version in new",
-			existing: []*apk.Package{
-				{Name: "pkg1", Version: "1.0.0"},
-			},
-			new: []*apk.Package{
-				{Name: "pkg1", Version: "1.1.0"},
-			},
-			expected: []*apk.Package{
-				{Name: "pkg1", Version: "1.1.0"},
-			},
-		},
-		{
-			name: "Lower version in new",
-			existing: []*apk.Package{
-				{Name: "pkg1", Version: "1.1.0"},
-			},
-			new: []*apk.Package{
-				{Name: "pkg1", Version: "1.0.0"},
-			},
-			expected: []*apk.Package{
-				{Name: "pkg1", Version: "1.1.0"},
-			},
-		},
-		{
-			name: "Same version, take new",
-			existing: []*apk.Package{
-				{Name: "pkg1", Version: "1.0.0", Description: "Old description"},
-			},
-			new: []*apk.Package{
-				{Name: "pkg1", Version: "1.0.0", Description: "New description"},
-			},
-			expected: []*apk.Package{
-				{Name: "pkg1", Version: "1.0.0", Description: "New description"},
-			},
-		},
-		{
-			name: "Complex mix of scenarios",
-			existing: []*apk.Package{
-				{Name: "pkg1", Version: "1.0.0"},
-				{Name: "pkg2", Version: "2.0.0"},
-				{Name: "pkg3", Version: "3.1.0"},
-				{Name: "pkg4", Version: "4.0.0", Description: "Old pkg4"},
-			},
-			new: []*apk.Package{
-				{Name: "pkg1", Version: "1.1.0"},
-				{Name: "pkg2", Version: "1.9.0"},
-				{Name: "pkg4", Version: "4.0.0", Description: "New pkg4"},
-				{Name: "pkg5", Version: "5.0.0"},
-			},
-			expected: []*apk.Package{
-				{Name: "pkg1", Version: "1.1.0"},
-				{Name: "pkg2", Version: "2.0.0"},
-				{Name: "pkg3", Version: "3.1.0"},
-				{Name: "pkg4", Version: "4.0.0", Description: "New pkg4"},
-				{Name: "pkg5", Version: "5.0.0"},
-			},
-		},
+		{"https://packages.wolfi.dev/os/x86_64/APKINDEX.tar.gz@2026-10-13", "https://packages.wolfi.dev/os/x86_64/APKINDEX.tar.gz", true},
+		{"/tmp/APKINDEX.tar.gz@2026-10-13T08:00:00Z", "/tmp/APKINDEX.tar.gz", true},
+		{"https://user@example.com/APKINDEX.tar.gz", "https://user@example.com/APKINDEX.tar.gz", false},
+		{"/tmp/APKINDEX.tar.gz", "/tmp/APKINDEX.tar.gz", false},
 	}
 
 	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
-			result := mergePackages(tc.existing, tc.new)
+		t.Run(tc.reference, func(t *testing.T) {
+			location, _, ok := splitAsOf(tc.reference)
+			if location != tc.location || ok != tc.ok {
+				t.Errorf("splitAsOf(%q) = %q, %v, want %q, %v", tc.reference, location, ok, tc.location, tc.ok)
+			}
+		})
+	}
+}
 
-			// Since the order of packages in the result is not guaranteed
-			// (it depends on map iteration order), we need to check for equality
-			// by first converting both to maps for comparison
+func TestRunSBOM(t *testing.T) {
+	repo := apkindex.NewRepository([]*apk.Package{
+		{Name: "app", Version: "1.0-r0", Dependencies: []string{"lib"}},
+		{Name: "lib", Version: "2.0-r0"},
+	})
 
-			expectedMap := make(map[string]*apk.Package)
-			for _, pkg := range tc.expected {
-				expectedMap[pkg.Name] = pkg
-			}
+	dir := t.TempDir()
+	output := filepath.Join(dir, "sbom.json")
+	if err := runSBOM(repo, nil, []string{"-format", "cyclonedx", "-o", output, "app"}); err != nil {
+		t.Fatalf("runSBOM failed: %v", err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("Failed to read the SBOM: %v", err)
+	}
+	if !strings.Contains(string(data), `"bomFormat": "CycloneDX"`) || !strings.Contains(string(data), "pkg:apk/wolfi/lib@2.0-r0") {
+		t.Errorf("Unexpected SBOM: %s", data)
+	}
 
-			resultMap := make(map[string]*apk.Package)
-			for _, pkg := range result {
-				resultMap[pkg.Name] = pkg
-			}
+	config := filepath.Join(dir, "apko.yaml")
+	if err := os.WriteFile(config, []byte("contents:\n  packages:\n    - app\n"), 0644); err != nil {
+		t.Fatalf("Failed to write the configuration: %v", err)
+	}
+	if err := runSBOM(repo, nil, []string{"-config", config, "-o", output}); err != nil {
+		t.Errorf("runSBOM with a configuration failed: %v", err)
+	}
+
+	if err := runSBOM(repo, nil, []string{"-config", config, "app"}); err == nil {
+		t.Error("Expected error when mixing packages and -config, got nil")
+	}
+	if err := runSBOM(repo, nil, []string{"unknown"}); err == nil {
+		t.Error("Expected error for an unknown package, got nil")
+	}
+}
+
+func TestRunAudit(t *testing.T) {
+	wolfi := sources.Source{
+		Location: "wolfi",
+		Packages: []*apk.Package{{Name: "glibc", Version: "2.40-r0", Dependencies: []string{"missing"}}},
+	}
+	overlay := sources.Source{
+		Location: "overlay",
+		Packages: []*apk.Package{{Name: "app", Version: "1.0-r0", Dependencies: []string{"glibc"}}},
+	}
+	repo := apkindex.NewRepository(append(wolfi.Packages, overlay.Packages...))
+	loaded := []sources.Source{wolfi, overlay}
+
+	if err := runAudit(repo, loaded, nil); err == nil {
+		t.Error("Expected the audit to fail on the missing dependency, got nil")
+	}
+
+	clean := apkindex.NewRepository(overlay.Packages[:0])
+	if err := runAudit(clean, nil, nil); err != nil {
+		t.Errorf("Expected an empty repository to pass the audit, got %v", err)
+	}
+
+	if err := runAudit(repo, loaded, []string{"-only", "unknown"}); err == nil {
+		t.Error("Expected error for an index that is not loaded, got nil")
+	}
+}
+
+func TestLoadRepositoryCache(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.tar.gz")
+	second := filepath.Join(dir, "second.tar.gz")
+	writeTestIndex(t, first, []*apk.Package{{Name: "pkg1", Version: "1.0.0-r0"}, {Name: "pkg2", Version: "1.0.0-r0"}})
+	writeTestIndex(t, second, []*apk.Package{{Name: "pkg2", Version: "2.0.0-r0"}})
+
+	cache, err := indexcache.Open(filepath.Join(dir, "cache"))
+	if err != nil {
+		t.Fatalf("Failed to open the index cache: %v", err)
+	}
+
+	parsed, parsedSources, err := loadRepository([]string{first, second}, 2, nil, cache, nil)
+	if err != nil {
+		t.Fatalf("loadRepository failed: %v", err)
+	}
+	cached, cachedSources, err := loadRepository([]string{first, second}, 2, nil, cache, nil)
+	if err != nil {
+		t.Fatalf("loadRepository from the cache failed: %v", err)
+	}
+
+	if len(cached) != 2 || len(cachedSources) != 2 || len(cachedSources[0].Packages) != len(parsedSources[0].Packages) {
+		t.Fatalf("Unexpected cached repository: %v, %v", cached, cachedSources)
+	}
+	versions := make(map[string]string)
+	for _, pkg := range cached {
+		versions[pkg.Name] = pkg.Version
+	}
+	for _, pkg := range parsed {
+		if versions[pkg.Name] != pkg.Version {
+			t.Errorf("Cached %s is %s, parsed %s", pkg.Name, versions[pkg.Name], pkg.Version)
+		}
+	}
+
+	// Changing an index invalidates the cache
+	writeTestIndex(t, second, []*apk.Package{{Name: "pkg2", Version: "3.0.0-r0"}})
+	updated, _, err := loadRepository([]string{first, second}, 2, nil, cache, nil)
+	if err != nil {
+		t.Fatalf("loadRepository after an update failed: %v", err)
+	}
+	for _, pkg := range updated {
+		if pkg.Name == "pkg2" && pkg.Version != "3.0.0-r0" {
+			t.Errorf("Expected the updated index to be parsed again, got pkg2 %s", pkg.Version)
+		}
+	}
+}
+
+func TestForEachIndex(t *testing.T) {
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	err := forEachIndex(10, 3, func(i int) error {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+
+		time.Sleep(time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+
+		if i%4 == 1 {
+			return fmt.Errorf("index %d failed", i)
+		}
+		return nil
+	})
+
+	if maxRunning > 3 {
+		t.Errorf("Expected at most 3 concurrent calls, got %d", maxRunning)
+	}
+	if err == nil || err.Error() != "index 1 failed\nindex 5 failed\nindex 9 failed" {
+		t.Errorf("Expected every error in index order, got %v", err)
+	}
+}
+
+func TestLoadRepositorySingleIndex(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "APKINDEX.tar.gz")
+	writeTestIndex(t, path, []*apk.Package{{Name: "pkg1", Version: "1.0.0-r0"}, {Name: "pkg1", Version: "1.1.0-r0"}, {Name: "pkg2", Version: "1.0.0-r0"}})
+
+	// Older versions of a package are kept, as they are in the index
+	packages, _, err := loadRepository([]string{path}, 1, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("loadRepository failed: %v", err)
+	}
+	var versions []string
+	for _, pkg := range packages {
+		versions = append(versions, pkg.Name+"-"+pkg.Version)
+	}
+	if want := []string{"pkg1-1.0.0-r0", "pkg1-1.1.0-r0", "pkg2-1.0.0-r0"}; !reflect.DeepEqual(versions, want) {
+		t.Errorf("Expected every version of the index, got %v", versions)
+	}
+}
 
-			// Check number of packages
-			if len(result) != len(tc.expected) {
-				t.Errorf("Expected %d packages, got %d", len(tc.expected), len(result))
+func TestLoadRepositoryErrors(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.tar.gz")
+	writeTestIndex(t, valid, []*apk.Package{{Name: "pkg1", Version: "1.0.0-r0"}})
+
+	_, _, err := loadRepository([]string{filepath.Join(dir, "missing1.tar.gz"), valid, filepath.Join(dir, "missing2.tar.gz")}, 2, nil, nil, nil)
+	if err == nil {
+		t.Fatal("Expected an error for the missing indexes, got nil")
+	}
+	if !strings.Contains(err.Error(), "missing1.tar.gz") || !strings.Contains(err.Error(), "missing2.tar.gz") {
+		t.Errorf("Expected both failures to be reported, got %v", err)
+	}
+}
+
+func TestLoadRepositoryOrder(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for i := 0; i < 6; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("index%d.tar.gz", i))
+		writeTestIndex(t, path, []*apk.Package{{Name: "pkg", Version: "1.0.0-r0", Description: fmt.Sprintf("from index %d", i)}})
+		paths = append(paths, path)
+	}
+
+	// Identical versions are taken from the last index, whatever order the parses complete in
+	for attempt := 0; attempt < 5; attempt++ {
+		packages, loaded, err := loadRepository(paths, 6, nil, nil, nil)
+		if err != nil {
+			t.Fatalf("loadRepository failed: %v", err)
+		}
+		if len(packages) != 1 || packages[0].Description != "from index 5" {
+			t.Fatalf("Expected the package of the last index, got %v", packages)
+		}
+		for i, src := range loaded {
+			if src.Location != paths[i] {
+				t.Errorf("Expected source %d to be %s, got %s", i, paths[i], src.Location)
 			}
+		}
+	}
+}
 
-			// Check each package
-			for name, expectedPkg := range expectedMap {
-				resultPkg, exists := resultMap[name]
-				if !exists {
-					t.Errorf("Expected package %s not found in result", name)
-					continue
-				}
+func TestUniqueLocations(t *testing.T) {
+	got := uniqueLocations([]string{"a", "b", "a", "c", "b"})
+	if want := []string{"a", "c", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("uniqueLocations() = %v, want %v", got, want)
+	}
+	if got := uniqueLocations(nil); len(got) != 0 {
+		t.Errorf("uniqueLocations(nil) = %v", got)
+	}
+}
 
-				if resultPkg.Version != expectedPkg.Version {
-					t.Errorf("Package %s version mismatch. Expected %s, got %s",
-						name, expectedPkg.Version, resultPkg.Version)
-				}
+func TestDownloadFileConcurrent(t *testing.T) {
+	content := strings.Repeat("index data ", 10000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(content))
+	}))
+	defer server.Close()
 
-				if expectedPkg.Description != "" && resultPkg.Description != expectedPkg.Description {
-					t.Errorf("Package %s description mismatch. Expected %s, got %s",
-						name, expectedPkg.Description, resultPkg.Description)
-				}
+	// Concurrent downloads of the same index only ever leave a complete file
+	dir := t.TempDir()
+	path := filepath.Join(dir, "APKINDEX.tar.gz")
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := downloadFile(server.URL, path); err != nil {
+				t.Errorf("Download failed: %v", err)
 			}
-		})
+		}()
+	}
+	wg.Wait()
+
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != content {
+		t.Errorf("Unexpected downloaded file (%d bytes): %v", len(data), err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("Expected only the downloaded file, got %d entries", len(entries))
 	}
 }
@@ -0,0 +1,347 @@
+package apkfile
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"chainguard.dev/apko/pkg/apk/expandapk"
+	"chainguard.dev/apko/pkg/apk/signature"
+)
+
+// Field is a key and value of .PKGINFO, in file order
+type Field struct {
+	Key   string
+	Value string
+}
+
+// Script is an install script of the control segment, such as .post-install
+type Script struct {
+	Name    string
+	Content string
+}
+
+// Entry is a file, directory or link of the data segment
+type Entry struct {
+	Name string
+	Mode fs.FileMode
+	Size int64
+	UID  int
+	GID  int
+
+	// Link is the target of a symbolic or hard link
+	Link string
+}
+
+// Signature is a signature of the control segment
+type Signature struct {
+	// KeyName is the file name of the public key, e.g. wolfi-signing.rsa.pub
+	KeyName string
+
+	// Hash is the digest the signature was made over, SHA-1 for .SIGN.RSA.
+	// and SHA-256 for .SIGN.RSA256. entries
+	Hash  crypto.Hash
+	Value []byte
+}
+
+// File is the content of a .apk file: the gzip compressed signature, control
+// and data segments, concatenated
+type File struct {
+	// Size is the size of the .apk file in bytes
+	Size int64
+
+	// Package holds the .PKGINFO fields as an index entry. Its checksum is the
+	// SHA-1 of the control segment, like the C: field of an APKINDEX.
+	Package *apk.Package
+	Fields  []Field
+
+	Signatures []Signature
+	Scripts    []Script
+	Entries    []Entry
+
+	// DataHash is the SHA-256 of the data segment, which .PKGINFO records
+	// in its datahash field
+	DataHash string
+
+	controlDigests map[crypto.Hash][]byte
+}
+
+// Open reads a .apk file from disk
+func Open(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return Read(f, info.Size())
+}
+
+// Read reads a .apk file of the given size
+func Read(r io.Reader, size int64) (*File, error) {
+	parts, err := expandapk.Split(r)
+	if err != nil {
+		return nil, fmt.Errorf("splitting apk: %w", err)
+	}
+
+	file := &File{Size: size}
+	if len(parts) == 3 {
+		if file.Signatures, err = readSignatures(parts[0]); err != nil {
+			return nil, err
+		}
+		parts = parts[1:]
+	}
+	if err := file.readControl(parts[0]); err != nil {
+		return nil, err
+	}
+	if err := file.readData(parts[1]); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// readSignatures reads the .SIGN.* entries of the signature segment
+func readSignatures(segment io.Reader) ([]Signature, error) {
+	var signatures []Signature
+	err := walk(segment, func(hdr *tar.Header, r io.Reader) error {
+		var hash crypto.Hash
+		var keyName string
+		switch {
+		case strings.HasPrefix(hdr.Name, ".SIGN.RSA256."):
+			hash, keyName = crypto.SHA256, strings.TrimPrefix(hdr.Name, ".SIGN.RSA256.")
+		case strings.HasPrefix(hdr.Name, ".SIGN.RSA."):
+			hash, keyName = crypto.SHA1, strings.TrimPrefix(hdr.Name, ".SIGN.RSA.")
+		default:
+			return nil
+		}
+		value, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		signatures = append(signatures, Signature{KeyName: keyName, Hash: hash, Value: value})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading signature segment: %w", err)
+	}
+	return signatures, nil
+}
+
+// readControl reads .PKGINFO and the install scripts, and records the digests
+// the signatures and the index checksum are computed over
+func (f *File) readControl(segment io.Reader) error {
+	control, err := io.ReadAll(segment)
+	if err != nil {
+		return fmt.Errorf("reading control segment: %w", err)
+	}
+	sha1Digest := sha1.Sum(control)
+	sha256Digest := sha256.Sum256(control)
+	f.controlDigests = map[crypto.Hash][]byte{
+		crypto.SHA1:   sha1Digest[:],
+		crypto.SHA256: sha256Digest[:],
+	}
+
+	var pkginfo []byte
+	err = walk(bytes.NewReader(control), func(hdr *tar.Header, r io.Reader) error {
+		content, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		if hdr.Name == ".PKGINFO" {
+			pkginfo = content
+		} else if strings.HasPrefix(hdr.Name, ".") && hdr.Typeflag == tar.TypeReg {
+			f.Scripts = append(f.Scripts, Script{Name: hdr.Name, Content: string(content)})
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("reading control segment: %w", err)
+	}
+	if pkginfo == nil {
+		return errors.New("no .PKGINFO in the control segment")
+	}
+
+	f.Fields = parseFields(pkginfo)
+	f.Package = packageOf(f.Fields)
+	f.Package.Checksum = sha1Digest[:]
+	f.Package.Size = uint64(f.Size)
+	return nil
+}
+
+// readData lists the entries of the data segment and computes its digest
+func (f *File) readData(segment io.Reader) error {
+	digest := sha256.New()
+	err := walk(io.TeeReader(segment, digest), func(hdr *tar.Header, r io.Reader) error {
+		f.Entries = append(f.Entries, Entry{
+			Name: hdr.Name,
+			Mode: hdr.FileInfo().Mode(),
+			Size: hdr.Size,
+			UID:  hdr.Uid,
+			GID:  hdr.Gid,
+			Link: hdr.Linkname,
+		})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("reading data segment: %w", err)
+	}
+	if _, err := io.Copy(digest, segment); err != nil {
+		return fmt.Errorf("reading data segment: %w", err)
+	}
+	f.DataHash = hex.EncodeToString(digest.Sum(nil))
+	return nil
+}
+
+// walk calls fn for every entry of a gzip compressed tar stream, reading the
+// stream to its end
+func walk(segment io.Reader, fn func(hdr *tar.Header, r io.Reader) error) error {
+	zr, err := gzip.NewReader(bufio.NewReader(segment))
+	if err != nil {
+		return err
+	}
+	zr.Multistream(false)
+
+	tr := tar.NewReader(zr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(hdr, tr); err != nil {
+			return err
+		}
+	}
+
+	// Drain the tar padding, so the digest of a data segment covers all of it
+	_, err = io.Copy(io.Discard, zr)
+	return err
+}
+
+// parseFields parses the "key = value" lines of .PKGINFO
+func parseFields(pkginfo []byte) []Field {
+	var fields []Field
+	for _, line := range strings.Split(string(pkginfo), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		fields = append(fields, Field{Key: strings.TrimSpace(key), Value: strings.TrimSpace(value)})
+	}
+	return fields
+}
+
+// packageOf builds an index entry from the .PKGINFO fields
+func packageOf(fields []Field) *apk.Package {
+	pkg := &apk.Package{}
+	for _, field := range fields {
+		switch field.Key {
+		case "pkgname":
+			pkg.Name = field.Value
+		case "pkgver":
+			pkg.Version = field.Value
+		case "arch":
+			pkg.Arch = field.Value
+		case "pkgdesc":
+			pkg.Description = field.Value
+		case "license":
+			pkg.License = field.Value
+		case "origin":
+			pkg.Origin = field.Value
+		case "maintainer":
+			pkg.Maintainer = field.Value
+		case "url":
+			pkg.URL = field.Value
+		case "commit":
+			pkg.RepoCommit = field.Value
+		case "datahash":
+			pkg.DataHash = field.Value
+		case "depend":
+			pkg.Dependencies = append(pkg.Dependencies, field.Value)
+		case "provides":
+			pkg.Provides = append(pkg.Provides, field.Value)
+		case "install_if":
+			pkg.InstallIf = append(pkg.InstallIf, strings.Fields(field.Value)...)
+		case "replaces":
+			pkg.Replaces = append(pkg.Replaces, field.Value)
+		case "size":
+			pkg.InstalledSize, _ = strconv.ParseUint(field.Value, 10, 64)
+		case "provider_priority":
+			pkg.ProviderPriority, _ = strconv.ParseUint(field.Value, 10, 64)
+		case "builddate":
+			pkg.BuildDate, _ = strconv.ParseInt(field.Value, 10, 64)
+			pkg.BuildTime = time.Unix(pkg.BuildDate, 0).UTC()
+		}
+	}
+	return pkg
+}
+
+// Verify checks a signature against a PEM encoded RSA public key
+func (f *File) Verify(sig Signature, publicKey []byte) error {
+	return signature.RSAVerifyDigest(f.controlDigests[sig.Hash], sig.Hash, sig.Value, publicKey)
+}
+
+// Mismatch is a field whose value in the .apk file differs from the index
+type Mismatch struct {
+	Field string
+	File  string
+	Index string
+}
+
+// Compare compares the metadata of the file with an index entry of the same
+// package
+func (f *File) Compare(entry *apk.Package) []Mismatch {
+	pkg := f.Package
+	fields := []struct {
+		name        string
+		file, index string
+	}{
+		{"arch", pkg.Arch, entry.Arch},
+		{"description", pkg.Description, entry.Description},
+		{"license", pkg.License, entry.License},
+		{"origin", pkg.Origin, entry.Origin},
+		{"maintainer", pkg.Maintainer, entry.Maintainer},
+		{"url", pkg.URL, entry.URL},
+		{"commit", pkg.RepoCommit, entry.RepoCommit},
+		{"dependencies", strings.Join(pkg.Dependencies, " "), strings.Join(entry.Dependencies, " ")},
+		{"provides", strings.Join(pkg.Provides, " "), strings.Join(entry.Provides, " ")},
+		{"install_if", strings.Join(pkg.InstallIf, " "), strings.Join(entry.InstallIf, " ")},
+		{"provider_priority", strconv.FormatUint(pkg.ProviderPriority, 10), strconv.FormatUint(entry.ProviderPriority, 10)},
+		{"build date", strconv.FormatInt(pkg.BuildDate, 10), strconv.FormatInt(entry.BuildDate, 10)},
+		{"installed size", strconv.FormatUint(pkg.InstalledSize, 10), strconv.FormatUint(entry.InstalledSize, 10)},
+		{"size", strconv.FormatUint(pkg.Size, 10), strconv.FormatUint(entry.Size, 10)},
+		{"checksum", pkg.ChecksumString(), entry.ChecksumString()},
+	}
+
+	var mismatches []Mismatch
+	for _, field := range fields {
+		if field.file != field.index {
+			mismatches = append(mismatches, Mismatch{Field: field.name, File: field.file, Index: field.index})
+		}
+	}
+	return mismatches
+}
@@ -0,0 +1,203 @@
+package apkfile
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type tarEntry struct {
+	name    string
+	mode    int64
+	content string
+	link    string
+}
+
+// segment returns a gzip compressed tar stream of the entries
+func segment(t *testing.T, entries []tarEntry, end bool) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(zw)
+	for _, entry := range entries {
+		hdr := &tar.Header{Name: entry.name, Mode: entry.mode, Size: int64(len(entry.content)), Typeflag: tar.TypeReg}
+		switch {
+		case entry.link != "":
+			hdr.Typeflag, hdr.Linkname, hdr.Size = tar.TypeSymlink, entry.link, 0
+		case strings.HasSuffix(entry.name, "/"):
+			hdr.Typeflag = tar.TypeDir
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(entry.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Like abuild, only the data segment has the end of archive marker
+	if end {
+		if err := tw.Close(); err != nil {
+			t.Fatal(err)
+		}
+	} else if err := tw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// buildAPK writes a signed .apk file and returns its path and the public key
+func buildAPK(t *testing.T) (string, []byte) {
+	data := segment(t, []tarEntry{
+		{name: "usr/", mode: 0o755},
+		{name: "usr/bin/", mode: 0o755},
+		{name: "usr/bin/hello", mode: 0o755, content: "#!/bin/sh\necho hello\n"},
+		{name: "usr/bin/hi", mode: 0o777, link: "hello"},
+	}, true)
+	datahash := sha256.Sum256(data)
+
+	pkginfo := strings.Join([]string{
+		"# Generated by melange",
+		"pkgname = hello",
+		"pkgver = 1.0-r0",
+		"arch = x86_64",
+		"size = 21",
+		"origin = hello",
+		"pkgdesc = Says hello",
+		"license = MIT",
+		"builddate = 1700000000",
+		"depend = busybox",
+		"depend = so:libc.so.6",
+		"provides = cmd:hello=1.0-r0",
+		"datahash = " + hex.EncodeToString(datahash[:]),
+	}, "\n") + "\n"
+	control := segment(t, []tarEntry{
+		{name: ".PKGINFO", mode: 0o644, content: pkginfo},
+		{name: ".post-install", mode: 0o755, content: "#!/bin/sh\necho installed\n"},
+	}, false)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha1.Sum(control)
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA1, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature := segment(t, []tarEntry{{name: ".SIGN.RSA.test.rsa.pub", mode: 0o644, content: string(sig)}}, false)
+
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	path := filepath.Join(t.TempDir(), "hello-1.0-r0.apk")
+	if err := os.WriteFile(path, append(append(signature, control...), data...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path, publicKey
+}
+
+func TestOpen(t *testing.T) {
+	path, publicKey := buildAPK(t)
+
+	file, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	pkg := file.Package
+	if pkg.Name != "hello" || pkg.Version != "1.0-r0" || pkg.InstalledSize != 21 || pkg.BuildDate != 1700000000 {
+		t.Errorf("Unexpected package: %+v", pkg)
+	}
+	if !reflect.DeepEqual(pkg.Dependencies, []string{"busybox", "so:libc.so.6"}) {
+		t.Errorf("Dependencies = %v", pkg.Dependencies)
+	}
+	if len(file.Fields) != 12 || file.Fields[0] != (Field{Key: "pkgname", Value: "hello"}) {
+		t.Errorf("Unexpected fields: %v", file.Fields)
+	}
+	if pkg.DataHash != file.DataHash {
+		t.Errorf("Data hash %s does not match .PKGINFO %s", file.DataHash, pkg.DataHash)
+	}
+
+	if len(file.Scripts) != 1 || file.Scripts[0].Name != ".post-install" {
+		t.Errorf("Unexpected scripts: %v", file.Scripts)
+	}
+
+	var names []string
+	for _, entry := range file.Entries {
+		names = append(names, entry.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"usr/", "usr/bin/", "usr/bin/hello", "usr/bin/hi"}) {
+		t.Errorf("Entries = %v", names)
+	}
+	if link := file.Entries[3]; link.Link != "hello" || link.Mode.Type() != os.ModeSymlink {
+		t.Errorf("Unexpected link entry: %+v", link)
+	}
+
+	if len(file.Signatures) != 1 || file.Signatures[0].KeyName != "test.rsa.pub" || file.Signatures[0].Hash != crypto.SHA1 {
+		t.Fatalf("Unexpected signatures: %+v", file.Signatures)
+	}
+	if err := file.Verify(file.Signatures[0], publicKey); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+	_, otherKey := buildAPK(t)
+	if err := file.Verify(file.Signatures[0], otherKey); err == nil {
+		t.Errorf("Expected verification with another key to fail")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	path, _ := buildAPK(t)
+	file, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	entry := *file.Package
+	if mismatches := file.Compare(&entry); len(mismatches) != 0 {
+		t.Errorf("Expected no mismatches with the same metadata, got %v", mismatches)
+	}
+
+	entry.License = "Apache-2.0"
+	entry.Checksum = []byte("other")
+	entry.Dependencies = []string{"busybox"}
+	mismatches := file.Compare(&entry)
+
+	var fields []string
+	for _, mismatch := range mismatches {
+		fields = append(fields, mismatch.Field)
+	}
+	if !reflect.DeepEqual(fields, []string{"license", "dependencies", "checksum"}) {
+		t.Errorf("Mismatched fields = %v", fields)
+	}
+	if mismatches[0] != (Mismatch{Field: "license", File: "MIT", Index: "Apache-2.0"}) {
+		t.Errorf("Unexpected mismatch: %+v", mismatches[0])
+	}
+}
+
+func TestOpenInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "invalid.apk")
+	if err := os.WriteFile(path, []byte("not an apk"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(path); err == nil {
+		t.Errorf("Expected an error for an invalid file")
+	}
+}
@@ -0,0 +1,81 @@
+package apkoconfig
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the subset of an apko image configuration used by the tools
+type Config struct {
+	Contents struct {
+		BuildRepositories []string `yaml:"build_repositories"`
+		Repositories      []string `yaml:"repositories"`
+		Keyring           []string `yaml:"keyring"`
+		Packages          []string `yaml:"packages"`
+	} `yaml:"contents"`
+	Archs []string `yaml:"archs"`
+}
+
+// Parse parses an apko YAML configuration
+func Parse(data []byte) (*Config, error) {
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("error parsing apko configuration: %w", err)
+	}
+	return &cfg, nil
+}
+
+// Read parses the inline configuration if given, or the configuration file at path
+func Read(inline, path string) (*Config, error) {
+	if inline != "" {
+		return Parse([]byte(inline))
+	}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading apko configuration: %w", err)
+		}
+		return Parse(data)
+	}
+	return nil, errors.New("either an inline configuration or a path must be provided")
+}
+
+// AllRepositories returns the build repositories followed by the runtime repositories
+func (c *Config) AllRepositories() []string {
+	return append(append([]string{}, c.Contents.BuildRepositories...), c.Contents.Repositories...)
+}
+
+// Arch returns the apk architecture of a single-architecture configuration,
+// or an empty string when it lists none or several
+func (c *Config) Arch() string {
+	if len(c.Archs) != 1 {
+		return ""
+	}
+	return APKArch(c.Archs[0])
+}
+
+// RepositoryLocation strips the optional "@tag " prefix of an apko repository entry
+func RepositoryLocation(repository string) string {
+	if strings.HasPrefix(repository, "@") {
+		if _, location, ok := strings.Cut(repository, " "); ok {
+			return strings.TrimSpace(location)
+		}
+	}
+	return repository
+}
+
+// APKArch maps an OCI architecture name to the apk architecture name
+func APKArch(arch string) string {
+	switch arch {
+	case "amd64":
+		return "x86_64"
+	case "arm64":
+		return "aarch64"
+	default:
+		return arch
+	}
+}
@@ -0,0 +1,71 @@
+package apkoconfig
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testConfig = `
+contents:
+  build_repositories:
+    - "@local /work/packages"
+  repositories:
+    - https://packages.wolfi.dev/os
+  keyring:
+    - https://packages.wolfi.dev/os/wolfi-signing.rsa.pub
+  packages:
+    - wolfi-base
+    - curl>8
+archs:
+  - arm64
+`
+
+func TestRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "apko.yaml")
+	if err := os.WriteFile(path, []byte(testConfig), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	for name, read := range map[string]func() (*Config, error){
+		"inline": func() (*Config, error) { return Read(testConfig, "") },
+		"path":   func() (*Config, error) { return Read("", path) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := read()
+			if err != nil {
+				t.Fatalf("Read failed: %v", err)
+			}
+			if !reflect.DeepEqual(cfg.Contents.Packages, []string{"wolfi-base", "curl>8"}) {
+				t.Errorf("Unexpected packages: %v", cfg.Contents.Packages)
+			}
+			if !reflect.DeepEqual(cfg.AllRepositories(), []string{"@local /work/packages", "https://packages.wolfi.dev/os"}) {
+				t.Errorf("Unexpected repositories: %v", cfg.AllRepositories())
+			}
+			if cfg.Arch() != "aarch64" {
+				t.Errorf("Expected arch aarch64, got %q", cfg.Arch())
+			}
+		})
+	}
+
+	if _, err := Read("", ""); err == nil {
+		t.Error("Expected error without a configuration")
+	}
+	if _, err := Read("contents: [", ""); err == nil {
+		t.Error("Expected error for invalid YAML")
+	}
+}
+
+func TestRepositoryLocation(t *testing.T) {
+	testCases := map[string]string{
+		"https://packages.wolfi.dev/os": "https://packages.wolfi.dev/os",
+		"@local /work/packages":         "/work/packages",
+		"@tag":                          "@tag",
+	}
+	for repository, expected := range testCases {
+		if got := RepositoryLocation(repository); got != expected {
+			t.Errorf("RepositoryLocation(%q) = %q, want %q", repository, got, expected)
+		}
+	}
+}
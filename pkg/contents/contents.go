@@ -0,0 +1,267 @@
+package contents
+
+import (
+	"bufio"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dlorenc/wolfi-mcp/pkg/apkfile"
+)
+
+// formatVersion is bumped whenever the index file layout changes
+const formatVersion = 1
+
+// Package is a scanned .apk file and the files it installs
+type Package struct {
+	Name    string
+	Version string
+	Arch    string
+
+	// Path is the location of the .apk file. Size and ModTime tell whether
+	// it changed since it was scanned.
+	Path    string
+	Size    int64
+	ModTime time.Time
+
+	// Files holds the paths of the regular files and links, without a leading
+	// slash, sorted
+	Files []string
+}
+
+// Owner is a file and a package that installs it
+type Owner struct {
+	File    string
+	Package *Package
+}
+
+// Index maps the files of a set of .apk archives to their packages
+type Index struct {
+	Packages []*Package
+}
+
+// file is the on-disk layout of an index
+type file struct {
+	Version  int
+	Packages []*Package
+}
+
+// Scan builds an index of the .apk files found under the directories. The
+// packages of a previous index are reused for the files that did not change.
+// Files that cannot be read are passed to warn and left out.
+func Scan(dirs []string, previous *Index, workers int, warn func(path string, err error)) (*Index, error) {
+	var paths []string
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && strings.HasSuffix(d.Name(), ".apk") {
+				paths = append(paths, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error scanning %s: %w", dir, err)
+		}
+	}
+
+	known := make(map[string]*Package)
+	if previous != nil {
+		for _, pkg := range previous.Packages {
+			known[pkg.Path] = pkg
+		}
+	}
+
+	if workers < 1 {
+		workers = 1
+	}
+	scanned := make([]*Package, len(paths))
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				pkg, err := scan(paths[i], known[paths[i]])
+				if err != nil {
+					warn(paths[i], err)
+					continue
+				}
+				scanned[i] = pkg
+			}
+		}()
+	}
+	for i := range paths {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
+	index := &Index{}
+	for _, pkg := range scanned {
+		if pkg != nil {
+			index.Packages = append(index.Packages, pkg)
+		}
+	}
+	return index, nil
+}
+
+// scan reads the file list of one .apk file, unless the previous scan of the
+// same file is still current
+func scan(p string, previous *Package) (*Package, error) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+	if previous != nil && previous.Size == info.Size() && previous.ModTime.Equal(info.ModTime()) {
+		return previous, nil
+	}
+
+	apk, err := apkfile.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	pkg := &Package{
+		Name:    apk.Package.Name,
+		Version: apk.Package.Version,
+		Arch:    apk.Package.Arch,
+		Path:    p,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	}
+	for _, entry := range apk.Entries {
+		if entry.Mode.IsDir() {
+			continue
+		}
+		pkg.Files = append(pkg.Files, strings.TrimPrefix(entry.Name, "/"))
+	}
+	sort.Strings(pkg.Files)
+	return pkg, nil
+}
+
+// Files returns the number of files in the index
+func (ix *Index) Files() int {
+	n := 0
+	for _, pkg := range ix.Packages {
+		n += len(pkg.Files)
+	}
+	return n
+}
+
+// Owners returns the packages installing a path
+func (ix *Index) Owners(p string) []Owner {
+	p = strings.TrimPrefix(path.Clean("/"+p), "/")
+
+	var owners []Owner
+	for _, pkg := range ix.Packages {
+		if i := sort.SearchStrings(pkg.Files, p); i < len(pkg.Files) && pkg.Files[i] == p {
+			owners = append(owners, Owner{File: p, Package: pkg})
+		}
+	}
+	sortOwners(owners)
+	return owners
+}
+
+// Glob returns the files matching a path.Match pattern and the packages
+// installing them. A pattern without a slash matches the file names in any
+// directory, a pattern with one matches whole paths.
+func (ix *Index) Glob(pattern string) ([]Owner, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	base := !strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	var owners []Owner
+	for _, pkg := range ix.Packages {
+		for _, f := range pkg.Files {
+			name := f
+			if base {
+				name = path.Base(f)
+			}
+			if ok, _ := path.Match(pattern, name); ok {
+				owners = append(owners, Owner{File: f, Package: pkg})
+			}
+		}
+	}
+	sortOwners(owners)
+	return owners, nil
+}
+
+// Load reads an index written by Store. A missing, stale or corrupted file
+// gives an empty index, which a scan then fills.
+func Load(p string) (*Index, error) {
+	f, err := os.Open(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Index{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error opening contents index: %w", err)
+	}
+	defer f.Close()
+
+	var stored file
+	if err := gob.NewDecoder(bufio.NewReader(f)).Decode(&stored); err != nil || stored.Version != formatVersion {
+		return &Index{}, nil
+	}
+	return &Index{Packages: stored.Packages}, nil
+}
+
+// Store writes the index to a file
+func (ix *Index) Store(p string) error {
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return fmt.Errorf("error creating contents index directory: %w", err)
+	}
+
+	// Write to a temporary file first so a crash never leaves a truncated index
+	tmp := p + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("error writing contents index: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	if err := gob.NewEncoder(w).Encode(&file{Version: formatVersion, Packages: ix.Packages}); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("error encoding contents index: %w", err)
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("error writing contents index: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("error writing contents index: %w", err)
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		return fmt.Errorf("error writing contents index: %w", err)
+	}
+	return nil
+}
+
+func sortOwners(owners []Owner) {
+	sort.Slice(owners, func(i, j int) bool {
+		a, b := owners[i], owners[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Package.Name != b.Package.Name {
+			return a.Package.Name < b.Package.Name
+		}
+		if a.Package.Version != b.Package.Version {
+			return a.Package.Version < b.Package.Version
+		}
+		return a.Package.Path < b.Package.Path
+	})
+}
@@ -0,0 +1,181 @@
+package contents
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// writeAPK writes an unsigned .apk file installing the given files
+func writeAPK(t *testing.T, path, name, version string, files ...string) {
+	segment := func(entries map[string]byte) []byte {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(zw)
+		for _, name := range sortedKeys(entries) {
+			if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o755, Typeflag: entries[name]}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := tw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	var control bytes.Buffer
+	zw := gzip.NewWriter(&control)
+	tw := tar.NewWriter(zw)
+	pkginfo := "pkgname = " + name + "\npkgver = " + version + "\narch = x86_64\n"
+	if err := tw.WriteHeader(&tar.Header{Name: ".PKGINFO", Mode: 0o644, Size: int64(len(pkginfo))}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write([]byte(pkginfo)); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := map[string]byte{"usr/": tar.TypeDir, "usr/bin/": tar.TypeDir}
+	for _, f := range files {
+		entries[f] = tar.TypeReg
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, append(control.Bytes(), segment(entries)...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func sortedKeys(m map[string]byte) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestScan(t *testing.T) {
+	dir := t.TempDir()
+	writeAPK(t, filepath.Join(dir, "x86_64", "gettext-0.22-r0.apk"), "gettext", "0.22-r0", "usr/bin/envsubst", "usr/bin/gettext")
+	writeAPK(t, filepath.Join(dir, "x86_64", "busybox-1.36-r0.apk"), "busybox", "1.36-r0", "usr/bin/busybox", "usr/bin/envsubst")
+	if err := os.WriteFile(filepath.Join(dir, "broken.apk"), []byte("not an apk"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var warned []string
+	warn := func(path string, err error) { warned = append(warned, filepath.Base(path)) }
+	index, err := Scan([]string{dir}, nil, 2, warn)
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if len(index.Packages) != 2 || index.Files() != 4 {
+		t.Fatalf("Scanned %d packages with %d files, want 2 and 4", len(index.Packages), index.Files())
+	}
+	if !reflect.DeepEqual(warned, []string{"broken.apk"}) {
+		t.Errorf("Warned about %v, want broken.apk", warned)
+	}
+
+	// Exact paths, with or without the leading slash
+	owners := index.Owners("/usr/bin/envsubst")
+	if len(owners) != 2 || owners[0].Package.Name != "busybox" || owners[1].Package.Name != "gettext" {
+		t.Errorf("Unexpected owners of /usr/bin/envsubst: %+v", owners)
+	}
+	if owners := index.Owners("usr/bin/gettext"); len(owners) != 1 {
+		t.Errorf("Unexpected owners of usr/bin/gettext: %+v", owners)
+	}
+	if owners := index.Owners("/usr/bin"); len(owners) != 0 {
+		t.Errorf("Directories should not be owned, got %+v", owners)
+	}
+
+	// File name and path globs
+	testCases := map[string][]string{
+		"gettext*":     {"usr/bin/gettext"},
+		"/usr/bin/b*":  {"usr/bin/busybox"},
+		"usr/*/envsu*": {"usr/bin/envsubst", "usr/bin/envsubst"},
+		"*.so":         nil,
+	}
+	for pattern, expected := range testCases {
+		owners, err := index.Glob(pattern)
+		if err != nil {
+			t.Fatalf("Glob(%q) error = %v", pattern, err)
+		}
+		var files []string
+		for _, owner := range owners {
+			files = append(files, owner.File)
+		}
+		if !reflect.DeepEqual(files, expected) {
+			t.Errorf("Glob(%q) = %v, want %v", pattern, files, expected)
+		}
+	}
+	if _, err := index.Glob("[usr"); err == nil {
+		t.Errorf("Expected an error for an invalid pattern")
+	}
+}
+
+func TestStoreAndRescan(t *testing.T) {
+	dir := t.TempDir()
+	apk := filepath.Join(dir, "gettext-0.22-r0.apk")
+	writeAPK(t, apk, "gettext", "0.22-r0", "usr/bin/envsubst")
+
+	index, err := Scan([]string{dir}, nil, 1, func(string, error) {})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "cache", "contents.gob")
+	if err := index.Store(path); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(loaded.Packages) != 1 || !reflect.DeepEqual(loaded.Packages[0].Files, []string{"usr/bin/envsubst"}) {
+		t.Fatalf("Unexpected loaded index: %+v", loaded.Packages)
+	}
+
+	// Unchanged files are reused from the previous index
+	rescanned, err := Scan([]string{dir}, loaded, 1, func(string, error) {})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if rescanned.Packages[0] != loaded.Packages[0] {
+		t.Errorf("Expected the unchanged package to be reused")
+	}
+
+	// Changed files are read again
+	writeAPK(t, apk, "gettext", "0.22-r1", "usr/bin/envsubst", "usr/bin/gettext")
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(apk, later, later); err != nil {
+		t.Fatal(err)
+	}
+	rescanned, err = Scan([]string{dir}, loaded, 1, func(string, error) {})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if rescanned.Packages[0].Version != "0.22-r1" || len(rescanned.Packages[0].Files) != 2 {
+		t.Errorf("Expected the changed package to be read again, got %+v", rescanned.Packages[0])
+	}
+
+	// A missing index is empty
+	empty, err := Load(filepath.Join(t.TempDir(), "missing.gob"))
+	if err != nil || len(empty.Packages) != 0 {
+		t.Errorf("Load() of a missing file = %+v, %v", empty, err)
+	}
+}
@@ -0,0 +1,288 @@
+package indexaudit
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/dlorenc/wolfi-mcp/pkg/resolve"
+)
+
+// Issue is a dependency of a package that cannot be resolved
+type Issue struct {
+	Package    *apk.Package
+	Dependency string
+
+	// Err is resolve.ErrNotFound or resolve.ErrUnsatisfiable
+	Err error
+}
+
+// BrokenClosure is a package whose own dependencies resolve, but whose
+// install closure pulls in a package with an issue
+type BrokenClosure struct {
+	Package *apk.Package
+
+	// Path is the chain of package names from Package to the one with the issue
+	Path []string
+
+	// Cause is the first issue found in the closure
+	Cause Issue
+}
+
+// Report is the result of auditing the dependencies of a set of packages
+type Report struct {
+	// Checked is the number of packages audited
+	Checked int
+
+	// Missing holds dependencies on names that no package provides
+	Missing []Issue
+
+	// Unsatisfiable holds version constraints that no available version satisfies
+	Unsatisfiable []Issue
+
+	// DanglingShared holds so: requirements that no package provides
+	DanglingShared []Issue
+
+	// BrokenClosures holds packages whose install closure fails to resolve
+	BrokenClosures []BrokenClosure
+}
+
+// Latest returns the highest version of each package name, sorted by name
+func Latest(packages []*apk.Package) []*apk.Package {
+	latest := make(map[string]*apk.Package, len(packages))
+	for _, pkg := range packages {
+		if existing, ok := latest[pkg.Name]; !ok || resolve.CompareVersions(pkg.Version, existing.Version) > 0 {
+			latest[pkg.Name] = pkg
+		}
+	}
+
+	result := make([]*apk.Package, 0, len(latest))
+	for _, pkg := range latest {
+		result = append(result, pkg)
+	}
+	sortPackages(result)
+	return result
+}
+
+// Run checks every dependency of the given packages against the repository
+func Run(repo *apkindex.Repository, packages []*apk.Package) *Report {
+	a := &auditor{
+		resolver: resolve.New(repo),
+		issues:   make(map[*apk.Package][]Issue),
+		checked:  make(map[*apk.Package]bool),
+		cause:    make(map[*apk.Package][]string),
+		last:     make(map[*apk.Package]*apk.Package),
+		done:     make(map[*apk.Package]bool),
+		order:    make(map[*apk.Package]int),
+		low:      make(map[*apk.Package]int),
+		onStack:  make(map[*apk.Package]bool),
+	}
+
+	report := &Report{Checked: len(packages)}
+	for _, pkg := range packages {
+		for _, issue := range a.directIssues(pkg) {
+			switch {
+			case errors.Is(issue.Err, resolve.ErrUnsatisfiable):
+				report.Unsatisfiable = append(report.Unsatisfiable, issue)
+			case strings.HasPrefix(issue.Dependency, "so:"):
+				report.DanglingShared = append(report.DanglingShared, issue)
+			default:
+				report.Missing = append(report.Missing, issue)
+			}
+		}
+	}
+
+	for _, pkg := range packages {
+		if len(a.directIssues(pkg)) > 0 {
+			// Already reported above
+			continue
+		}
+		if path := a.brokenPath(pkg); path != nil {
+			report.BrokenClosures = append(report.BrokenClosures, BrokenClosure{
+				Package: pkg,
+				Path:    path,
+				Cause:   a.issues[a.last[pkg]][0],
+			})
+		}
+	}
+
+	return report
+}
+
+// Empty reports whether the audit found no issues
+func (r *Report) Empty() bool {
+	return len(r.Missing) == 0 && len(r.Unsatisfiable) == 0 && len(r.DanglingShared) == 0 && len(r.BrokenClosures) == 0
+}
+
+// String formats the report as text
+func (r *Report) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Audited the dependencies of %d packages.\n", r.Checked))
+
+	if r.Empty() {
+		sb.WriteString("\nNo issues found.\n")
+		return sb.String()
+	}
+
+	writeIssues(&sb, "Dependencies with no provider", r.Missing)
+	writeIssues(&sb, "Version constraints no available version satisfies", r.Unsatisfiable)
+	writeIssues(&sb, "Dangling so: requirements", r.DanglingShared)
+
+	if len(r.BrokenClosures) > 0 {
+		sb.WriteString(fmt.Sprintf("\nPackages whose install closure fails to resolve (%d):\n", len(r.BrokenClosures)))
+		for _, broken := range r.BrokenClosures {
+			sb.WriteString(fmt.Sprintf("- %s (%s): %s needs %s\n", broken.Package.Name, broken.Package.Version,
+				strings.Join(broken.Path, " -> "), broken.Cause.Dependency))
+		}
+	}
+
+	return sb.String()
+}
+
+func writeIssues(sb *strings.Builder, title string, issues []Issue) {
+	if len(issues) == 0 {
+		return
+	}
+	sb.WriteString(fmt.Sprintf("\n%s (%d):\n", title, len(issues)))
+	for _, issue := range issues {
+		sb.WriteString(fmt.Sprintf("- %s (%s): %s\n", issue.Package.Name, issue.Package.Version, issue.Dependency))
+	}
+}
+
+type auditor struct {
+	resolver *resolve.Resolver
+	issues   map[*apk.Package][]Issue
+	checked  map[*apk.Package]bool
+
+	// cause holds the path to the first package with an issue in the closure
+	// of each package, nil if none, and last holds that package. They are
+	// final for the packages in done.
+	cause map[*apk.Package][]string
+	last  map[*apk.Package]*apk.Package
+	done  map[*apk.Package]bool
+
+	// Tarjan's algorithm state, which groups the packages of a dependency
+	// cycle so they are decided together
+	order   map[*apk.Package]int
+	low     map[*apk.Package]int
+	stack   []*apk.Package
+	onStack map[*apk.Package]bool
+}
+
+// directIssues returns the unresolvable dependencies of a package
+func (a *auditor) directIssues(pkg *apk.Package) []Issue {
+	if a.checked[pkg] {
+		return a.issues[pkg]
+	}
+	a.checked[pkg] = true
+
+	for _, dep := range pkg.Dependencies {
+		if strings.HasPrefix(dep, "!") {
+			// Conflicts don't need a provider
+			continue
+		}
+		if _, err := a.resolver.Resolve(dep); err != nil {
+			a.issues[pkg] = append(a.issues[pkg], Issue{Package: pkg, Dependency: dep, Err: err})
+		}
+	}
+	return a.issues[pkg]
+}
+
+// brokenPath returns the path from pkg to the first package of its closure
+// with an unresolvable dependency, or nil when the closure resolves
+func (a *auditor) brokenPath(pkg *apk.Package) []string {
+	if !a.done[pkg] {
+		a.visit(pkg)
+	}
+	return a.cause[pkg]
+}
+
+// dependencies returns the packages the dependencies of pkg resolve to
+func (a *auditor) dependencies(pkg *apk.Package) []*apk.Package {
+	var result []*apk.Package
+	for _, dep := range pkg.Dependencies {
+		if strings.HasPrefix(dep, "!") {
+			continue
+		}
+		if choice, err := a.resolver.Resolve(dep); err == nil {
+			result = append(result, choice.Package)
+		}
+	}
+	return result
+}
+
+// visit looks for broken closures depth first. A package whose closure is
+// found broken is final, but one on a dependency cycle that is still open is
+// not: another package of the cycle may reach an issue later. The packages of
+// a cycle are therefore only decided when the whole cycle has been visited.
+func (a *auditor) visit(pkg *apk.Package) {
+	a.order[pkg] = len(a.order)
+	a.low[pkg] = a.order[pkg]
+	a.stack = append(a.stack, pkg)
+	a.onStack[pkg] = true
+
+	if len(a.directIssues(pkg)) > 0 {
+		a.cause[pkg] = []string{pkg.Name}
+		a.last[pkg] = pkg
+	}
+
+	for _, dep := range a.dependencies(pkg) {
+		if _, seen := a.order[dep]; !seen {
+			a.visit(dep)
+			a.low[pkg] = min(a.low[pkg], a.low[dep])
+		} else if a.onStack[dep] {
+			a.low[pkg] = min(a.low[pkg], a.order[dep])
+		}
+		if a.cause[pkg] == nil && a.cause[dep] != nil {
+			a.cause[pkg] = append([]string{pkg.Name}, a.cause[dep]...)
+			a.last[pkg] = a.last[dep]
+		}
+	}
+
+	if a.low[pkg] != a.order[pkg] {
+		// pkg is on a cycle that is still open
+		return
+	}
+
+	// Pop the cycle pkg closes, or pkg alone
+	var cycle []*apk.Package
+	for {
+		top := a.stack[len(a.stack)-1]
+		a.stack = a.stack[:len(a.stack)-1]
+		a.onStack[top] = false
+		cycle = append(cycle, top)
+		if top == pkg {
+			break
+		}
+	}
+
+	// Every package of a cycle reaches the issues of the others
+	for changed := true; changed; {
+		changed = false
+		for _, member := range cycle {
+			if a.cause[member] != nil {
+				continue
+			}
+			for _, dep := range a.dependencies(member) {
+				if a.cause[dep] != nil {
+					a.cause[member] = append([]string{member.Name}, a.cause[dep]...)
+					a.last[member] = a.last[dep]
+					changed = true
+					break
+				}
+			}
+		}
+	}
+	for _, member := range cycle {
+		a.done[member] = true
+	}
+}
+
+func sortPackages(packages []*apk.Package) {
+	sort.Slice(packages, func(i, j int) bool {
+		return packages[i].Name < packages[j].Name
+	})
+}
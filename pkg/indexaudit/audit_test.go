@@ -0,0 +1,82 @@
+package indexaudit
+
+import (
+	"strings"
+	"testing"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+)
+
+func TestRun(t *testing.T) {
+	packages := []*apk.Package{
+		{Name: "app", Version: "1.0-r0", Dependencies: []string{"lib", "!conflict"}},
+		{Name: "lib", Version: "1.0-r0", Dependencies: []string{"so:libgone.so.1"}},
+		{Name: "lib", Version: "0.9-r0"},
+		{Name: "tool", Version: "1.0-r0", Dependencies: []string{"lib>2", "missing"}},
+		{Name: "cyclic-a", Version: "1.0-r0", Dependencies: []string{"cyclic-b"}},
+		{Name: "cyclic-b", Version: "1.0-r0", Dependencies: []string{"cyclic-a"}},
+	}
+	repo := apkindex.NewRepository(packages)
+
+	latest := Latest(packages)
+	if len(latest) != 5 || latest[3].Name != "lib" || latest[3].Version != "1.0-r0" {
+		t.Fatalf("Unexpected latest packages: %v", latest)
+	}
+
+	report := Run(repo, latest)
+	if report.Checked != 5 {
+		t.Errorf("Expected 5 packages to be checked, got %d", report.Checked)
+	}
+	if len(report.Missing) != 1 || report.Missing[0].Dependency != "missing" {
+		t.Errorf("Unexpected missing dependencies: %+v", report.Missing)
+	}
+	if len(report.Unsatisfiable) != 1 || report.Unsatisfiable[0].Dependency != "lib>2" {
+		t.Errorf("Unexpected unsatisfiable constraints: %+v", report.Unsatisfiable)
+	}
+	if len(report.DanglingShared) != 1 || report.DanglingShared[0].Package.Name != "lib" {
+		t.Errorf("Unexpected dangling so: requirements: %+v", report.DanglingShared)
+	}
+	if len(report.BrokenClosures) != 1 || report.BrokenClosures[0].Package.Name != "app" {
+		t.Fatalf("Unexpected broken closures: %+v", report.BrokenClosures)
+	}
+
+	text := report.String()
+	for _, check := range []string{
+		"Audited the dependencies of 5 packages.",
+		"Dependencies with no provider (1):\n- tool (1.0-r0): missing",
+		"Dangling so: requirements (1):\n- lib (1.0-r0): so:libgone.so.1",
+		"fails to resolve (1):\n- app (1.0-r0): app -> lib needs so:libgone.so.1",
+	} {
+		if !strings.Contains(text, check) {
+			t.Errorf("Expected report to contain %q, got:\n%s", check, text)
+		}
+	}
+
+	if clean := Run(repo, []*apk.Package{packages[4], packages[5]}); !clean.Empty() {
+		t.Errorf("Expected no issues for the dependency cycle, got:\n%s", clean)
+	}
+}
+
+func TestRunBrokenBehindCycle(t *testing.T) {
+	// a and b depend on each other, and only a reaches the broken c
+	a := &apk.Package{Name: "a", Version: "1.0-r0", Dependencies: []string{"b", "c"}}
+	b := &apk.Package{Name: "b", Version: "1.0-r0", Dependencies: []string{"a"}}
+	c := &apk.Package{Name: "c", Version: "1.0-r0", Dependencies: []string{"gone"}}
+	repo := apkindex.NewRepository([]*apk.Package{a, b, c})
+
+	// The result must not depend on the order the packages are visited in
+	for _, order := range [][]*apk.Package{{a, b, c}, {b, a, c}, {c, b, a}} {
+		report := Run(repo, order)
+		paths := make(map[string]string)
+		for _, broken := range report.BrokenClosures {
+			paths[broken.Package.Name] = strings.Join(broken.Path, " -> ")
+			if broken.Cause.Package != c {
+				t.Errorf("Unexpected cause for %s: %+v", broken.Package.Name, broken.Cause)
+			}
+		}
+		if len(paths) != 2 || paths["a"] != "a -> c" || paths["b"] != "b -> a -> c" {
+			t.Errorf("Order %s, %s, %s: unexpected broken closures %v", order[0].Name, order[1].Name, order[2].Name, paths)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package indexcache
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"github.com/dlorenc/wolfi-mcp/pkg/sources"
+)
+
+// formatVersion is bumped whenever the cache file layout changes
+const formatVersion = 2
+
+// cacheFile is the name of the cache file inside the cache directory
+const cacheFile = "repository.gob"
+
+// Input identifies an index the repository is built from
+type Input struct {
+	Location string
+	Path     string
+	Digest   string
+}
+
+// Key returns the cache key of a repository built from the given indexes, in order
+func Key(inputs []Input) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "v%d\n", formatVersion)
+	for _, input := range inputs {
+		fmt.Fprintf(h, "%s\n%s\n", input.Location, input.Digest)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// Entry is a parsed and merged repository
+type Entry struct {
+	// Sources holds the packages of each index, in command line order
+	Sources []sources.Source
+
+	// Merged holds the packages after merging the indexes
+	Merged []*apk.Package
+}
+
+// file is the on-disk layout. Packages are stored once and referenced by
+// index from the sources and the merged list.
+type file struct {
+	Version  int
+	Key      string
+	Packages []apk.Package
+	Sources  []fileSource
+	Merged   []int
+}
+
+type fileSource struct {
+	Location string
+	Path     string
+	Digest   string
+	Packages []int
+}
+
+// Cache persists the last parsed repository in a directory
+type Cache struct {
+	dir string
+}
+
+// Open opens the index cache in dir, creating it if needed
+func Open(dir string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating index cache directory: %w", err)
+	}
+	return &Cache{dir: dir}, nil
+}
+
+// Load returns the cached repository if it was built with the given key
+func (c *Cache) Load(key string) (*Entry, bool, error) {
+	f, err := os.Open(filepath.Join(c.dir, cacheFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("error opening index cache: %w", err)
+	}
+	defer f.Close()
+
+	var cached file
+	if err := gob.NewDecoder(bufio.NewReader(f)).Decode(&cached); err != nil {
+		// A stale or corrupted cache is simply rebuilt
+		return nil, false, nil
+	}
+	if cached.Version != formatVersion || cached.Key != key {
+		return nil, false, nil
+	}
+
+	packages := make([]*apk.Package, len(cached.Packages))
+	for i := range cached.Packages {
+		packages[i] = &cached.Packages[i]
+	}
+	resolve := func(indexes []int) ([]*apk.Package, error) {
+		result := make([]*apk.Package, 0, len(indexes))
+		for _, i := range indexes {
+			if i < 0 || i >= len(packages) {
+				return nil, fmt.Errorf("invalid package reference %d", i)
+			}
+			result = append(result, packages[i])
+		}
+		return result, nil
+	}
+
+	entry := &Entry{}
+	for _, src := range cached.Sources {
+		srcPackages, err := resolve(src.Packages)
+		if err != nil {
+			return nil, false, nil
+		}
+		entry.Sources = append(entry.Sources, sources.Source{Location: src.Location, Path: src.Path, Digest: src.Digest, Packages: srcPackages})
+	}
+	if entry.Merged, err = resolve(cached.Merged); err != nil {
+		return nil, false, nil
+	}
+	return entry, true, nil
+}
+
+// Store replaces the cached repository
+func (c *Cache) Store(key string, entry *Entry) error {
+	cached := file{Version: formatVersion, Key: key}
+	indexes := make(map[*apk.Package]int)
+	reference := func(packages []*apk.Package) []int {
+		result := make([]int, 0, len(packages))
+		for _, pkg := range packages {
+			i, ok := indexes[pkg]
+			if !ok {
+				i = len(cached.Packages)
+				indexes[pkg] = i
+				cached.Packages = append(cached.Packages, *pkg)
+			}
+			result = append(result, i)
+		}
+		return result
+	}
+
+	for _, src := range entry.Sources {
+		cached.Sources = append(cached.Sources, fileSource{Location: src.Location, Path: src.Path, Digest: src.Digest, Packages: reference(src.Packages)})
+	}
+	cached.Merged = reference(entry.Merged)
+
+	// Write to a temporary file first so a crash never leaves a truncated cache
+	tmp := filepath.Join(c.dir, cacheFile+".tmp")
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("error writing index cache: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	if err := gob.NewEncoder(w).Encode(&cached); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("error encoding index cache: %w", err)
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("error writing index cache: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("error writing index cache: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(c.dir, cacheFile)); err != nil {
+		return fmt.Errorf("error writing index cache: %w", err)
+	}
+	return nil
+}
+
+// FileDigest returns the sha256 of a file
+func FileDigest(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
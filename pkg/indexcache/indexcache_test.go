@@ -0,0 +1,63 @@
+package indexcache
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"github.com/dlorenc/wolfi-mcp/pkg/sources"
+)
+
+func TestCache(t *testing.T) {
+	cache, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	shared := &apk.Package{Name: "lib", Version: "1.0-r0", Checksum: []byte{1, 2}, Provides: []string{"so:lib.so.1=1"}}
+	entry := &Entry{
+		Sources: []sources.Source{
+			{Location: "a", Path: "/tmp/a.tar.gz", Digest: "1", Packages: []*apk.Package{shared, {Name: "app", Version: "1.0-r0"}}},
+			{Location: "b", Path: "/tmp/b.tar.gz", Packages: []*apk.Package{{Name: "app", Version: "2.0-r0"}}},
+		},
+	}
+	entry.Merged = []*apk.Package{shared, entry.Sources[1].Packages[0]}
+
+	key := Key([]Input{{Location: "a", Digest: "1"}, {Location: "b", Digest: "2"}})
+	if _, ok, err := cache.Load(key); ok || err != nil {
+		t.Fatalf("Expected a miss on an empty cache, got %v, %v", ok, err)
+	}
+
+	if err := cache.Store(key, entry); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+
+	loaded, ok, err := cache.Load(key)
+	if !ok || err != nil {
+		t.Fatalf("Expected a hit, got %v, %v", ok, err)
+	}
+	if !reflect.DeepEqual(loaded, entry) {
+		t.Errorf("Loaded entry differs:\n%+v\n%+v", loaded, entry)
+	}
+	if loaded.Sources[0].Packages[0] != loaded.Merged[0] {
+		t.Errorf("Expected packages shared between sources and the merged list to be shared after loading")
+	}
+
+	// Changing the order or digest of the indexes changes the key
+	if other := Key([]Input{{Location: "b", Digest: "2"}, {Location: "a", Digest: "1"}}); other == key {
+		t.Errorf("Expected the key to depend on the order of the indexes")
+	}
+	if _, ok, _ := cache.Load(Key([]Input{{Location: "a", Digest: "3"}})); ok {
+		t.Errorf("Expected a miss for different digests")
+	}
+
+	// A corrupted cache is treated as a miss
+	if err := os.WriteFile(filepath.Join(cache.dir, cacheFile), []byte("garbage"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := cache.Load(key); ok || err != nil {
+		t.Errorf("Expected a miss for a corrupted cache, got %v, %v", ok, err)
+	}
+}
@@ -0,0 +1,232 @@
+package indexdiff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"github.com/dlorenc/wolfi-mcp/pkg/resolve"
+)
+
+// Change describes how a package differs between two snapshots
+type Change struct {
+	Name       string
+	OldVersion string
+	NewVersion string
+
+	// EpochOnly is true when only the -rN release number changed
+	EpochOnly bool
+
+	AddedDependencies   []string
+	RemovedDependencies []string
+	AddedProvides       []string
+	RemovedProvides     []string
+}
+
+// Report is the difference between two index snapshots, comparing the
+// latest version of each package name
+type Report struct {
+	OldCount int
+	NewCount int
+
+	Added      []*apk.Package
+	Removed    []*apk.Package
+	Upgraded   []Change
+	Downgraded []Change
+
+	// Modified holds packages whose dependencies or provides changed without a version change
+	Modified []Change
+}
+
+// Compare computes the difference between an old and a new snapshot
+func Compare(old, new []*apk.Package) *Report {
+	oldLatest := latest(old)
+	newLatest := latest(new)
+
+	report := &Report{OldCount: len(oldLatest), NewCount: len(newLatest)}
+
+	for name, pkg := range newLatest {
+		if _, ok := oldLatest[name]; !ok {
+			report.Added = append(report.Added, pkg)
+		}
+	}
+	for name, oldPkg := range oldLatest {
+		newPkg, ok := newLatest[name]
+		if !ok {
+			report.Removed = append(report.Removed, oldPkg)
+			continue
+		}
+
+		change := Change{
+			Name:       name,
+			OldVersion: oldPkg.Version,
+			NewVersion: newPkg.Version,
+			EpochOnly:  upstreamVersion(oldPkg.Version) == upstreamVersion(newPkg.Version),
+		}
+		change.AddedDependencies, change.RemovedDependencies = difference(oldPkg.Dependencies, newPkg.Dependencies)
+		change.AddedProvides, change.RemovedProvides = difference(oldPkg.Provides, newPkg.Provides)
+
+		switch cmp := resolve.CompareVersions(newPkg.Version, oldPkg.Version); {
+		case cmp > 0:
+			report.Upgraded = append(report.Upgraded, change)
+		case cmp < 0:
+			report.Downgraded = append(report.Downgraded, change)
+		case change.hasRelationshipChanges():
+			report.Modified = append(report.Modified, change)
+		}
+	}
+
+	sortPackages(report.Added)
+	sortPackages(report.Removed)
+	sortChanges(report.Upgraded)
+	sortChanges(report.Downgraded)
+	sortChanges(report.Modified)
+
+	return report
+}
+
+// Empty reports whether the snapshots have no differences
+func (r *Report) Empty() bool {
+	return len(r.Added) == 0 && len(r.Removed) == 0 && len(r.Upgraded) == 0 &&
+		len(r.Downgraded) == 0 && len(r.Modified) == 0
+}
+
+// String formats the report as text
+func (r *Report) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Compared %d packages before with %d packages after.\n", r.OldCount, r.NewCount))
+
+	if r.Empty() {
+		sb.WriteString("\nNo differences found.\n")
+		return sb.String()
+	}
+
+	var upstream, epoch []Change
+	for _, change := range r.Upgraded {
+		if change.EpochOnly {
+			epoch = append(epoch, change)
+		} else {
+			upstream = append(upstream, change)
+		}
+	}
+
+	writePackages(&sb, "Added", r.Added)
+	writePackages(&sb, "Removed", r.Removed)
+	writeChanges(&sb, "Upgraded (upstream version changes)", upstream)
+	writeChanges(&sb, "Rebuilt (epoch-only -rN bumps)", epoch)
+	writeChanges(&sb, "Downgraded", r.Downgraded)
+	writeChanges(&sb, "Dependencies or provides changed without a version change", r.Modified)
+
+	return sb.String()
+}
+
+func (c Change) hasRelationshipChanges() bool {
+	return len(c.AddedDependencies) > 0 || len(c.RemovedDependencies) > 0 ||
+		len(c.AddedProvides) > 0 || len(c.RemovedProvides) > 0
+}
+
+func writePackages(sb *strings.Builder, title string, packages []*apk.Package) {
+	if len(packages) == 0 {
+		return
+	}
+	sb.WriteString(fmt.Sprintf("\n%s (%d):\n", title, len(packages)))
+	for _, pkg := range packages {
+		sb.WriteString(fmt.Sprintf("- %s (%s)\n", pkg.Name, pkg.Version))
+	}
+}
+
+func writeChanges(sb *strings.Builder, title string, changes []Change) {
+	if len(changes) == 0 {
+		return
+	}
+	sb.WriteString(fmt.Sprintf("\n%s (%d):\n", title, len(changes)))
+	for _, change := range changes {
+		if change.OldVersion == change.NewVersion {
+			sb.WriteString(fmt.Sprintf("- %s (%s)\n", change.Name, change.NewVersion))
+		} else {
+			sb.WriteString(fmt.Sprintf("- %s %s -> %s\n", change.Name, change.OldVersion, change.NewVersion))
+		}
+		writeRelationship(sb, "dependencies", change.AddedDependencies, change.RemovedDependencies)
+		writeRelationship(sb, "provides", change.AddedProvides, change.RemovedProvides)
+	}
+}
+
+func writeRelationship(sb *strings.Builder, title string, added, removed []string) {
+	if len(added) == 0 && len(removed) == 0 {
+		return
+	}
+	var parts []string
+	for _, a := range added {
+		parts = append(parts, "+"+a)
+	}
+	for _, r := range removed {
+		parts = append(parts, "-"+r)
+	}
+	sb.WriteString(fmt.Sprintf("    %s: %s\n", title, strings.Join(parts, " ")))
+}
+
+// latest returns the highest version of each package name
+func latest(packages []*apk.Package) map[string]*apk.Package {
+	result := make(map[string]*apk.Package, len(packages))
+	for _, pkg := range packages {
+		if existing, ok := result[pkg.Name]; !ok || resolve.CompareVersions(pkg.Version, existing.Version) > 0 {
+			result[pkg.Name] = pkg
+		}
+	}
+	return result
+}
+
+// upstreamVersion strips the -rN release suffix of an apk version
+func upstreamVersion(version string) string {
+	if idx := strings.LastIndex(version, "-r"); idx != -1 {
+		return version[:idx]
+	}
+	return version
+}
+
+// difference returns the entries only present in new and only present in old.
+// Versions are ignored for entries such as so: provides, whose versions change
+// with every release, unless the name is only present in one of the lists.
+func difference(old, new []string) (added, removed []string) {
+	oldNames := make(map[string]bool, len(old))
+	for _, o := range old {
+		oldNames[entryName(o)] = true
+	}
+	newNames := make(map[string]bool, len(new))
+	for _, n := range new {
+		newNames[entryName(n)] = true
+	}
+
+	for _, n := range new {
+		if !oldNames[entryName(n)] {
+			added = append(added, n)
+		}
+	}
+	for _, o := range old {
+		if !newNames[entryName(o)] {
+			removed = append(removed, o)
+		}
+	}
+	return added, removed
+}
+
+// entryName returns the name of a dependency or provides entry without its version constraint
+func entryName(entry string) string {
+	if idx := strings.IndexAny(entry, "=<>~"); idx > 0 {
+		return entry[:idx]
+	}
+	return entry
+}
+
+func sortPackages(packages []*apk.Package) {
+	sort.Slice(packages, func(i, j int) bool {
+		return packages[i].Name < packages[j].Name
+	})
+}
+
+func sortChanges(changes []Change) {
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Name < changes[j].Name
+	})
+}
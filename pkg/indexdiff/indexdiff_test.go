@@ -0,0 +1,87 @@
+package indexdiff
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+)
+
+func TestCompare(t *testing.T) {
+	old := []*apk.Package{
+		{Name: "curl", Version: "8.9.0-r0", Dependencies: []string{"libcurl-openssl4=8.9.0-r0", "so:libz.so.1"}},
+		{Name: "curl", Version: "8.9.1-r0", Dependencies: []string{"libcurl-openssl4=8.9.1-r0", "so:libz.so.1"}},
+		{Name: "git", Version: "2.46.0-r1"},
+		{Name: "jq", Version: "1.7.1-r0", Provides: []string{"cmd:jq=1.7.1-r0"}},
+		{Name: "go", Version: "1.23.2-r0"},
+		{Name: "removed", Version: "1.0-r0"},
+		{Name: "unchanged", Version: "1.0-r0"},
+	}
+	new := []*apk.Package{
+		{Name: "curl", Version: "8.10.0-r0", Dependencies: []string{"libcurl-openssl4=8.10.0-r0", "so:libpsl.so.5"}},
+		{Name: "git", Version: "2.46.0-r2"},
+		{Name: "jq", Version: "1.7.1-r0", Provides: []string{"cmd:jq=1.7.1-r0", "cmd:jq-tool=1.7.1-r0"}},
+		{Name: "go", Version: "1.23.1-r0"},
+		{Name: "added", Version: "1.0-r0"},
+		{Name: "unchanged", Version: "1.0-r0"},
+	}
+
+	report := Compare(old, new)
+
+	if report.OldCount != 6 || report.NewCount != 6 {
+		t.Errorf("Unexpected counts %d/%d", report.OldCount, report.NewCount)
+	}
+	if len(report.Added) != 1 || report.Added[0].Name != "added" {
+		t.Errorf("Unexpected added packages: %v", report.Added)
+	}
+	if len(report.Removed) != 1 || report.Removed[0].Name != "removed" {
+		t.Errorf("Unexpected removed packages: %v", report.Removed)
+	}
+
+	expectedUpgrades := []Change{
+		{
+			Name:                "curl",
+			OldVersion:          "8.9.1-r0",
+			NewVersion:          "8.10.0-r0",
+			AddedDependencies:   []string{"so:libpsl.so.5"},
+			RemovedDependencies: []string{"so:libz.so.1"},
+		},
+		{Name: "git", OldVersion: "2.46.0-r1", NewVersion: "2.46.0-r2", EpochOnly: true},
+	}
+	if !reflect.DeepEqual(report.Upgraded, expectedUpgrades) {
+		t.Errorf("Upgraded =\n%+v\nwant\n%+v", report.Upgraded, expectedUpgrades)
+	}
+
+	if len(report.Downgraded) != 1 || report.Downgraded[0].Name != "go" {
+		t.Errorf("Unexpected downgrades: %+v", report.Downgraded)
+	}
+	if len(report.Modified) != 1 || report.Modified[0].Name != "jq" ||
+		!reflect.DeepEqual(report.Modified[0].AddedProvides, []string{"cmd:jq-tool=1.7.1-r0"}) {
+		t.Errorf("Unexpected modified packages: %+v", report.Modified)
+	}
+
+	text := report.String()
+	for _, expected := range []string{
+		"Upgraded (upstream version changes) (1):\n- curl 8.9.1-r0 -> 8.10.0-r0\n    dependencies: +so:libpsl.so.5 -so:libz.so.1",
+		"Rebuilt (epoch-only -rN bumps) (1):\n- git 2.46.0-r1 -> 2.46.0-r2",
+		"Downgraded (1):\n- go 1.23.2-r0 -> 1.23.1-r0",
+		"- jq (1.7.1-r0)\n    provides: +cmd:jq-tool=1.7.1-r0",
+	} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("Expected report to contain %q, got:\n%s", expected, text)
+		}
+	}
+}
+
+func TestCompareIdentical(t *testing.T) {
+	packages := []*apk.Package{{Name: "curl", Version: "8.10.0-r0"}}
+
+	report := Compare(packages, packages)
+	if !report.Empty() {
+		t.Errorf("Expected an empty report, got %+v", report)
+	}
+	if !strings.Contains(report.String(), "No differences found") {
+		t.Errorf("Unexpected report: %s", report.String())
+	}
+}
@@ -0,0 +1,231 @@
+package indexgen
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"chainguard.dev/apko/pkg/apk/signature"
+	"github.com/dlorenc/wolfi-mcp/pkg/apkfile"
+	"github.com/dlorenc/wolfi-mcp/pkg/resolve"
+)
+
+// Scan reads the .apk files of a directory, not including its subdirectories,
+// into index entries sorted by name and version. Every file that cannot be
+// read is reported.
+func Scan(dir string, workers int) ([]*apk.Package, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, err
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.apk"))
+	if err != nil {
+		return nil, err
+	}
+
+	if workers < 1 {
+		workers = 1
+	}
+	packages := make([]*apk.Package, len(paths))
+	errs := make([]error, len(paths))
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				file, err := apkfile.Open(paths[i])
+				if err != nil {
+					errs[i] = fmt.Errorf("error reading %s: %w", paths[i], err)
+					continue
+				}
+				packages[i] = file.Package
+			}
+		}()
+	}
+	for i := range paths {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(packages, func(i, j int) bool {
+		if packages[i].Name != packages[j].Name {
+			return packages[i].Name < packages[j].Name
+		}
+		return resolve.CompareVersions(packages[i].Version, packages[j].Version) < 0
+	})
+	return packages, nil
+}
+
+// Options controls how an index is written
+type Options struct {
+	// Description is stored in the DESCRIPTION file of the index
+	Description string
+
+	// KeyFile is a PEM encoded RSA private key to sign the index with. The
+	// index is unsigned when it is empty.
+	KeyFile string
+
+	// KeyName is the file name of the public key apk verifies the signature
+	// with (default: the name of KeyFile with .pub appended)
+	KeyName string
+}
+
+// Write writes a standard APKINDEX.tar.gz of the packages. A signed index is
+// prefixed with a signature segment, like the output of abuild-sign.
+func Write(w io.Writer, packages []*apk.Package, opts Options) error {
+	var entries strings.Builder
+	for _, pkg := range packages {
+		writeEntry(&entries, pkg)
+	}
+	index, err := archive(map[string]string{
+		"APKINDEX":    entries.String(),
+		"DESCRIPTION": opts.Description,
+	})
+	if err != nil {
+		return err
+	}
+
+	if opts.KeyFile != "" {
+		keyName := opts.KeyName
+		if keyName == "" {
+			keyName = filepath.Base(opts.KeyFile) + ".pub"
+		}
+
+		digest := sha256.Sum256(index)
+		sig, err := signature.RSASignDigest(digest[:], crypto.SHA256, opts.KeyFile, "")
+		if err != nil {
+			return fmt.Errorf("error signing index: %w", err)
+		}
+		segment, err := signatureSegment(".SIGN.RSA256."+keyName, sig)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(segment); err != nil {
+			return err
+		}
+	}
+
+	_, err = w.Write(index)
+	return err
+}
+
+// WriteFile writes the index to a file
+func WriteFile(path string, packages []*apk.Package, opts Options) error {
+	var buf bytes.Buffer
+	if err := Write(&buf, packages, opts); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// writeEntry writes the APKINDEX entry of a package
+func writeEntry(sb *strings.Builder, pkg *apk.Package) {
+	field := func(key, value string) {
+		if value != "" {
+			sb.WriteString(key + ":" + value + "\n")
+		}
+	}
+	field("C", pkg.ChecksumString())
+	field("P", pkg.Name)
+	field("V", pkg.Version)
+	field("A", pkg.Arch)
+	if pkg.Size > 0 {
+		field("S", strconv.FormatUint(pkg.Size, 10))
+	}
+	if pkg.InstalledSize > 0 {
+		field("I", strconv.FormatUint(pkg.InstalledSize, 10))
+	}
+	sb.WriteString("T:" + pkg.Description + "\n")
+	field("U", pkg.URL)
+	field("L", pkg.License)
+	field("o", pkg.Origin)
+	field("m", pkg.Maintainer)
+	if pkg.BuildDate > 0 {
+		field("t", strconv.FormatInt(pkg.BuildDate, 10))
+	}
+	field("c", pkg.RepoCommit)
+	field("D", strings.Join(pkg.Dependencies, " "))
+	field("i", strings.Join(pkg.InstallIf, " "))
+	field("p", strings.Join(pkg.Provides, " "))
+	field("r", strings.Join(pkg.Replaces, " "))
+	if pkg.ProviderPriority > 0 {
+		field("k", strconv.FormatUint(pkg.ProviderPriority, 10))
+	}
+	sb.WriteString("\n")
+}
+
+// archive returns a gzip compressed tar archive of the files, in name order
+func archive(files map[string]string) ([]byte, error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(zw)
+	for _, name := range names {
+		hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(files[name])), Typeflag: tar.TypeReg, Uname: "root", Gname: "root"}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write([]byte(files[name])); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// signatureSegment returns the gzip compressed tar stream holding a signature.
+// Like abuild-sign, the tar stream has no end of archive marker, so the
+// segment can be concatenated with the signed one.
+func signatureSegment(name string, sig []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(zw)
+	hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(sig)), Typeflag: tar.TypeReg, Uname: "root", Gname: "root"}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return nil, err
+	}
+	if _, err := tw.Write(sig); err != nil {
+		return nil, err
+	}
+	if err := tw.Flush(); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Location returns the location of the index apk index would write in dir,
+// which identifies packages read from the directory itself
+func Location(dir string) string {
+	return strings.TrimSuffix(filepath.ToSlash(dir), "/") + "/APKINDEX.tar.gz"
+}
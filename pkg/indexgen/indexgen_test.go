@@ -0,0 +1,211 @@
+package indexgen
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"chainguard.dev/apko/pkg/apk/expandapk"
+	"chainguard.dev/apko/pkg/apk/signature"
+)
+
+// writeAPK writes an unsigned .apk file with the given .PKGINFO
+func writeAPK(t *testing.T, path, pkginfo string) {
+	segment := func(name, content string) []byte {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(zw)
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+		if err := tw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	content := append(segment(".PKGINFO", pkginfo), segment("usr/share/doc/README", "hello\n")...)
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testDir(t *testing.T) string {
+	dir := t.TempDir()
+	writeAPK(t, filepath.Join(dir, "hello-1.0-r1.apk"), "pkgname = hello\npkgver = 1.0-r1\narch = x86_64\nsize = 6\npkgdesc = Says hello\nlicense = MIT\nbuilddate = 1700000000\ndepend = busybox\nprovides = cmd:hello=1.0-r1\n")
+	writeAPK(t, filepath.Join(dir, "hello-1.0-r0.apk"), "pkgname = hello\npkgver = 1.0-r0\narch = x86_64\n")
+	writeAPK(t, filepath.Join(dir, "hello-bash-completion-1.0-r1.apk"), "pkgname = hello-bash-completion\npkgver = 1.0-r1\narch = x86_64\ninstall_if = hello=1.0-r1 bash-completion\n")
+
+	// Subdirectories, such as other architectures, are not read
+	if err := os.Mkdir(filepath.Join(dir, "aarch64"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeAPK(t, filepath.Join(dir, "aarch64", "hello-1.0-r1.apk"), "pkgname = hello\npkgver = 1.0-r1\narch = aarch64\n")
+	return dir
+}
+
+func TestScan(t *testing.T) {
+	packages, err := Scan(testDir(t), 2)
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	var names []string
+	for _, pkg := range packages {
+		names = append(names, pkg.Name+"-"+pkg.Version)
+	}
+	if !reflect.DeepEqual(names, []string{"hello-1.0-r0", "hello-1.0-r1", "hello-bash-completion-1.0-r1"}) {
+		t.Errorf("Scan() = %v", names)
+	}
+	if pkg := packages[1]; pkg.Size == 0 || len(pkg.Checksum) == 0 || pkg.License != "MIT" {
+		t.Errorf("Unexpected package entry: %+v", pkg)
+	}
+
+	if _, err := Scan(filepath.Join(t.TempDir(), "missing"), 1); err == nil {
+		t.Errorf("Expected an error for a missing directory")
+	}
+
+	broken := t.TempDir()
+	if err := os.WriteFile(filepath.Join(broken, "broken.apk"), []byte("not an apk"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Scan(broken, 1); err == nil || !strings.Contains(err.Error(), "broken.apk") {
+		t.Errorf("Expected an error naming the broken file, got %v", err)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	packages, err := Scan(testDir(t), 1)
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, packages, Options{Description: "local build"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	index, err := apk.IndexFromArchive(io.NopCloser(bytes.NewReader(buf.Bytes())))
+	if err != nil {
+		t.Fatalf("Failed to parse the written index: %v", err)
+	}
+	if index.Description != "local build" || len(index.Packages) != 3 || index.Signature != nil {
+		t.Fatalf("Unexpected index: %+v", index)
+	}
+
+	// The entries round-trip through the APKINDEX format
+	for i, pkg := range index.Packages {
+		want := packages[i]
+		if pkg.Name != want.Name || pkg.Version != want.Version || pkg.ChecksumString() != want.ChecksumString() ||
+			pkg.Size != want.Size || pkg.BuildDate != want.BuildDate ||
+			!reflect.DeepEqual(pkg.Dependencies, want.Dependencies) || !reflect.DeepEqual(pkg.InstallIf, want.InstallIf) {
+			t.Errorf("Entry %d = %+v, want %+v", i, pkg, want)
+		}
+	}
+	if got := index.Packages[2].InstallIf; !reflect.DeepEqual(got, []string{"hello=1.0-r1", "bash-completion"}) {
+		t.Errorf("InstallIf = %v", got)
+	}
+}
+
+func TestWriteEntry(t *testing.T) {
+	var sb strings.Builder
+	writeEntry(&sb, &apk.Package{
+		Name:         "busybox-full",
+		Version:      "1.36.1-r0",
+		Dependencies: []string{"so:libc.so.6"},
+		Provides:     []string{"busybox=1.36.1-r0"},
+		Replaces:     []string{"busybox", "coreutils"},
+	})
+	if got := sb.String(); !strings.Contains(got, "\np:busybox=1.36.1-r0\nr:busybox coreutils\n") {
+		t.Errorf("Expected the replaced packages after the provided ones, got %q", got)
+	}
+}
+
+func TestWriteSigned(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "local.rsa")
+	private := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := os.WriteFile(keyFile, private, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	public := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	dir := testDir(t)
+	packages, err := Scan(dir, 1)
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	path := filepath.Join(dir, "APKINDEX.tar.gz")
+	if err := WriteFile(path, packages, Options{KeyFile: keyFile}); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, err := apk.IndexFromArchive(io.NopCloser(bytes.NewReader(data)))
+	if err != nil {
+		t.Fatalf("Failed to parse the signed index: %v", err)
+	}
+	if len(index.Packages) != 3 || len(index.Signature) == 0 {
+		t.Fatalf("Expected a signed index with 3 packages, got %+v", index)
+	}
+
+	// The signature segment names the public key and covers the index segment
+	parts, err := expandapk.Split(bytes.NewReader(data))
+	if err != nil || len(parts) != 3 {
+		t.Fatalf("Expected a signature and an index segment, got %d parts: %v", len(parts), err)
+	}
+	zr, err := gzip.NewReader(parts[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	hdr, err := tar.NewReader(zr).Next()
+	if err != nil || hdr.Name != ".SIGN.RSA256.local.rsa.pub" {
+		t.Errorf("Unexpected signature entry %v: %v", hdr, err)
+	}
+	signed, err := io.ReadAll(parts[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256(signed)
+	if err := signature.RSAVerifyDigest(digest[:], crypto.SHA256, index.Signature, public); err != nil {
+		t.Errorf("Signature does not verify: %v", err)
+	}
+
+	if err := Write(io.Discard, packages, Options{KeyFile: filepath.Join(t.TempDir(), "missing.rsa")}); err == nil {
+		t.Errorf("Expected an error for a missing key")
+	}
+}
+
+func TestLocation(t *testing.T) {
+	if got := Location("/home/dev/packages/x86_64/"); got != "/home/dev/packages/x86_64/APKINDEX.tar.gz" {
+		t.Errorf("Location() = %q", got)
+	}
+}
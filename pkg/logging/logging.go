@@ -0,0 +1,211 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// Supported log formats
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// ParseLevel parses a level name: debug, info, warn (or warning) or error
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("unknown log level %q (use debug, info, warn or error)", name)
+	}
+}
+
+// NewHandler returns a handler writing records at or above level to w in the given format
+func NewHandler(w io.Writer, level slog.Leveler, format string) (slog.Handler, error) {
+	options := &slog.HandlerOptions{Level: level}
+	switch strings.ToLower(format) {
+	case FormatText:
+		return slog.NewTextHandler(w, options), nil
+	case FormatJSON:
+		return slog.NewJSONHandler(w, options), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q (use %s or %s)", format, FormatText, FormatJSON)
+	}
+}
+
+// Sink receives forwarded records, with their attributes flattened into a map.
+// Attributes inside groups are keyed by their dotted path.
+type Sink func(level slog.Level, message string, attrs map[string]any)
+
+// Forwarder passes records at or above a level to a sink, such as the
+// connected MCP clients. The sink is usually only known once the server is
+// created, so records are dropped until SetSink is called.
+type Forwarder struct {
+	level slog.Leveler
+
+	mu   sync.RWMutex
+	sink Sink
+}
+
+// NewForwarder creates a forwarder for records at or above level
+func NewForwarder(level slog.Leveler) *Forwarder {
+	return &Forwarder{level: level}
+}
+
+// SetSink sets where records are forwarded to
+func (f *Forwarder) SetSink(sink Sink) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sink = sink
+}
+
+// Handler returns a slog handler forwarding records to the sink
+func (f *Forwarder) Handler() slog.Handler {
+	return &forwardHandler{forwarder: f}
+}
+
+// forwardHandler is the slog handler of a Forwarder
+type forwardHandler struct {
+	forwarder *Forwarder
+	attrs     []slog.Attr
+	prefix    string
+}
+
+func (h *forwardHandler) Enabled(_ context.Context, level slog.Level) bool {
+	if level < h.forwarder.level.Level() {
+		return false
+	}
+	h.forwarder.mu.RLock()
+	defer h.forwarder.mu.RUnlock()
+	return h.forwarder.sink != nil
+}
+
+func (h *forwardHandler) Handle(_ context.Context, record slog.Record) error {
+	h.forwarder.mu.RLock()
+	sink := h.forwarder.sink
+	h.forwarder.mu.RUnlock()
+	if sink == nil {
+		return nil
+	}
+
+	attrs := make(map[string]any)
+	for _, attr := range h.attrs {
+		flatten(attrs, "", attr)
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		flatten(attrs, h.prefix, attr)
+		return true
+	})
+	sink(record.Level, record.Message, attrs)
+	return nil
+}
+
+func (h *forwardHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append([]slog.Attr(nil), h.attrs...)
+	for _, attr := range attrs {
+		if h.prefix != "" {
+			attr.Key = h.prefix + attr.Key
+		}
+		clone.attrs = append(clone.attrs, attr)
+	}
+	return &clone
+}
+
+func (h *forwardHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.prefix = h.prefix + name + "."
+	return &clone
+}
+
+// flatten adds an attribute to attrs, expanding groups into dotted keys
+func flatten(attrs map[string]any, prefix string, attr slog.Attr) {
+	value := attr.Value.Resolve()
+	if value.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			prefix += attr.Key + "."
+		}
+		for _, member := range value.Group() {
+			flatten(attrs, prefix, member)
+		}
+		return
+	}
+	if attr.Key == "" {
+		return
+	}
+	switch value.Kind() {
+	case slog.KindDuration:
+		attrs[prefix+attr.Key] = value.Duration().String()
+	case slog.KindTime:
+		attrs[prefix+attr.Key] = value.Time()
+	case slog.KindAny:
+		if err, ok := value.Any().(error); ok {
+			attrs[prefix+attr.Key] = err.Error()
+			return
+		}
+		attrs[prefix+attr.Key] = value.Any()
+	default:
+		attrs[prefix+attr.Key] = value.Any()
+	}
+}
+
+// Tee returns a handler passing every record to each of the handlers that
+// has its level enabled
+func Tee(handlers ...slog.Handler) slog.Handler {
+	return teeHandler(handlers)
+}
+
+// teeHandler is the slog handler returned by Tee
+type teeHandler []slog.Handler
+
+func (t teeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range t {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t teeHandler) Handle(ctx context.Context, record slog.Record) error {
+	var first error
+	for _, h := range t {
+		if !h.Enabled(ctx, record.Level) {
+			continue
+		}
+		if err := h.Handle(ctx, record.Clone()); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+func (t teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	result := make(teeHandler, len(t))
+	for i, h := range t {
+		result[i] = h.WithAttrs(attrs)
+	}
+	return result
+}
+
+func (t teeHandler) WithGroup(name string) slog.Handler {
+	result := make(teeHandler, len(t))
+	for i, h := range t {
+		result[i] = h.WithGroup(name)
+	}
+	return result
+}
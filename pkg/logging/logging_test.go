@@ -0,0 +1,103 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLevel(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expected slog.Level
+		isError  bool
+	}{
+		{name: "debug", expected: slog.LevelDebug},
+		{name: "INFO", expected: slog.LevelInfo},
+		{name: "warn", expected: slog.LevelWarn},
+		{name: "warning", expected: slog.LevelWarn},
+		{name: "error", expected: slog.LevelError},
+		{name: "verbose", isError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			level, err := ParseLevel(tc.name)
+			if (err != nil) != tc.isError {
+				t.Fatalf("ParseLevel(%q) error = %v", tc.name, err)
+			}
+			if !tc.isError && level != tc.expected {
+				t.Errorf("ParseLevel(%q) = %v, want %v", tc.name, level, tc.expected)
+			}
+		})
+	}
+}
+
+func TestNewHandler(t *testing.T) {
+	var buf bytes.Buffer
+	handler, err := NewHandler(&buf, slog.LevelWarn, FormatJSON)
+	if err != nil {
+		t.Fatalf("NewHandler() error = %v", err)
+	}
+	logger := slog.New(handler)
+	logger.Info("hidden")
+	logger.Warn("shown", "index", "a")
+
+	if strings.Contains(buf.String(), "hidden") {
+		t.Errorf("Expected messages below the level to be dropped, got: %s", buf.String())
+	}
+	if !strings.Contains(buf.String(), `"msg":"shown","index":"a"`) {
+		t.Errorf("Expected a JSON record, got: %s", buf.String())
+	}
+
+	if _, err := NewHandler(&buf, slog.LevelInfo, "xml"); err == nil {
+		t.Errorf("Expected an error for an unknown format")
+	}
+}
+
+func TestForwarder(t *testing.T) {
+	type message struct {
+		level slog.Level
+		text  string
+		attrs map[string]any
+	}
+	var forwarded []message
+
+	var buf bytes.Buffer
+	local, _ := NewHandler(&buf, slog.LevelDebug, FormatText)
+	forwarder := NewForwarder(slog.LevelWarn)
+	logger := slog.New(Tee(local, forwarder.Handler()))
+
+	// Nothing is forwarded before a sink is set
+	logger.Warn("early")
+
+	forwarder.SetSink(func(level slog.Level, text string, attrs map[string]any) {
+		forwarded = append(forwarded, message{level, text, attrs})
+	})
+	logger.Info("local only")
+	logger.With("component", "index").WithGroup("cache").Warn("stale", "error", errors.New("bad digest"), "age", time.Minute)
+
+	if len(forwarded) != 1 {
+		t.Fatalf("Expected one forwarded message, got %+v", forwarded)
+	}
+	got := forwarded[0]
+	if got.level != slog.LevelWarn || got.text != "stale" {
+		t.Errorf("Unexpected forwarded message %+v", got)
+	}
+	expected := map[string]any{"component": "index", "cache.error": "bad digest", "cache.age": "1m0s"}
+	for key, value := range expected {
+		if got.attrs[key] != value {
+			t.Errorf("Expected attribute %s=%v, got %v", key, value, got.attrs[key])
+		}
+	}
+
+	// Every message still reaches the local handler
+	for _, check := range []string{"early", "local only", "stale"} {
+		if !strings.Contains(buf.String(), check) {
+			t.Errorf("Expected local log to contain %q, got: %s", check, buf.String())
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package melange
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Definition is the subset of a melange package definition used by the tools
+type Definition struct {
+	// Path is the file the definition was read from
+	Path string `yaml:"-"`
+
+	Package struct {
+		Name        string `yaml:"name"`
+		Version     string `yaml:"version"`
+		Epoch       int    `yaml:"epoch"`
+		Description string `yaml:"description"`
+		Copyright   []struct {
+			License string `yaml:"license"`
+		} `yaml:"copyright"`
+	} `yaml:"package"`
+	Vars        map[string]string `yaml:"vars"`
+	Pipeline    []Step            `yaml:"pipeline"`
+	Subpackages []Subpackage      `yaml:"subpackages"`
+	Update      Update            `yaml:"update"`
+}
+
+// Step is a pipeline step, either a named pipeline with its inputs or a
+// shell script. Inputs are kept as YAML nodes, since some pipelines take
+// lists or maps.
+type Step struct {
+	Name     string               `yaml:"name"`
+	Uses     string               `yaml:"uses"`
+	With     map[string]yaml.Node `yaml:"with"`
+	Runs     string               `yaml:"runs"`
+	Pipeline []Step               `yaml:"pipeline"`
+}
+
+// Input returns an input of the step as written, or an empty string when it
+// is missing or not a single value
+func (s Step) Input(name string) string {
+	node, ok := s.With[name]
+	if !ok || node.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return node.Value
+}
+
+// Subpackage is a package split from the output of the main build
+type Subpackage struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Range       string `yaml:"range"`
+	Pipeline    []Step `yaml:"pipeline"`
+}
+
+// Update is the configuration of the automated version updates
+type Update struct {
+	Enabled bool `yaml:"enabled"`
+	Manual  bool `yaml:"manual"`
+	GitHub  *struct {
+		Identifier  string `yaml:"identifier"`
+		StripPrefix string `yaml:"strip-prefix"`
+		TagFilter   string `yaml:"tag-filter"`
+		UseTag      bool   `yaml:"use-tag"`
+	} `yaml:"github"`
+	ReleaseMonitor *struct {
+		Identifier int `yaml:"identifier"`
+	} `yaml:"release-monitor"`
+	Git *struct {
+		StripPrefix string `yaml:"strip-prefix"`
+		TagFilter   string `yaml:"tag-filter"`
+	} `yaml:"git"`
+	IgnoreRegexPatterns []string `yaml:"ignore-regex-patterns"`
+}
+
+// Source is where a build fetches its upstream source from
+type Source struct {
+	// Kind is the pipeline fetching the source, e.g. fetch or git-checkout
+	Kind string
+
+	URL string
+
+	// Ref is the tag, branch or commit of a git checkout
+	Ref string
+
+	// Checksum is the expected digest of a fetched archive or the expected
+	// commit of a git checkout
+	Checksum string
+}
+
+// Parse parses a melange YAML package definition
+func Parse(data []byte) (*Definition, error) {
+	var def Definition
+	if err := yaml.Unmarshal(data, &def); err != nil {
+		return nil, fmt.Errorf("error parsing melange definition: %w", err)
+	}
+	if def.Package.Name == "" || def.Package.Version == "" {
+		return nil, errors.New("not a melange definition: no package name and version")
+	}
+	return &def, nil
+}
+
+// Version returns the version of the packages the definition builds, as it
+// appears in the index
+func (d *Definition) Version() string {
+	return d.Package.Version + "-r" + strconv.Itoa(d.Package.Epoch)
+}
+
+// Licenses returns the declared licenses
+func (d *Definition) Licenses() []string {
+	var licenses []string
+	for _, copyright := range d.Package.Copyright {
+		if copyright.License != "" {
+			licenses = append(licenses, copyright.License)
+		}
+	}
+	return licenses
+}
+
+// SubpackageNames returns the names of the declared subpackages, with the
+// package variables substituted. Subpackages generated from a range keep
+// their templated name.
+func (d *Definition) SubpackageNames() []string {
+	names := make([]string, 0, len(d.Subpackages))
+	for _, sub := range d.Subpackages {
+		names = append(names, d.Substitute(sub.Name))
+	}
+	return names
+}
+
+// Sources returns the upstream sources fetched by the main pipeline
+func (d *Definition) Sources() []Source {
+	var sources []Source
+	var walk func(steps []Step)
+	walk = func(steps []Step) {
+		for _, step := range steps {
+			switch step.Uses {
+			case "fetch":
+				sources = append(sources, Source{
+					Kind:     step.Uses,
+					URL:      d.Substitute(step.Input("uri")),
+					Checksum: firstOf(step, "expected-sha256", "expected-sha512"),
+				})
+			case "git-checkout":
+				sources = append(sources, Source{
+					Kind:     step.Uses,
+					URL:      d.Substitute(step.Input("repository")),
+					Ref:      d.Substitute(firstOf(step, "tag", "branch")),
+					Checksum: step.Input("expected-commit"),
+				})
+			}
+			walk(step.Pipeline)
+		}
+	}
+	walk(d.Pipeline)
+	return sources
+}
+
+// Substitute replaces the ${{package.*}} and ${{vars.*}} variables of a
+// value. Unknown variables are left in place.
+func (d *Definition) Substitute(value string) string {
+	if !strings.Contains(value, "${{") {
+		return value
+	}
+	pairs := []string{
+		"${{package.name}}", d.Package.Name,
+		"${{package.version}}", d.Package.Version,
+		"${{package.epoch}}", strconv.Itoa(d.Package.Epoch),
+		"${{package.full-version}}", d.Version(),
+	}
+	for name, v := range d.Vars {
+		pairs = append(pairs, "${{vars."+name+"}}", v)
+	}
+	return strings.NewReplacer(pairs...).Replace(value)
+}
+
+// firstOf returns the first non-empty input of a step
+func firstOf(step Step, keys ...string) string {
+	for _, key := range keys {
+		if value := step.Input(key); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// Tree holds the package definitions of a checkout, such as a clone of
+// wolfi-dev/os, by the name of the origin they build
+type Tree struct {
+	Dir         string
+	Definitions map[string]*Definition
+
+	// packages maps the main package and every subpackage to its origin
+	packages map[string]string
+}
+
+// Load reads the melange definitions at the top level of dir. Files that
+// are not melange definitions, such as CI workflows, are passed to warn and
+// left out.
+func Load(dir string, warn func(path string, err error)) (*Tree, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("error reading melange definitions: %w", err)
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+
+	tree := &Tree{Dir: dir, Definitions: make(map[string]*Definition), packages: make(map[string]string)}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			warn(path, err)
+			continue
+		}
+		def, err := Parse(data)
+		if err != nil {
+			warn(path, err)
+			continue
+		}
+		def.Path = path
+		tree.Add(def)
+	}
+	return tree, nil
+}
+
+// Add adds a definition to the tree, replacing any definition of the same
+// origin
+func (t *Tree) Add(def *Definition) {
+	if t.Definitions == nil {
+		t.Definitions = make(map[string]*Definition)
+		t.packages = make(map[string]string)
+	}
+	origin := def.Package.Name
+	t.Definitions[origin] = def
+	t.packages[origin] = origin
+	for _, name := range def.SubpackageNames() {
+		t.packages[name] = origin
+	}
+}
+
+// Lookup returns the definition building a package, found by the name of
+// its origin or of any of its declared subpackages
+func (t *Tree) Lookup(name string) *Definition {
+	if def, ok := t.Definitions[name]; ok {
+		return def
+	}
+	if origin, ok := t.packages[name]; ok {
+		return t.Definitions[origin]
+	}
+	return nil
+}
+
+// Origins returns the names of the defined origins, sorted
+func (t *Tree) Origins() []string {
+	names := make([]string, 0, len(t.Definitions))
+	for name := range t.Definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
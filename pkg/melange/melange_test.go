@@ -0,0 +1,190 @@
+package melange
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const opensslYAML = `package:
+  name: openssl
+  version: 3.4.1
+  epoch: 2
+  description: "Transport Layer Security toolkit"
+  copyright:
+    - license: Apache-2.0
+
+vars:
+  tag-prefix: openssl-
+
+pipeline:
+  - uses: fetch
+    with:
+      uri: https://github.com/openssl/openssl/releases/download/${{vars.tag-prefix}}${{package.version}}/openssl-${{package.version}}.tar.gz
+      expected-sha256: 0123abcd
+      strip-components: 1
+  - name: Configure and build
+    runs: |
+      ./Configure
+      make -j$(nproc)
+  - uses: strip
+
+subpackages:
+  - name: libssl3
+    pipeline:
+      - runs: mv usr/lib/libssl.so.3 ${{targets.subpkgdir}}/usr/lib/
+  - name: ${{package.name}}-dev
+
+update:
+  enabled: true
+  github:
+    identifier: openssl/openssl
+    strip-prefix: openssl-
+    use-tag: true
+`
+
+func TestParse(t *testing.T) {
+	def, err := Parse([]byte(opensslYAML))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if def.Version() != "3.4.1-r2" {
+		t.Errorf("Version() = %s, want 3.4.1-r2", def.Version())
+	}
+	if !reflect.DeepEqual(def.Licenses(), []string{"Apache-2.0"}) {
+		t.Errorf("Licenses() = %v", def.Licenses())
+	}
+	if !reflect.DeepEqual(def.SubpackageNames(), []string{"libssl3", "openssl-dev"}) {
+		t.Errorf("SubpackageNames() = %v", def.SubpackageNames())
+	}
+	if len(def.Pipeline) != 3 || def.Pipeline[1].Name != "Configure and build" {
+		t.Errorf("Unexpected pipeline: %+v", def.Pipeline)
+	}
+
+	want := []Source{{
+		Kind:     "fetch",
+		URL:      "https://github.com/openssl/openssl/releases/download/openssl-3.4.1/openssl-3.4.1.tar.gz",
+		Checksum: "0123abcd",
+	}}
+	if !reflect.DeepEqual(def.Sources(), want) {
+		t.Errorf("Sources() = %+v, want %+v", def.Sources(), want)
+	}
+
+	if !def.Update.Enabled || def.Update.GitHub == nil || def.Update.GitHub.Identifier != "openssl/openssl" || !def.Update.GitHub.UseTag {
+		t.Errorf("Unexpected update configuration: %+v", def.Update)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for name, data := range map[string]string{
+		"workflow": "name: CI\non:\n  push: {}\n",
+		"invalid":  "package: [",
+	} {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestSourcesGitCheckout(t *testing.T) {
+	def, err := Parse([]byte(`package:
+  name: crane
+  version: 0.20.2
+  epoch: 0
+pipeline:
+  - uses: git-checkout
+    with:
+      repository: https://github.com/google/go-containerregistry
+      tag: v${{package.version}}
+      expected-commit: c195f151efe3369874c72662cd69ad43ee485128
+`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	want := []Source{{
+		Kind:     "git-checkout",
+		URL:      "https://github.com/google/go-containerregistry",
+		Ref:      "v0.20.2",
+		Checksum: "c195f151efe3369874c72662cd69ad43ee485128",
+	}}
+	if !reflect.DeepEqual(def.Sources(), want) {
+		t.Errorf("Sources() = %+v, want %+v", def.Sources(), want)
+	}
+}
+
+func TestSourcesUnusualInputs(t *testing.T) {
+	def, err := Parse([]byte(`package:
+  name: hello
+  version: 1.10
+  epoch: 0
+pipeline:
+  - uses: patch
+    with:
+      patches:
+        - fix-build.patch
+        - fix-tests.patch
+  - uses: git-checkout
+    with:
+      repository: https://example.com/hello
+      tag: 1.10
+      cherry-picks: |
+        main/0123abcd: fix
+      options:
+        depth: 1
+`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	// Lists and maps are accepted, and numbers are kept as written
+	want := []Source{{Kind: "git-checkout", URL: "https://example.com/hello", Ref: "1.10"}}
+	if !reflect.DeepEqual(def.Sources(), want) {
+		t.Errorf("Sources() = %+v, want %+v", def.Sources(), want)
+	}
+	if got := def.Pipeline[0].Input("patches"); got != "" {
+		t.Errorf("Input() of a list = %q, want empty", got)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"openssl.yaml":  opensslYAML,
+		"README.md":     "# Packages\n",
+		".yamllint":     "extends: default\n",
+		"workflow.yaml": "name: CI\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var skipped []string
+	tree, err := Load(dir, func(path string, err error) {
+		skipped = append(skipped, filepath.Base(path))
+	})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(tree.Origins(), []string{"openssl"}) {
+		t.Errorf("Origins() = %v", tree.Origins())
+	}
+	if !reflect.DeepEqual(skipped, []string{"workflow.yaml"}) {
+		t.Errorf("Skipped = %v", skipped)
+	}
+	if def := tree.Lookup("openssl-dev"); def == nil || def.Path != filepath.Join(dir, "openssl.yaml") {
+		t.Errorf("Lookup(openssl-dev) = %+v", def)
+	}
+	if def := tree.Lookup("curl"); def != nil {
+		t.Errorf("Expected no definition for curl, got %+v", def)
+	}
+
+	if _, err := Load(filepath.Join(dir, "missing"), nil); err == nil {
+		t.Errorf("Expected an error for a missing directory")
+	}
+}
@@ -0,0 +1,155 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/dlorenc/wolfi-mcp/pkg/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes every metric name
+const namespace = "wolfi_mcp"
+
+// Names of the caches whose lookups are counted
+const (
+	CacheIndex  = "index"
+	CacheResult = "result"
+)
+
+// Metrics records tool usage and index state in a Prometheus registry. All
+// methods do nothing on a nil *Metrics, so callers need not check whether
+// metrics are enabled.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	toolCalls     *prometheus.CounterVec
+	toolErrors    *prometheus.CounterVec
+	toolDuration  *prometheus.HistogramVec
+	indexLoad     prometheus.Histogram
+	packages      *prometheus.GaugeVec
+	lastRefresh   prometheus.Gauge
+	cacheLookups  *prometheus.CounterVec
+	advisoryCount prometheus.Gauge
+}
+
+// New creates the metrics in a new registry, along with the Go runtime and
+// process metrics
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		toolCalls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "tool_calls_total",
+			Help:      "Number of tool calls, by tool.",
+		}, []string{"tool"}),
+		toolErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "tool_errors_total",
+			Help:      "Number of tool calls that returned an error, by tool.",
+		}, []string{"tool"}),
+		toolDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "tool_call_duration_seconds",
+			Help:      "Duration of tool calls, by tool.",
+			Buckets:   []float64{.001, .005, .01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+		}, []string{"tool"}),
+		indexLoad: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "index_load_duration_seconds",
+			Help:      "Duration of fetching, parsing and merging the indexes.",
+			Buckets:   []float64{.1, .25, .5, 1, 2.5, 5, 10, 30, 60, 120},
+		}),
+		packages: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "packages",
+			Help:      "Number of packages loaded, by repository index. The merged repository is reported as \"merged\".",
+		}, []string{"repository"}),
+		lastRefresh: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "index_last_refresh_timestamp_seconds",
+			Help:      "Unix time of the last successful index load.",
+		}),
+		cacheLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_lookups_total",
+			Help:      "Number of cache lookups, by cache and result (hit or miss).",
+		}, []string{"cache", "result"}),
+		advisoryCount: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "advisories",
+			Help:      "Number of vulnerability advisories loaded.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		m.toolCalls, m.toolErrors, m.toolDuration,
+		m.indexLoad, m.packages, m.lastRefresh,
+		m.cacheLookups, m.advisoryCount,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus text format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Middleware records the count, duration and errors of every tool call
+func (m *Metrics) Middleware() tools.Middleware {
+	if m == nil {
+		return nil
+	}
+	return tools.Timing(m.ObserveToolCall)
+}
+
+// ObserveToolCall records a tool call
+func (m *Metrics) ObserveToolCall(tool string, elapsed time.Duration, result *mcp.CallToolResult, err error) {
+	if m == nil {
+		return
+	}
+	m.toolCalls.WithLabelValues(tool).Inc()
+	m.toolDuration.WithLabelValues(tool).Observe(elapsed.Seconds())
+	if err != nil || (result != nil && result.IsError) {
+		m.toolErrors.WithLabelValues(tool).Inc()
+	}
+}
+
+// ObserveIndexLoad records a successful index load that took elapsed and
+// produced the given number of packages per repository
+func (m *Metrics) ObserveIndexLoad(elapsed time.Duration, packages map[string]int) {
+	if m == nil {
+		return
+	}
+	m.indexLoad.Observe(elapsed.Seconds())
+	m.packages.Reset()
+	for repository, count := range packages {
+		m.packages.WithLabelValues(repository).Set(float64(count))
+	}
+	m.lastRefresh.SetToCurrentTime()
+}
+
+// ObserveCacheLookup records a lookup in one of the caches
+func (m *Metrics) ObserveCacheLookup(cache string, hit bool) {
+	if m == nil {
+		return
+	}
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	m.cacheLookups.WithLabelValues(cache, result).Inc()
+}
+
+// SetAdvisories records the number of vulnerability advisories loaded
+func (m *Metrics) SetAdvisories(count int) {
+	if m == nil {
+		return
+	}
+	m.advisoryCount.Set(float64(count))
+}
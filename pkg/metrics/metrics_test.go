@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestMetrics(t *testing.T) {
+	m := New()
+
+	handler := m.Middleware()(mcp.NewTool("search_packages"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if request.Params.Arguments["query"] == "" {
+			return mcp.NewToolResultError("empty query"), nil
+		}
+		return mcp.NewToolResultText("ok"), nil
+	})
+	for _, query := range []string{"yaml", "curl", ""} {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{"query": query}
+		if _, err := handler(context.Background(), request); err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+	}
+
+	m.ObserveIndexLoad(2*time.Second, map[string]int{"merged": 3, "https://example.com/APKINDEX.tar.gz": 4})
+	m.ObserveCacheLookup(CacheIndex, true)
+	m.ObserveCacheLookup(CacheResult, false)
+	m.SetAdvisories(7)
+
+	recorder := httptest.NewRecorder()
+	m.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(recorder.Body)
+	text := string(body)
+
+	for _, check := range []string{
+		`wolfi_mcp_tool_calls_total{tool="search_packages"} 3`,
+		`wolfi_mcp_tool_errors_total{tool="search_packages"} 1`,
+		`wolfi_mcp_tool_call_duration_seconds_count{tool="search_packages"} 3`,
+		`wolfi_mcp_index_load_duration_seconds_sum 2`,
+		`wolfi_mcp_packages{repository="merged"} 3`,
+		`wolfi_mcp_packages{repository="https://example.com/APKINDEX.tar.gz"} 4`,
+		`wolfi_mcp_index_last_refresh_timestamp_seconds`,
+		`wolfi_mcp_cache_lookups_total{cache="index",result="hit"} 1`,
+		`wolfi_mcp_cache_lookups_total{cache="result",result="miss"} 1`,
+		`wolfi_mcp_advisories 7`,
+		`go_goroutines`,
+	} {
+		if !strings.Contains(text, check) {
+			t.Errorf("Expected metrics to contain %q, got:\n%s", check, text)
+		}
+	}
+}
+
+func TestNilMetrics(t *testing.T) {
+	// A nil *Metrics disables metrics without checks at every call site
+	var m *Metrics
+	if m.Middleware() != nil {
+		t.Errorf("Expected no middleware when metrics are disabled")
+	}
+	m.ObserveToolCall("tool", time.Second, nil, nil)
+	m.ObserveIndexLoad(time.Second, nil)
+	m.ObserveCacheLookup(CacheIndex, true)
+	m.SetAdvisories(1)
+}
@@ -0,0 +1,227 @@
+package query
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dlorenc/wolfi-mcp/pkg/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// aliases maps short command names to tool names
+var aliases = map[string]string{
+	"search":       "search_packages",
+	"info":         "package_info",
+	"dependencies": "package_dependencies",
+	"deps":         "package_dependencies",
+	"versions":     "compare_versions",
+	"graph":        "package_graph",
+}
+
+type registered struct {
+	tool    mcp.Tool
+	handler tools.ToolHandler
+}
+
+// Runner calls tool handlers directly from the command line, without an MCP client
+type Runner struct {
+	tools map[string]registered
+}
+
+// New creates a new Runner without any tools
+func New() *Runner {
+	return &Runner{tools: make(map[string]registered)}
+}
+
+// AddTool adds a tool and its handler to the runner
+func (r *Runner) AddTool(tool mcp.Tool, handler tools.ToolHandler) {
+	r.tools[tool.Name] = registered{tool: tool, handler: handler}
+}
+
+// Run calls the tool named by the first argument and writes its result to w.
+// Positional arguments fill the required parameters of the tool in order,
+// and --name value or --name=value flags set any parameter. A parameter can
+// be named by a unique suffix, e.g. --type for query_type. With --json, the
+// raw tool result is printed as JSON.
+func (r *Runner) Run(ctx context.Context, w io.Writer, args []string) error {
+	if len(args) == 0 || args[0] == "list" {
+		r.list(w)
+		if len(args) == 0 {
+			return fmt.Errorf("usage: query <tool> [arguments] [--parameter value] [--json]")
+		}
+		return nil
+	}
+
+	name := args[0]
+	if alias, ok := aliases[name]; ok {
+		name = alias
+	}
+	t, ok := r.tools[name]
+	if !ok {
+		return fmt.Errorf("unknown tool %q, run \"query list\" to see the available tools", args[0])
+	}
+
+	arguments, asJSON, err := parseArguments(t.tool, args[1:])
+	if err != nil {
+		return err
+	}
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = t.tool.Name
+	request.Params.Arguments = arguments
+
+	result, err := t.handler(ctx, request)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error encoding the result: %w", err)
+		}
+		fmt.Fprintln(w, string(data))
+	} else {
+		for _, content := range result.Content {
+			if text, ok := content.(mcp.TextContent); ok {
+				fmt.Fprintln(w, strings.TrimRight(text.Text, "\n"))
+			}
+		}
+	}
+
+	if result.IsError {
+		return fmt.Errorf("%s failed", t.tool.Name)
+	}
+	return nil
+}
+
+// list writes the available tools and their aliases
+func (r *Runner) list(w io.Writer) {
+	shortNames := make(map[string][]string)
+	for alias, name := range aliases {
+		shortNames[name] = append(shortNames[name], alias)
+	}
+
+	names := make([]string, 0, len(r.tools))
+	for name := range r.tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "Available tools:")
+	for _, name := range names {
+		sort.Strings(shortNames[name])
+		title := name
+		if len(shortNames[name]) > 0 {
+			title = fmt.Sprintf("%s (%s)", name, strings.Join(shortNames[name], ", "))
+		}
+		fmt.Fprintf(w, "  %s\n      %s\n", title, r.tools[name].tool.Description)
+	}
+}
+
+// parseArguments turns command line arguments into tool arguments
+func parseArguments(tool mcp.Tool, args []string) (map[string]interface{}, bool, error) {
+	arguments := make(map[string]interface{})
+	asJSON := false
+	positional := 0
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			if positional >= len(tool.InputSchema.Required) {
+				return nil, false, fmt.Errorf("unexpected argument %q", arg)
+			}
+			arguments[tool.InputSchema.Required[positional]] = arg
+			positional++
+			continue
+		}
+
+		flagName, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if flagName == "json" {
+			asJSON = true
+			continue
+		}
+
+		property, err := findProperty(tool, flagName)
+		if err != nil {
+			return nil, false, err
+		}
+		kind := propertyType(tool, property)
+
+		if !hasValue {
+			switch {
+			case kind == "boolean" && (i+1 >= len(args) || (args[i+1] != "true" && args[i+1] != "false")):
+				// A bare boolean flag means true
+				value = "true"
+			case i+1 < len(args):
+				i++
+				value = args[i]
+			default:
+				return nil, false, fmt.Errorf("missing value for --%s", flagName)
+			}
+		}
+
+		converted, err := convert(kind, value)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid value for --%s: %w", flagName, err)
+		}
+		arguments[property] = converted
+	}
+
+	for _, required := range tool.InputSchema.Required {
+		if _, ok := arguments[required]; !ok {
+			return nil, false, fmt.Errorf("missing required parameter %q", required)
+		}
+	}
+
+	return arguments, asJSON, nil
+}
+
+// findProperty returns the parameter of a tool matching a flag name exactly
+// or by a unique suffix
+func findProperty(tool mcp.Tool, flagName string) (string, error) {
+	flagName = strings.ReplaceAll(flagName, "-", "_")
+	if _, ok := tool.InputSchema.Properties[flagName]; ok {
+		return flagName, nil
+	}
+
+	var matches []string
+	for property := range tool.InputSchema.Properties {
+		if strings.HasSuffix(property, "_"+flagName) {
+			matches = append(matches, property)
+		}
+	}
+	switch len(matches) {
+	case 1:
+		return matches[0], nil
+	case 0:
+		return "", fmt.Errorf("%s has no parameter %q", tool.Name, flagName)
+	default:
+		sort.Strings(matches)
+		return "", fmt.Errorf("--%s is ambiguous for %s: %s", flagName, tool.Name, strings.Join(matches, ", "))
+	}
+}
+
+// propertyType returns the JSON schema type of a tool parameter
+func propertyType(tool mcp.Tool, property string) string {
+	schema, _ := tool.InputSchema.Properties[property].(map[string]interface{})
+	kind, _ := schema["type"].(string)
+	return kind
+}
+
+// convert parses a flag value into the JSON type of the parameter
+func convert(kind, value string) (interface{}, error) {
+	switch kind {
+	case "boolean":
+		return strconv.ParseBool(value)
+	case "number", "integer":
+		return strconv.ParseFloat(value, 64)
+	default:
+		return value, nil
+	}
+}
@@ -0,0 +1,80 @@
+package query
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/dlorenc/wolfi-mcp/pkg/secdb"
+	"github.com/dlorenc/wolfi-mcp/pkg/tools"
+	"github.com/dlorenc/wolfi-mcp/pkg/tools/graph"
+	"github.com/dlorenc/wolfi-mcp/pkg/tools/info"
+	"github.com/dlorenc/wolfi-mcp/pkg/tools/search"
+	"github.com/dlorenc/wolfi-mcp/pkg/tools/vulnerabilities"
+)
+
+func TestRun(t *testing.T) {
+	repo := apkindex.NewRepository([]*apk.Package{
+		{Name: "python-3.12", Version: "3.12.1-r0", Dependencies: []string{"libffi"}},
+		{Name: "libffi", Version: "3.4-r0"},
+		{Name: "py3-yaml", Version: "6.0-r0"},
+	})
+
+	runner := New()
+	tools.RegisterAll(runner, repo, nil, search.New(), info.New(), graph.New(), vulnerabilities.New(secdb.New()))
+
+	testCases := []struct {
+		name      string
+		args      []string
+		isError   bool
+		checkText []string
+	}{
+		{
+			name:      "info",
+			args:      []string{"info", "libffi"},
+			checkText: []string{"libffi", "3.4-r0"},
+		},
+		{
+			name:      "graph with suffix flag",
+			args:      []string{"graph", "python-3.12", "--type", "depends_on", "--depth=3"},
+			checkText: []string{"Dependency graph for python-3.12 (3.12.1-r0) with depth 3", "libffi (3.4-r0)"},
+		},
+		{
+			name:      "json output",
+			args:      []string{"search", "yaml", "--json"},
+			checkText: []string{`"content": [`, "py3-yaml"},
+		},
+		{
+			name:      "full tool name and bare boolean",
+			args:      []string{"package_vulnerabilities", "python-3.12", "--closure"},
+			checkText: []string{"runtime closure of python-3.12 (2 packages)"},
+		},
+		{
+			name:      "list",
+			args:      []string{"list"},
+			checkText: []string{"package_graph (graph)", "package_info (info)"},
+		},
+		{name: "unknown tool", args: []string{"frobnicate"}, isError: true},
+		{name: "missing required parameter", args: []string{"graph", "python-3.12"}, isError: true},
+		{name: "unknown parameter", args: []string{"info", "libffi", "--colour", "red"}, isError: true},
+		{name: "tool error", args: []string{"graph", "python-3.12", "--type", "nonsense"}, isError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := runner.Run(context.Background(), &out, tc.args)
+			if (err != nil) != tc.isError {
+				t.Fatalf("Expected error %v, got %v", tc.isError, err)
+			}
+			for _, check := range tc.checkText {
+				if !strings.Contains(out.String(), check) {
+					t.Errorf("Expected output to contain %q, got: %s", check, out.String())
+				}
+			}
+		})
+	}
+}
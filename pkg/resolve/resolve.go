@@ -0,0 +1,373 @@
+package resolve
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+)
+
+var (
+	// ErrNotFound is returned when nothing in the repository provides a name
+	ErrNotFound = errors.New("no package provides it")
+
+	// ErrUnsatisfiable is returned when providers exist but none matches the version constraint
+	ErrUnsatisfiable = errors.New("no available version satisfies the constraint")
+)
+
+// candidate is a package that provides a name at a given version
+type candidate struct {
+	pkg     *apk.Package
+	version string
+}
+
+// Resolver selects packages for dependency strings the way apk would
+type Resolver struct {
+	providers map[string][]candidate
+}
+
+// New creates a new Resolver over all packages in the repository
+func New(repo *apkindex.Repository) *Resolver {
+	r := &Resolver{providers: make(map[string][]candidate)}
+	for _, pkg := range repo.GetAllPackages() {
+		// Every package implicitly provides its own name
+		r.providers[pkg.Name] = append(r.providers[pkg.Name], candidate{pkg: pkg, version: pkg.Version})
+
+		for _, provide := range pkg.Provides {
+			name, version, _ := strings.Cut(provide, "=")
+			r.providers[name] = append(r.providers[name], candidate{pkg: pkg, version: version})
+		}
+	}
+	return r
+}
+
+// Choice describes how a single dependency string was resolved
+type Choice struct {
+	// Package is the package apk would select
+	Package *apk.Package
+
+	// Candidates holds every package that satisfies the constraint, best first
+	Candidates []*apk.Package
+
+	// Ambiguous is true when several packages tie for selection
+	Ambiguous bool
+}
+
+// Providers returns every package that provides the given name, regardless of version
+func (r *Resolver) Providers(name string) []*apk.Package {
+	var result []*apk.Package
+	for _, c := range r.providers[name] {
+		result = append(result, c.pkg)
+	}
+	return result
+}
+
+// Contested describes a name provided by several distinct packages
+type Contested struct {
+	// Name is the provided name, e.g. "so:libcrypto.so.3" or "cmd:sh"
+	Name string
+
+	// Choice is how apk would resolve the name, with one candidate per package name
+	Choice *Choice
+}
+
+// Contested returns every name provided by more than one distinct package
+// name, sorted by name. Several versions of the same package do not count.
+func (r *Resolver) Contested() []Contested {
+	var result []Contested
+	for name, candidates := range r.providers {
+		distinct := false
+		for _, c := range candidates[1:] {
+			if c.pkg.Name != candidates[0].pkg.Name {
+				distinct = true
+				break
+			}
+		}
+		if !distinct {
+			continue
+		}
+
+		choice, err := r.Resolve(name)
+		if err != nil {
+			continue
+		}
+		choice.Candidates = Distinct(choice.Candidates)
+		result = append(result, Contested{Name: name, Choice: choice})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// Distinct keeps the first, best ranked, package of each name from a ranked list
+func Distinct(packages []*apk.Package) []*apk.Package {
+	seen := make(map[string]bool)
+	var result []*apk.Package
+	for _, pkg := range packages {
+		if !seen[pkg.Name] {
+			seen[pkg.Name] = true
+			result = append(result, pkg)
+		}
+	}
+	return result
+}
+
+// Resolve selects the package that satisfies a dependency string such as "foo", "foo>=1.2" or "so:libc.so.6"
+func (r *Resolver) Resolve(dependency string) (*Choice, error) {
+	constraint := apk.ResolvePackageNameVersionPin(dependency)
+
+	all := r.providers[constraint.Name]
+	if len(all) == 0 {
+		return nil, ErrNotFound
+	}
+
+	versioned := strings.ContainsAny(strings.TrimPrefix(dependency, constraint.Name), "=<>~")
+
+	var matching []candidate
+	for _, c := range all {
+		if !versioned || satisfies(constraint, c.version) {
+			matching = append(matching, c)
+		}
+	}
+	if len(matching) == 0 {
+		return nil, ErrUnsatisfiable
+	}
+
+	// Rank the candidates: a real package with the requested name beats
+	// virtual providers, then provider_priority, then the highest version
+	sort.SliceStable(matching, func(i, j int) bool {
+		a, b := matching[i], matching[j]
+		aExact, bExact := a.pkg.Name == constraint.Name, b.pkg.Name == constraint.Name
+		if aExact != bExact {
+			return aExact
+		}
+		if a.pkg.ProviderPriority != b.pkg.ProviderPriority {
+			return a.pkg.ProviderPriority > b.pkg.ProviderPriority
+		}
+		if cmp := CompareVersions(a.pkg.Version, b.pkg.Version); cmp != 0 {
+			return cmp > 0
+		}
+		return a.pkg.Name < b.pkg.Name
+	})
+
+	choice := &Choice{Package: matching[0].pkg}
+	seen := make(map[*apk.Package]bool)
+	for _, c := range matching {
+		if !seen[c.pkg] {
+			seen[c.pkg] = true
+			choice.Candidates = append(choice.Candidates, c.pkg)
+		}
+	}
+
+	// Without a package of the exact name, several distinct packages at the
+	// same priority leave apk without a clear winner
+	best := matching[0].pkg
+	if best.Name != constraint.Name {
+		for _, c := range matching[1:] {
+			if c.pkg.Name != best.Name && c.pkg.ProviderPriority == best.ProviderPriority {
+				choice.Ambiguous = true
+				break
+			}
+		}
+	}
+
+	return choice, nil
+}
+
+// satisfies reports whether a provided version matches the constraint
+func satisfies(constraint apk.ParsedConstraint, version string) bool {
+	if version == "" {
+		return false
+	}
+	v, err := apk.ParseVersion(version)
+	if err != nil {
+		return false
+	}
+	ok, err := constraint.SatisfiedBy(v)
+	return err == nil && ok
+}
+
+// CompareVersions compares two apk version strings, falling back to a
+// plain string comparison when either of them cannot be parsed
+func CompareVersions(a, b string) int {
+	va, errA := apk.ParseVersion(a)
+	vb, errB := apk.ParseVersion(b)
+	if errA != nil || errB != nil {
+		return strings.Compare(a, b)
+	}
+	return apk.CompareVersions(va, vb)
+}
+
+// Problem describes a dependency that could not be resolved
+type Problem struct {
+	// Dependency is the dependency string that failed
+	Dependency string
+
+	// RequiredBy is the package that declared the dependency, empty for requested packages
+	RequiredBy string
+
+	// Err is ErrNotFound or ErrUnsatisfiable
+	Err error
+}
+
+func (p Problem) String() string {
+	if p.RequiredBy == "" {
+		return fmt.Sprintf("%s: %v", p.Dependency, p.Err)
+	}
+	return fmt.Sprintf("%s (required by %s): %v", p.Dependency, p.RequiredBy, p.Err)
+}
+
+// Ambiguity records a dependency for which several packages tied
+type Ambiguity struct {
+	// Dependency is the dependency string that was resolved
+	Dependency string
+
+	// Chosen is the package that was selected
+	Chosen string
+
+	// Candidates lists every package that could have been selected
+	Candidates []string
+}
+
+// Closure is the transitive set of packages installed for a set of requests
+type Closure struct {
+	// Packages holds the selected packages sorted by name
+	Packages []*apk.Package
+
+	// Problems holds every dependency that could not be resolved
+	Problems []Problem
+
+	// Ambiguities holds every dependency resolved without a clear winner
+	Ambiguities []Ambiguity
+
+	// Roots holds the names of the packages selected for the requests
+	Roots []string
+
+	parents      map[string]string
+	dependencies map[string][]string
+}
+
+// Closure computes the install closure of the requested dependency strings
+func (r *Resolver) Closure(requests []string) *Closure {
+	closure := &Closure{parents: make(map[string]string), dependencies: make(map[string][]string)}
+	selected := make(map[string]*apk.Package)
+	var queue []*apk.Package
+
+	add := func(dependency, requiredBy string) {
+		if strings.HasPrefix(dependency, "!") {
+			// Conflicts don't pull anything in
+			return
+		}
+
+		choice, err := r.Resolve(dependency)
+		if err != nil {
+			closure.Problems = append(closure.Problems, Problem{Dependency: dependency, RequiredBy: requiredBy, Err: err})
+			return
+		}
+
+		// Record the edge once the package satisfying the dependency is known
+		link := func(name string) {
+			if requiredBy == "" {
+				for _, root := range closure.Roots {
+					if root == name {
+						return
+					}
+				}
+				closure.Roots = append(closure.Roots, name)
+				return
+			}
+			for _, dep := range closure.dependencies[requiredBy] {
+				if dep == name {
+					return
+				}
+			}
+			closure.dependencies[requiredBy] = append(closure.dependencies[requiredBy], name)
+		}
+
+		// Prefer a package that is already part of the closure
+		for _, c := range choice.Candidates {
+			if selected[c.Name] == c {
+				link(c.Name)
+				return
+			}
+		}
+
+		if choice.Ambiguous {
+			ambiguity := Ambiguity{Dependency: dependency, Chosen: choice.Package.Name}
+			for _, c := range choice.Candidates {
+				ambiguity.Candidates = append(ambiguity.Candidates, c.Name)
+			}
+			closure.Ambiguities = append(closure.Ambiguities, ambiguity)
+		}
+
+		link(choice.Package.Name)
+		if _, ok := selected[choice.Package.Name]; ok {
+			// Another version of this package was already selected
+			return
+		}
+		selected[choice.Package.Name] = choice.Package
+		if requiredBy != "" {
+			closure.parents[choice.Package.Name] = requiredBy
+		}
+		queue = append(queue, choice.Package)
+	}
+
+	for _, request := range requests {
+		add(request, "")
+	}
+	for len(queue) > 0 {
+		pkg := queue[0]
+		queue = queue[1:]
+		for _, dep := range pkg.Dependencies {
+			add(dep, pkg.Name)
+		}
+	}
+
+	for _, pkg := range selected {
+		closure.Packages = append(closure.Packages, pkg)
+	}
+	sort.Slice(closure.Packages, func(i, j int) bool {
+		return closure.Packages[i].Name < closure.Packages[j].Name
+	})
+
+	return closure
+}
+
+// Dependencies returns the names of the closure packages selected for the
+// dependencies of the named package
+func (c *Closure) Dependencies(name string) []string {
+	return c.dependencies[name]
+}
+
+// Path returns the chain of packages that pulled the named package into the
+// closure, starting from a requested package
+func (c *Closure) Path(name string) []string {
+	path := []string{name}
+	for parent, ok := c.parents[name]; ok; parent, ok = c.parents[parent] {
+		path = append([]string{parent}, path...)
+	}
+	return path
+}
+
+// Size returns the total download size of the closure
+func (c *Closure) Size() uint64 {
+	var total uint64
+	for _, pkg := range c.Packages {
+		total += pkg.Size
+	}
+	return total
+}
+
+// InstalledSize returns the total installed size of the closure
+func (c *Closure) InstalledSize() uint64 {
+	var total uint64
+	for _, pkg := range c.Packages {
+		total += pkg.InstalledSize
+	}
+	return total
+}
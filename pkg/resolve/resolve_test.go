@@ -0,0 +1,171 @@
+package resolve
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+)
+
+func newTestRepository() *apkindex.Repository {
+	return apkindex.NewRepository([]*apk.Package{
+		{
+			Name:          "app",
+			Version:       "1.0.0-r0",
+			Dependencies:  []string{"lib>=2.0", "so:libc.so.6", "cmd:sh"},
+			Size:          100,
+			InstalledSize: 1000,
+		},
+		{
+			Name:          "lib",
+			Version:       "2.1.0-r0",
+			Dependencies:  []string{"so:libc.so.6"},
+			Size:          10,
+			InstalledSize: 100,
+		},
+		{
+			Name:          "glibc",
+			Version:       "2.40-r0",
+			Provides:      []string{"so:libc.so.6=6"},
+			Size:          1,
+			InstalledSize: 10,
+		},
+		{
+			Name:     "busybox",
+			Version:  "1.36-r0",
+			Provides: []string{"cmd:sh=1.36-r0"},
+		},
+		{
+			Name:     "bash",
+			Version:  "5.2-r0",
+			Provides: []string{"cmd:sh=5.2-r0"},
+		},
+		{
+			Name:             "dash",
+			Version:          "0.5-r0",
+			Provides:         []string{"cmd:sh=0.5-r0"},
+			ProviderPriority: 10,
+		},
+	})
+}
+
+func TestResolve(t *testing.T) {
+	r := New(newTestRepository())
+
+	testCases := []struct {
+		name       string
+		dependency string
+		expected   string
+		ambiguous  bool
+		err        error
+	}{
+		{name: "exact name", dependency: "lib", expected: "lib"},
+		{name: "satisfied constraint", dependency: "lib>=2.0", expected: "lib"},
+		{name: "unsatisfied constraint", dependency: "lib>3", err: ErrUnsatisfiable},
+		{name: "unknown package", dependency: "nope", err: ErrNotFound},
+		{name: "shared library", dependency: "so:libc.so.6", expected: "glibc"},
+		{name: "provider priority", dependency: "cmd:sh", expected: "dash"},
+		{name: "versioned provide", dependency: "cmd:sh<1", expected: "dash"},
+		{name: "tied providers", dependency: "cmd:sh>1", expected: "bash", ambiguous: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			choice, err := r.Resolve(tc.dependency)
+			if tc.err != nil {
+				if !errors.Is(err, tc.err) {
+					t.Fatalf("Expected error %v, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if choice.Package.Name != tc.expected {
+				t.Errorf("Expected %s, got %s", tc.expected, choice.Package.Name)
+			}
+			if choice.Ambiguous != tc.ambiguous {
+				t.Errorf("Expected Ambiguous=%v, got %v", tc.ambiguous, choice.Ambiguous)
+			}
+		})
+	}
+}
+
+func TestClosure(t *testing.T) {
+	r := New(newTestRepository())
+
+	closure := r.Closure([]string{"app", "missing"})
+
+	var names []string
+	for _, pkg := range closure.Packages {
+		names = append(names, pkg.Name)
+	}
+	expected := []string{"app", "dash", "glibc", "lib"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected closure %v, got %v", expected, names)
+	}
+
+	if len(closure.Problems) != 1 || closure.Problems[0].Dependency != "missing" {
+		t.Errorf("Expected a single problem for 'missing', got %v", closure.Problems)
+	}
+
+	if path := closure.Path("glibc"); !reflect.DeepEqual(path, []string{"app", "glibc"}) {
+		t.Errorf("Unexpected path for glibc: %v", path)
+	}
+
+	if deps := closure.Dependencies("app"); !reflect.DeepEqual(deps, []string{"lib", "glibc", "dash"}) {
+		t.Errorf("Unexpected dependencies of app: %v", deps)
+	}
+	if deps := closure.Dependencies("lib"); !reflect.DeepEqual(deps, []string{"glibc"}) {
+		t.Errorf("Unexpected dependencies of lib: %v", deps)
+	}
+	if !reflect.DeepEqual(closure.Roots, []string{"app"}) {
+		t.Errorf("Unexpected roots: %v", closure.Roots)
+	}
+
+	if closure.Size() != 111 {
+		t.Errorf("Expected size 111, got %d", closure.Size())
+	}
+	if closure.InstalledSize() != 1110 {
+		t.Errorf("Expected installed size 1110, got %d", closure.InstalledSize())
+	}
+}
+
+func TestContested(t *testing.T) {
+	contested := New(newTestRepository()).Contested()
+	if len(contested) != 1 || contested[0].Name != "cmd:sh" {
+		t.Fatalf("Expected only cmd:sh to be contested, got %+v", contested)
+	}
+
+	var names []string
+	for _, pkg := range contested[0].Choice.Candidates {
+		names = append(names, pkg.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"dash", "bash", "busybox"}) {
+		t.Errorf("Unexpected candidates: %v", names)
+	}
+	if contested[0].Choice.Ambiguous {
+		t.Errorf("Expected dash to win on provider_priority")
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	testCases := []struct {
+		a, b     string
+		expected int
+	}{
+		{"1.0.0", "1.0.0", 0},
+		{"1.10.0", "1.9.0", 1},
+		{"1.0.0-r1", "1.0.0-r10", -1},
+		{"2.0_rc1", "2.0", -1},
+		{"not a version", "not a version", 0},
+	}
+
+	for _, tc := range testCases {
+		if got := CompareVersions(tc.a, tc.b); got != tc.expected {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package sbom
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dlorenc/wolfi-mcp/pkg/resolve"
+)
+
+type cdxDocument struct {
+	BOMFormat    string          `json:"bomFormat"`
+	SpecVersion  string          `json:"specVersion"`
+	SerialNumber string          `json:"serialNumber"`
+	Version      int             `json:"version"`
+	Metadata     cdxMetadata     `json:"metadata"`
+	Components   []cdxComponent  `json:"components"`
+	Dependencies []cdxDependency `json:"dependencies"`
+}
+
+type cdxMetadata struct {
+	Timestamp string       `json:"timestamp"`
+	Tools     cdxTools     `json:"tools"`
+	Component cdxComponent `json:"component"`
+}
+
+type cdxTools struct {
+	Components []cdxComponent `json:"components"`
+}
+
+type cdxComponent struct {
+	Type        string        `json:"type"`
+	BOMRef      string        `json:"bom-ref,omitempty"`
+	Name        string        `json:"name"`
+	Version     string        `json:"version,omitempty"`
+	Description string        `json:"description,omitempty"`
+	Supplier    *cdxSupplier  `json:"supplier,omitempty"`
+	PURL        string        `json:"purl,omitempty"`
+	Licenses    []cdxLicense  `json:"licenses,omitempty"`
+	Properties  []cdxProperty `json:"properties,omitempty"`
+}
+
+type cdxSupplier struct {
+	Name string `json:"name"`
+}
+
+type cdxLicense struct {
+	Expression string           `json:"expression,omitempty"`
+	License    *cdxNamedLicense `json:"license,omitempty"`
+}
+
+type cdxNamedLicense struct {
+	Name string `json:"name"`
+}
+
+type cdxProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type cdxDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn"`
+}
+
+// rootRef is the bom-ref of the component the document describes
+const rootRef = "root"
+
+// CycloneDX renders the packages of a closure as a CycloneDX 1.5 JSON document
+func CycloneDX(closure *resolve.Closure, opts Options) ([]byte, error) {
+	id := documentID(closure, opts)
+	doc := cdxDocument{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: fmt.Sprintf("urn:uuid:%s-%s-%s-%s-%s", id[0:8], id[8:12], id[12:16], id[16:20], id[20:32]),
+		Version:      1,
+		Metadata: cdxMetadata{
+			Timestamp: opts.Created.UTC().Format(time.RFC3339),
+			Tools: cdxTools{
+				Components: []cdxComponent{{Type: "application", Name: creator}},
+			},
+			Component: cdxComponent{Type: "container", BOMRef: rootRef, Name: opts.Name},
+		},
+		Components:   []cdxComponent{},
+		Dependencies: []cdxDependency{},
+	}
+
+	refs := make(map[string]string, len(closure.Packages))
+	for _, pkg := range closure.Packages {
+		refs[pkg.Name] = PURL(pkg, opts.vendor(pkg).Namespace)
+	}
+
+	for _, pkg := range closure.Packages {
+		c := cdxComponent{
+			Type:        "library",
+			BOMRef:      refs[pkg.Name],
+			Name:        pkg.Name,
+			Version:     pkg.Version,
+			Description: pkg.Description,
+			PURL:        refs[pkg.Name],
+		}
+		if supplier := opts.vendor(pkg).Supplier; supplier != "" {
+			c.Supplier = &cdxSupplier{Name: supplier}
+		}
+		if expression, ok := license(pkg); ok {
+			c.Licenses = []cdxLicense{{Expression: expression}}
+		} else if pkg.License != "" {
+			c.Licenses = []cdxLicense{{License: &cdxNamedLicense{Name: pkg.License}}}
+		}
+		if pkg.Origin != "" {
+			c.Properties = append(c.Properties, cdxProperty{Name: "apk:origin", Value: pkg.Origin})
+		}
+		if checksum := controlChecksum(pkg); checksum != "" {
+			c.Properties = append(c.Properties, cdxProperty{Name: "apk:control-checksum", Value: checksum})
+		}
+		doc.Components = append(doc.Components, c)
+	}
+
+	root := cdxDependency{Ref: rootRef, DependsOn: []string{}}
+	for _, name := range closure.Roots {
+		root.DependsOn = append(root.DependsOn, refs[name])
+	}
+	doc.Dependencies = append(doc.Dependencies, root)
+	for _, pkg := range closure.Packages {
+		dependency := cdxDependency{Ref: refs[pkg.Name], DependsOn: []string{}}
+		for _, dep := range closure.Dependencies(pkg.Name) {
+			dependency.DependsOn = append(dependency.DependsOn, refs[dep])
+		}
+		doc.Dependencies = append(doc.Dependencies, dependency)
+	}
+
+	return marshal(doc)
+}
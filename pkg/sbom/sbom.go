@@ -0,0 +1,148 @@
+package sbom
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"github.com/dlorenc/wolfi-mcp/pkg/resolve"
+	"github.com/dlorenc/wolfi-mcp/pkg/spdx"
+)
+
+// Supported SBOM formats
+const (
+	FormatSPDX      = "spdx"
+	FormatCycloneDX = "cyclonedx"
+)
+
+// creator identifies this tool in generated documents
+const creator = "wolfi-mcp"
+
+// Options describes the generated document
+type Options struct {
+	// Name is the name of the document, e.g. the image or package list it describes
+	Name string
+
+	// Created is the creation time recorded in the document
+	Created time.Time
+
+	// Vendor returns the distribution a package is attributed to. Packages
+	// are attributed to Wolfi when it is nil or returns no namespace.
+	Vendor func(pkg *apk.Package) Vendor
+}
+
+// Vendor is the distribution a package comes from
+type Vendor struct {
+	// Namespace is the purl namespace, e.g. "wolfi" or "alpine"
+	Namespace string
+
+	// Supplier is the organization distributing the package, if known
+	Supplier string
+}
+
+// Wolfi is the vendor of the packages whose repository is not known
+var Wolfi = Vendor{Namespace: "wolfi", Supplier: "Wolfi"}
+
+// knownVendors maps repository domains to their distribution
+var knownVendors = []struct {
+	domain string
+	vendor Vendor
+}{
+	{"wolfi.dev", Wolfi},
+	{"alpinelinux.org", Vendor{Namespace: "alpine", Supplier: "Alpine Linux"}},
+	{"cgr.dev", Vendor{Namespace: "chainguard", Supplier: "Chainguard"}},
+}
+
+// VendorOf returns the distribution of a repository given by name, such as
+// packages.wolfi.dev/os. Other remote repositories are attributed to their
+// host without a supplier, and local directories to Wolfi.
+func VendorOf(repository string) Vendor {
+	if strings.HasPrefix(repository, "/") || strings.HasPrefix(repository, ".") {
+		return Wolfi
+	}
+	host, _, _ := strings.Cut(repository, "/")
+	host, _, _ = strings.Cut(strings.ToLower(host), ":")
+	if !strings.Contains(host, ".") {
+		return Wolfi
+	}
+	for _, known := range knownVendors {
+		if host == known.domain || strings.HasSuffix(host, "."+known.domain) {
+			return known.vendor
+		}
+	}
+	return Vendor{Namespace: host}
+}
+
+// vendor returns the distribution of a package
+func (o Options) vendor(pkg *apk.Package) Vendor {
+	if o.Vendor != nil {
+		if v := o.Vendor(pkg); v.Namespace != "" {
+			return v
+		}
+	}
+	return Wolfi
+}
+
+// Generate renders the packages of a closure as an SBOM in the given format
+func Generate(format string, closure *resolve.Closure, opts Options) ([]byte, error) {
+	switch strings.ToLower(format) {
+	case "", FormatSPDX:
+		return SPDX(closure, opts)
+	case FormatCycloneDX:
+		return CycloneDX(closure, opts)
+	default:
+		return nil, fmt.Errorf("unsupported SBOM format %q, expected %s or %s", format, FormatSPDX, FormatCycloneDX)
+	}
+}
+
+// PURL returns the package URL of a package of the namespace's distribution
+func PURL(pkg *apk.Package, namespace string) string {
+	purl := fmt.Sprintf("pkg:apk/%s/%s@%s", url.PathEscape(namespace), url.PathEscape(pkg.Name), url.PathEscape(pkg.Version))
+	if pkg.Arch != "" {
+		purl += "?arch=" + url.QueryEscape(pkg.Arch)
+	}
+	return purl
+}
+
+// controlChecksum returns the SHA-1 checksum of the control segment of a
+// package, as recorded by the APKINDEX, or an empty string when it is not
+// known. It identifies the package metadata, not the .apk file.
+func controlChecksum(pkg *apk.Package) string {
+	if len(pkg.Checksum) == 0 {
+		return ""
+	}
+	return "sha1:" + hex.EncodeToString(pkg.Checksum)
+}
+
+// license returns the normalized SPDX expression of a package license, if it is one
+func license(pkg *apk.Package) (string, bool) {
+	e, err := spdx.Parse(pkg.License)
+	if err != nil {
+		return "", false
+	}
+	return e.String(), true
+}
+
+// documentID returns a stable identifier for the document contents, so the
+// same closure generated at the same time always gets the same identifier
+func documentID(closure *resolve.Closure, opts Options) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n", opts.Name, opts.Created.UTC().Format(time.RFC3339))
+	for _, pkg := range closure.Packages {
+		fmt.Fprintf(h, "%s\n", PURL(pkg, opts.vendor(pkg).Namespace))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func marshal(v interface{}) ([]byte, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error encoding SBOM: %w", err)
+	}
+	return append(data, '\n'), nil
+}
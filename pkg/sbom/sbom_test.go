@@ -0,0 +1,160 @@
+package sbom
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/dlorenc/wolfi-mcp/pkg/resolve"
+)
+
+func testClosure() *resolve.Closure {
+	repo := apkindex.NewRepository([]*apk.Package{
+		{Name: "app", Version: "1.0-r0", Arch: "x86_64", License: "Apache-2.0", Origin: "app", Checksum: []byte{0xde, 0xad}, Dependencies: []string{"so:libfoo.so.1"}},
+		{Name: "libfoo", Version: "2.0-r1", Arch: "x86_64", License: "Custom license text", Origin: "foo", Provides: []string{"so:libfoo.so.1=1"}},
+	})
+	return resolve.New(repo).Closure([]string{"app"})
+}
+
+func TestPURL(t *testing.T) {
+	pkg := &apk.Package{Name: "libstdc++", Version: "13.2.0-r1", Arch: "aarch64"}
+	if got, want := PURL(pkg, "wolfi"), "pkg:apk/wolfi/libstdc++@13.2.0-r1?arch=aarch64"; got != want {
+		t.Errorf("PURL() = %q, want %q", got, want)
+	}
+}
+
+func TestVendorOf(t *testing.T) {
+	testCases := map[string]Vendor{
+		"packages.wolfi.dev/os":                    Wolfi,
+		"dl-cdn.alpinelinux.org/alpine/v3.20/main": {Namespace: "alpine", Supplier: "Alpine Linux"},
+		"packages.cgr.dev/extras":                  {Namespace: "chainguard", Supplier: "Chainguard"},
+		"APK.Example.com:8443/overlay":             {Namespace: "apk.example.com"},
+		"/home/user/packages":                      Wolfi,
+		"./packages":                               Wolfi,
+	}
+	for repository, want := range testCases {
+		if got := VendorOf(repository); got != want {
+			t.Errorf("VendorOf(%q) = %+v, want %+v", repository, got, want)
+		}
+	}
+}
+
+func TestSPDX(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	data, err := Generate(FormatSPDX, testClosure(), Options{Name: "test", Created: created})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	var doc spdxDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if doc.SPDXVersion != "SPDX-2.3" || doc.CreationInfo.Created != "2024-05-01T12:00:00Z" {
+		t.Errorf("Unexpected document header: %+v", doc)
+	}
+	if len(doc.Packages) != 2 {
+		t.Fatalf("Expected 2 packages, got %d", len(doc.Packages))
+	}
+
+	app := doc.Packages[0]
+	if app.SPDXID != "SPDXRef-Package-app-1.0-r0" || app.LicenseDeclared != "Apache-2.0" {
+		t.Errorf("Unexpected app package: %+v", app)
+	}
+	// The index checksum covers the control segment only, so it is not a package checksum
+	if strings.Contains(string(data), `"checksums"`) {
+		t.Errorf("Expected no package checksums, got %s", data)
+	}
+	if len(app.Annotations) != 1 || app.Annotations[0].Comment != "apk:control-checksum sha1:dead" || app.Annotations[0].AnnotationType != "OTHER" {
+		t.Errorf("Unexpected annotations: %+v", app.Annotations)
+	}
+	if app.ExternalRefs[0].ReferenceLocator != "pkg:apk/wolfi/app@1.0-r0?arch=x86_64" {
+		t.Errorf("Unexpected purl: %v", app.ExternalRefs)
+	}
+	if doc.Packages[1].LicenseDeclared != noAssertion || doc.Packages[1].SourceInfo != "built by the foo origin package" {
+		t.Errorf("Unexpected libfoo package: %+v", doc.Packages[1])
+	}
+
+	expected := []spdxRelationship{
+		{SPDXElementID: "SPDXRef-DOCUMENT", RelationshipType: "DESCRIBES", RelatedSPDXElement: "SPDXRef-Package-app-1.0-r0"},
+		{SPDXElementID: "SPDXRef-Package-app-1.0-r0", RelationshipType: "DEPENDS_ON", RelatedSPDXElement: "SPDXRef-Package-libfoo-2.0-r1"},
+	}
+	if !reflect.DeepEqual(doc.Relationships, expected) {
+		t.Errorf("Unexpected relationships: %+v", doc.Relationships)
+	}
+
+	// The same closure at the same time must produce the same document
+	again, _ := Generate(FormatSPDX, testClosure(), Options{Name: "test", Created: created})
+	if string(again) != string(data) {
+		t.Errorf("Expected deterministic output")
+	}
+}
+
+func TestSPDXIDs(t *testing.T) {
+	ids := spdxIDs([]*apk.Package{
+		{Name: "foo+bar", Version: "1.0-r0"},
+		{Name: "foo-bar", Version: "1.0-r0"},
+		{Name: "foo_bar", Version: "1.0-r0"},
+	})
+	want := map[string]string{
+		"foo+bar": "SPDXRef-Package-foo-bar-1.0-r0",
+		"foo-bar": "SPDXRef-Package-foo-bar-1.0-r0-2",
+		"foo_bar": "SPDXRef-Package-foo-bar-1.0-r0-3",
+	}
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("spdxIDs() = %v, want %v", ids, want)
+	}
+}
+
+func TestCycloneDX(t *testing.T) {
+	data, err := Generate(FormatCycloneDX, testClosure(), Options{Name: "test", Created: time.Unix(0, 0)})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	var doc cdxDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if doc.BOMFormat != "CycloneDX" || doc.SpecVersion != "1.5" || len(doc.SerialNumber) != len("urn:uuid:")+36 {
+		t.Errorf("Unexpected document header: %+v", doc)
+	}
+	if len(doc.Components) != 2 {
+		t.Fatalf("Expected 2 components, got %d", len(doc.Components))
+	}
+
+	if doc.Components[0].Supplier == nil || doc.Components[0].Supplier.Name != "Wolfi" {
+		t.Errorf("Unexpected supplier: %+v", doc.Components[0].Supplier)
+	}
+
+	if !reflect.DeepEqual(doc.Components[0].Properties, []cdxProperty{{Name: "apk:origin", Value: "app"}, {Name: "apk:control-checksum", Value: "sha1:dead"}}) {
+		t.Errorf("Unexpected app properties: %+v", doc.Components[0].Properties)
+	}
+
+	lib := doc.Components[1]
+	if lib.PURL != "pkg:apk/wolfi/libfoo@2.0-r1?arch=x86_64" || lib.Licenses[0].License.Name != "Custom license text" {
+		t.Errorf("Unexpected libfoo component: %+v", lib)
+	}
+	if !reflect.DeepEqual(lib.Properties, []cdxProperty{{Name: "apk:origin", Value: "foo"}}) {
+		t.Errorf("Unexpected properties: %+v", lib.Properties)
+	}
+
+	expected := []cdxDependency{
+		{Ref: rootRef, DependsOn: []string{"pkg:apk/wolfi/app@1.0-r0?arch=x86_64"}},
+		{Ref: "pkg:apk/wolfi/app@1.0-r0?arch=x86_64", DependsOn: []string{"pkg:apk/wolfi/libfoo@2.0-r1?arch=x86_64"}},
+		{Ref: "pkg:apk/wolfi/libfoo@2.0-r1?arch=x86_64", DependsOn: []string{}},
+	}
+	if !reflect.DeepEqual(doc.Dependencies, expected) {
+		t.Errorf("Unexpected dependencies: %+v", doc.Dependencies)
+	}
+}
+
+func TestGenerateUnknownFormat(t *testing.T) {
+	if _, err := Generate("swid", testClosure(), Options{}); err == nil {
+		t.Errorf("Expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,176 @@
+package sbom
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"github.com/dlorenc/wolfi-mcp/pkg/resolve"
+)
+
+// noAssertion is the SPDX value for fields whose value is unknown
+const noAssertion = "NOASSERTION"
+
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	Name             string            `json:"name"`
+	SPDXID           string            `json:"SPDXID"`
+	VersionInfo      string            `json:"versionInfo"`
+	Supplier         string            `json:"supplier"`
+	DownloadLocation string            `json:"downloadLocation"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	Homepage         string            `json:"homepage,omitempty"`
+	SourceInfo       string            `json:"sourceInfo,omitempty"`
+	LicenseConcluded string            `json:"licenseConcluded"`
+	LicenseDeclared  string            `json:"licenseDeclared"`
+	CopyrightText    string            `json:"copyrightText"`
+	Description      string            `json:"description,omitempty"`
+	ExternalRefs     []spdxExternalRef `json:"externalRefs"`
+	Annotations      []spdxAnnotation  `json:"annotations,omitempty"`
+}
+
+// spdxAnnotation records information that has no SPDX field, such as the
+// apk control checksum, which is not a checksum of the package file
+type spdxAnnotation struct {
+	AnnotationDate string `json:"annotationDate"`
+	AnnotationType string `json:"annotationType"`
+	Annotator      string `json:"annotator"`
+	Comment        string `json:"comment"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// SPDX renders the packages of a closure as an SPDX 2.3 JSON document
+func SPDX(closure *resolve.Closure, opts Options) ([]byte, error) {
+	doc := spdxDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              opts.Name,
+		DocumentNamespace: fmt.Sprintf("https://spdx.org/spdxdocs/%s/%s", creator, documentID(closure, opts)),
+		CreationInfo: spdxCreationInfo{
+			Created:  opts.Created.UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: " + creator},
+		},
+		Packages:      []spdxPackage{},
+		Relationships: []spdxRelationship{},
+	}
+
+	ids := spdxIDs(closure.Packages)
+
+	for _, pkg := range closure.Packages {
+		vendor := opts.vendor(pkg)
+		supplier := noAssertion
+		if vendor.Supplier != "" {
+			supplier = "Organization: " + vendor.Supplier
+		}
+		p := spdxPackage{
+			Name:             pkg.Name,
+			SPDXID:           ids[pkg.Name],
+			VersionInfo:      pkg.Version,
+			Supplier:         supplier,
+			DownloadLocation: noAssertion,
+			Homepage:         pkg.URL,
+			LicenseConcluded: noAssertion,
+			LicenseDeclared:  noAssertion,
+			CopyrightText:    noAssertion,
+			Description:      pkg.Description,
+			ExternalRefs: []spdxExternalRef{{
+				ReferenceCategory: "PACKAGE-MANAGER",
+				ReferenceType:     "purl",
+				ReferenceLocator:  PURL(pkg, vendor.Namespace),
+			}},
+		}
+		if expression, ok := license(pkg); ok {
+			p.LicenseDeclared = expression
+		}
+		if pkg.Origin != "" {
+			p.SourceInfo = fmt.Sprintf("built by the %s origin package", pkg.Origin)
+		}
+		if checksum := controlChecksum(pkg); checksum != "" {
+			p.Annotations = []spdxAnnotation{{
+				AnnotationDate: doc.CreationInfo.Created,
+				AnnotationType: "OTHER",
+				Annotator:      "Tool: " + creator,
+				Comment:        "apk:control-checksum " + checksum,
+			}}
+		}
+		doc.Packages = append(doc.Packages, p)
+	}
+
+	for _, root := range closure.Roots {
+		doc.Relationships = append(doc.Relationships, spdxRelationship{
+			SPDXElementID:      doc.SPDXID,
+			RelationshipType:   "DESCRIBES",
+			RelatedSPDXElement: ids[root],
+		})
+	}
+	for _, pkg := range closure.Packages {
+		for _, dep := range closure.Dependencies(pkg.Name) {
+			doc.Relationships = append(doc.Relationships, spdxRelationship{
+				SPDXElementID:      ids[pkg.Name],
+				RelationshipType:   "DEPENDS_ON",
+				RelatedSPDXElement: ids[dep],
+			})
+		}
+	}
+
+	return marshal(doc)
+}
+
+// spdxIDs returns the SPDX element identifiers of packages by name. Names
+// that only differ by characters SPDX identifiers cannot hold, such as foo+bar
+// and foo-bar, get a numbered suffix after the first one.
+func spdxIDs(packages []*apk.Package) map[string]string {
+	ids := make(map[string]string, len(packages))
+	used := make(map[string]bool, len(packages))
+	for _, pkg := range packages {
+		id := spdxID(pkg)
+		for n := 2; used[id]; n++ {
+			id = fmt.Sprintf("%s-%d", spdxID(pkg), n)
+		}
+		used[id] = true
+		ids[pkg.Name] = id
+	}
+	return ids
+}
+
+// spdxID returns the SPDX element identifier of a package, which may only
+// contain letters, numbers, dots and dashes
+func spdxID(pkg *apk.Package) string {
+	id := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-':
+			return r
+		default:
+			return '-'
+		}
+	}, pkg.Name+"-"+pkg.Version)
+	return "SPDXRef-Package-" + id
+}
@@ -0,0 +1,266 @@
+package secdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dlorenc/wolfi-mcp/pkg/resolve"
+)
+
+// Advisory is a vulnerability affecting a range of versions of a package
+type Advisory struct {
+	// ID is the vulnerability identifier, e.g. CVE-2024-1234 or GHSA-xxxx-xxxx-xxxx
+	ID string
+
+	// Aliases holds other identifiers of the same vulnerability
+	Aliases []string
+
+	// Package is the name of the affected package
+	Package string
+
+	// Introduced is the first affected version, empty when every version up to the fix is affected
+	Introduced string
+
+	// Fixed is the first version with the fix, empty when no fix was released
+	Fixed string
+
+	// LastAffected is the last affected version, for ranges without a fixed version
+	LastAffected string
+
+	// NotAffected marks vulnerabilities the package was never affected by
+	NotAffected bool
+}
+
+// Affects reports whether the given version of the package is vulnerable
+func (a Advisory) Affects(version string) bool {
+	if a.NotAffected {
+		return false
+	}
+	if a.Introduced != "" && resolve.CompareVersions(version, a.Introduced) < 0 {
+		return false
+	}
+	if a.Fixed != "" {
+		return resolve.CompareVersions(version, a.Fixed) < 0
+	}
+	if a.LastAffected != "" {
+		return resolve.CompareVersions(version, a.LastAffected) <= 0
+	}
+	return true
+}
+
+// Database holds advisories indexed by package name
+type Database struct {
+	advisories map[string][]Advisory
+}
+
+// New creates an empty advisory database
+func New() *Database {
+	return &Database{advisories: make(map[string][]Advisory)}
+}
+
+// Load adds the advisories of a secdb JSON file or a directory of OSV JSON files
+func (d *Database) Load(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("error reading advisory feed: %w", err)
+	}
+	if !info.IsDir() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("error reading advisory feed: %w", err)
+		}
+		advisories, err := ParseSecDB(data)
+		if err != nil {
+			return fmt.Errorf("error parsing %s: %w", path, err)
+		}
+		d.add(advisories)
+		d.sort()
+		return nil
+	}
+
+	defer d.sort()
+	return filepath.WalkDir(path, func(file string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || filepath.Ext(file) != ".json" {
+			return nil
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("error reading advisory feed: %w", err)
+		}
+		advisories, err := ParseOSV(data)
+		if err != nil {
+			return fmt.Errorf("error parsing %s: %w", file, err)
+		}
+		d.add(advisories)
+		return nil
+	})
+}
+
+// Advisories returns every advisory of a package, sorted by ID
+func (d *Database) Advisories(name string) []Advisory {
+	return d.advisories[name]
+}
+
+// Count returns the number of advisories in the database
+func (d *Database) Count() int {
+	count := 0
+	for _, advisories := range d.advisories {
+		count += len(advisories)
+	}
+	return count
+}
+
+// Affecting returns the advisories affecting the given version of a package
+func (d *Database) Affecting(name, version string) []Advisory {
+	var result []Advisory
+	for _, advisory := range d.advisories[name] {
+		if advisory.Affects(version) {
+			result = append(result, advisory)
+		}
+	}
+	return result
+}
+
+func (d *Database) add(advisories []Advisory) {
+	for _, advisory := range advisories {
+		d.advisories[advisory.Package] = append(d.advisories[advisory.Package], advisory)
+	}
+}
+
+func (d *Database) sort() {
+	for _, advisories := range d.advisories {
+		sort.SliceStable(advisories, func(i, j int) bool {
+			if advisories[i].ID != advisories[j].ID {
+				return advisories[i].ID < advisories[j].ID
+			}
+			return advisories[i].Fixed < advisories[j].Fixed
+		})
+	}
+}
+
+// secDB is the Alpine and Wolfi security database format
+type secDB struct {
+	Packages []struct {
+		Pkg struct {
+			Name     string              `json:"name"`
+			Secfixes map[string][]string `json:"secfixes"`
+		} `json:"pkg"`
+	} `json:"packages"`
+}
+
+// ParseSecDB parses an Alpine or Wolfi secdb JSON document. Each secfixes
+// entry lists the vulnerabilities fixed by a version, "0" listing those the
+// package was never affected by.
+func ParseSecDB(data []byte) ([]Advisory, error) {
+	var db secDB
+	if err := json.Unmarshal(data, &db); err != nil {
+		return nil, err
+	}
+
+	var result []Advisory
+	for _, entry := range db.Packages {
+		for version, ids := range entry.Pkg.Secfixes {
+			for _, id := range ids {
+				// Entries may carry aliases separated by spaces, e.g. "CVE-2024-1 GHSA-..."
+				fields := strings.Fields(id)
+				if len(fields) == 0 {
+					continue
+				}
+				advisory := Advisory{ID: fields[0], Aliases: fields[1:], Package: entry.Pkg.Name}
+				if version == "0" {
+					advisory.NotAffected = true
+				} else {
+					advisory.Fixed = version
+				}
+				result = append(result, advisory)
+			}
+		}
+	}
+	return result, nil
+}
+
+// osvEntry is the subset of the OSV schema used to match packages
+type osvEntry struct {
+	ID       string   `json:"id"`
+	Aliases  []string `json:"aliases"`
+	Affected []struct {
+		Package struct {
+			Ecosystem string `json:"ecosystem"`
+			Name      string `json:"name"`
+		} `json:"package"`
+		Ranges []struct {
+			Type   string `json:"type"`
+			Events []struct {
+				Introduced   string `json:"introduced"`
+				Fixed        string `json:"fixed"`
+				LastAffected string `json:"last_affected"`
+			} `json:"events"`
+		} `json:"ranges"`
+	} `json:"affected"`
+}
+
+// apkEcosystems are the OSV ecosystems whose packages are apk packages
+var apkEcosystems = []string{"Wolfi", "Alpine", "Chainguard"}
+
+// isAPKEcosystem reports whether an OSV ecosystem, such as "Alpine:v3.20",
+// versions its packages with apk versions
+func isAPKEcosystem(ecosystem string) bool {
+	name, _, _ := strings.Cut(ecosystem, ":")
+	for _, known := range apkEcosystems {
+		if strings.EqualFold(name, known) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseOSV parses an OSV JSON document, turning each affected range into an
+// advisory. Packages of other ecosystems, such as PyPI or npm, are left out,
+// since their names and versions do not refer to apk packages.
+func ParseOSV(data []byte) ([]Advisory, error) {
+	var entry osvEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+
+	var result []Advisory
+	for _, affected := range entry.Affected {
+		if !isAPKEcosystem(affected.Package.Ecosystem) {
+			continue
+		}
+		for _, r := range affected.Ranges {
+			if r.Type != "ECOSYSTEM" {
+				// Git and semver ranges do not use apk versions
+				continue
+			}
+
+			advisory := Advisory{ID: entry.ID, Aliases: entry.Aliases, Package: affected.Package.Name}
+			for _, event := range r.Events {
+				switch {
+				case event.Introduced != "":
+					// Start a new range at every introduced event
+					if advisory.Introduced != "" || advisory.Fixed != "" || advisory.LastAffected != "" {
+						result = append(result, advisory)
+						advisory = Advisory{ID: entry.ID, Aliases: entry.Aliases, Package: affected.Package.Name}
+					}
+					if event.Introduced != "0" {
+						advisory.Introduced = event.Introduced
+					}
+				case event.Fixed != "":
+					advisory.Fixed = event.Fixed
+				case event.LastAffected != "":
+					advisory.LastAffected = event.LastAffected
+				}
+			}
+			result = append(result, advisory)
+		}
+	}
+	return result, nil
+}
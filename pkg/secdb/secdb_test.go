@@ -0,0 +1,131 @@
+package secdb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testSecDB = `{
+  "apkurl": "{{urlprefix}}/{{reponame}}/{{arch}}/{{pkg.name}}-{{pkg.ver}}.apk",
+  "reponame": "os",
+  "packages": [
+    {"pkg": {"name": "openssl", "secfixes": {
+      "3.1.4-r0": ["CVE-2023-5678"],
+      "3.1.5-r0": ["CVE-2024-0727 GHSA-9v9h-cgj8-h64p"],
+      "0": ["CVE-2022-0001"]
+    }}}
+  ]
+}`
+
+const testOSV = `{
+  "id": "CVE-2024-9999",
+  "aliases": ["GHSA-aaaa-bbbb-cccc"],
+  "affected": [{
+    "package": {"ecosystem": "Wolfi", "name": "curl"},
+    "ranges": [
+      {"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "8.5.0-r0"}, {"introduced": "8.6.0-r0"}, {"last_affected": "8.6.0-r2"}]},
+      {"type": "GIT", "events": [{"introduced": "0"}, {"fixed": "abcdef"}]}
+    ]
+  }, {
+    "package": {"ecosystem": "PyPI", "name": "curl"},
+    "ranges": [
+      {"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "9.0"}]}
+    ]
+  }]
+}`
+
+func TestSecDB(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "security.json")
+	if err := os.WriteFile(path, []byte(testSecDB), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	db := New()
+	if err := db.Load(path); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if db.Count() != 3 {
+		t.Errorf("Expected 3 advisories, got %d", db.Count())
+	}
+
+	advisories := db.Advisories("openssl")
+	if advisories[1].ID != "CVE-2023-5678" || advisories[1].Fixed != "3.1.4-r0" {
+		t.Errorf("Unexpected advisory: %+v", advisories[1])
+	}
+	if advisories[2].ID != "CVE-2024-0727" || len(advisories[2].Aliases) != 1 {
+		t.Errorf("Expected aliases to be split from the ID, got %+v", advisories[2])
+	}
+
+	testCases := []struct {
+		version  string
+		affected []string
+	}{
+		{"3.1.3-r0", []string{"CVE-2023-5678", "CVE-2024-0727"}},
+		{"3.1.4-r0", []string{"CVE-2024-0727"}},
+		{"3.1.5-r0", nil},
+	}
+	for _, tc := range testCases {
+		var ids []string
+		for _, advisory := range db.Affecting("openssl", tc.version) {
+			ids = append(ids, advisory.ID)
+		}
+		if len(ids) != len(tc.affected) || (len(ids) > 0 && ids[len(ids)-1] != tc.affected[len(tc.affected)-1]) {
+			t.Errorf("Affecting(openssl, %s) = %v, want %v", tc.version, ids, tc.affected)
+		}
+	}
+}
+
+func TestOSV(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "curl"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "curl", "CVE-2024-9999.json"), []byte(testOSV), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("not an advisory"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	db := New()
+	if err := db.Load(dir); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// The GIT range and the PyPI package are ignored and the ECOSYSTEM range is split in two
+	if len(db.Advisories("curl")) != 2 {
+		t.Fatalf("Expected 2 advisories, got %+v", db.Advisories("curl"))
+	}
+
+	testCases := []struct {
+		version  string
+		affected bool
+	}{
+		{"8.4.0-r0", true},
+		{"8.5.0-r0", false},
+		{"8.6.0-r1", true},
+		{"8.6.0-r3", false},
+		{"8.9.0-r0", false},
+	}
+	for _, tc := range testCases {
+		if got := len(db.Affecting("curl", tc.version)) > 0; got != tc.affected {
+			t.Errorf("curl %s affected = %v, want %v", tc.version, got, tc.affected)
+		}
+	}
+}
+
+func TestIsAPKEcosystem(t *testing.T) {
+	for ecosystem, want := range map[string]bool{
+		"Wolfi":        true,
+		"Alpine:v3.20": true,
+		"chainguard":   true,
+		"PyPI":         false,
+		"npm":          false,
+		"":             false,
+	} {
+		if got := isAPKEcosystem(ecosystem); got != want {
+			t.Errorf("isAPKEcosystem(%q) = %v, want %v", ecosystem, got, want)
+		}
+	}
+}
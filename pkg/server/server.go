@@ -1,15 +1,36 @@
 package server
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
 	"github.com/dlorenc/wolfi-mcp/pkg/tools"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
 
+// loggerName identifies the server in the log notifications sent to clients
+const loggerName = "wolfi-mcp"
+
 // Config holds server configuration options
 type Config struct {
 	Name    string
 	Version string
+
+	// Logger receives the errors of the stdio transport, if set
+	Logger *slog.Logger
 }
 
 // DefaultConfig returns a default server configuration
@@ -24,22 +45,32 @@ func DefaultConfig() Config {
 type Server struct {
 	config Config
 	server *server.MCPServer
+
+	// sessions holds the client sessions that log notifications are sent to
+	sessions sync.Map
+
+	// levels holds the logging level each session asked for, by session ID
+	levels sync.Map
 }
 
 // New creates a new Server instance
 func New(config Config) *Server {
-	mcpServer := server.NewMCPServer(
+	s := &Server{config: config}
+
+	hooks := &server.Hooks{}
+	hooks.AddOnRegisterSession(func(ctx context.Context, session server.ClientSession) {
+		s.sessions.Store(session.SessionID(), session)
+	})
+
+	s.server = server.NewMCPServer(
 		config.Name,
 		config.Version,
 		server.WithResourceCapabilities(true, true),
 		server.WithLogging(),
 		server.WithRecovery(),
+		server.WithHooks(hooks),
 	)
-
-	return &Server{
-		config: config,
-		server: mcpServer,
-	}
+	return s
 }
 
 // AddTool adds a tool and its handler to the server
@@ -47,7 +78,194 @@ func (s *Server) AddTool(tool mcp.Tool, handler tools.ToolHandler) {
 	s.server.AddTool(tool, server.ToolHandlerFunc(handler))
 }
 
-// Serve starts the server to handle stdin/stdout communication
+// unregister forgets a session that ended
+func (s *Server) unregister(sessionID string) {
+	s.server.UnregisterSession(sessionID)
+	s.sessions.Delete(sessionID)
+	s.levels.Delete(sessionID)
+}
+
+// Log sends a log message notification to every initialized client that
+// asked for messages of this level
+func (s *Server) Log(level slog.Level, message string, attrs map[string]any) {
+	data := map[string]any{"message": message}
+	for key, value := range attrs {
+		data[key] = value
+	}
+	params := map[string]any{
+		"level":  loggingLevel(level),
+		"logger": loggerName,
+		"data":   data,
+	}
+
+	s.sessions.Range(func(key, value any) bool {
+		if wanted, ok := s.levels.Load(key); ok && severity(loggingLevel(level)) < severity(wanted.(mcp.LoggingLevel)) {
+			return true
+		}
+		session := value.(server.ClientSession)
+		// Clients that are not initialized yet or too slow to keep up miss the message
+		_ = s.server.SendNotificationToClient(s.server.WithContext(context.Background(), session), "notifications/message", params)
+		return true
+	})
+}
+
+// loggingLevel maps a slog level to the closest MCP logging level
+func loggingLevel(level slog.Level) mcp.LoggingLevel {
+	switch {
+	case level >= slog.LevelError:
+		return mcp.LoggingLevelError
+	case level >= slog.LevelWarn:
+		return mcp.LoggingLevelWarning
+	case level >= slog.LevelInfo:
+		return mcp.LoggingLevelInfo
+	default:
+		return mcp.LoggingLevelDebug
+	}
+}
+
+// severities lists the MCP logging levels from the least to the most severe
+var severities = []mcp.LoggingLevel{
+	mcp.LoggingLevelDebug,
+	mcp.LoggingLevelInfo,
+	mcp.LoggingLevelNotice,
+	mcp.LoggingLevelWarning,
+	mcp.LoggingLevelError,
+	mcp.LoggingLevelCritical,
+	mcp.LoggingLevelAlert,
+	mcp.LoggingLevelEmergency,
+}
+
+// severity returns the rank of an MCP logging level, or -1 if it is unknown
+func severity(level mcp.LoggingLevel) int {
+	return slices.Index(severities, level)
+}
+
+// Serve starts the server to handle stdin/stdout communication, until stdin
+// is closed or the process is interrupted
 func (s *Server) Serve() error {
-	return server.ServeStdio(s.server)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	err := s.Listen(ctx, os.Stdin, os.Stdout)
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+// stdioSession is the session of the single client of a stdio connection
+type stdioSession struct {
+	notifications chan mcp.JSONRPCNotification
+	initialized   atomic.Bool
+}
+
+func (s *stdioSession) SessionID() string {
+	return "stdio"
+}
+
+func (s *stdioSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return s.notifications
+}
+
+func (s *stdioSession) Initialize() {
+	s.initialized.Store(true)
+}
+
+func (s *stdioSession) Initialized() bool {
+	return s.initialized.Load()
+}
+
+// Listen serves the client writing JSON-RPC messages to in and reading the
+// responses and notifications from out, one per line. It returns when in is
+// closed or ctx is cancelled, and the client session is forgotten.
+func (s *Server) Listen(ctx context.Context, in io.Reader, out io.Writer) error {
+	session := &stdioSession{notifications: make(chan mcp.JSONRPCNotification, 100)}
+	if err := s.server.RegisterSession(ctx, session); err != nil {
+		return fmt.Errorf("error registering session: %w", err)
+	}
+	defer s.unregister(session.SessionID())
+
+	ctx, cancel := context.WithCancel(s.server.WithContext(ctx, session))
+	defer cancel()
+
+	// Responses and notifications are written from different goroutines
+	var mu sync.Mutex
+	write := func(message mcp.JSONRPCMessage) {
+		data, err := json.Marshal(message)
+		if err == nil {
+			mu.Lock()
+			_, err = fmt.Fprintf(out, "%s\n", data)
+			mu.Unlock()
+		}
+		if err != nil && s.config.Logger != nil {
+			s.config.Logger.Error("Error writing message", "error", err)
+		}
+	}
+	go func() {
+		for {
+			select {
+			case notification := <-session.notifications:
+				write(notification)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	lines := make(chan string)
+	failed := make(chan error, 1)
+	go func() {
+		reader := bufio.NewReader(in)
+		for {
+			line, err := reader.ReadString('\n')
+			if line = strings.TrimSpace(line); line != "" {
+				select {
+				case lines <- line:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err != nil {
+				failed <- err
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case line := <-lines:
+			if response := s.handle(ctx, session.SessionID(), line); response != nil {
+				write(response)
+			}
+		case err := <-failed:
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("error reading messages: %w", err)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// handle answers a message from a client. Requests to set the logging
+// level are answered here, as the MCP server does not keep track of them.
+func (s *Server) handle(ctx context.Context, sessionID string, line string) mcp.JSONRPCMessage {
+	var request struct {
+		ID     mcp.RequestId `json:"id"`
+		Method mcp.MCPMethod `json:"method"`
+		Params struct {
+			Level mcp.LoggingLevel `json:"level"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal([]byte(line), &request); err != nil || request.ID == nil || request.Method != "logging/setLevel" {
+		return s.server.HandleMessage(ctx, json.RawMessage(line))
+	}
+
+	if severity(request.Params.Level) < 0 {
+		return mcp.NewJSONRPCError(request.ID, mcp.INVALID_PARAMS, fmt.Sprintf("unknown logging level %q", request.Params.Level), nil)
+	}
+	s.levels.Store(sessionID, request.Params.Level)
+	return mcp.NewJSONRPCResponse(request.ID, mcp.Result{})
 }
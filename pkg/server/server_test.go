@@ -1,7 +1,12 @@
 package server
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"strings"
 	"testing"
 
 	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
@@ -79,8 +84,73 @@ func TestRegisterAll(t *testing.T) {
 	mockTool2 := newMockTool()
 
 	// Register all tools
-	tools.RegisterAll(srv, mockRepo, mockTool1, mockTool2)
+	tools.RegisterAll(srv, mockRepo, nil, mockTool1, mockTool2)
 
 	// Again, we can't easily verify the internal state,
 	// but we can verify the code executes without errors
 }
+
+// TestListen tests that each client gets the log messages of the level it
+// asked for, and that its session is forgotten when it disconnects
+func TestListen(t *testing.T) {
+	srv := New(DefaultConfig())
+	in, client := io.Pipe()
+	reader, out := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Listen(context.Background(), in, out)
+	}()
+
+	lines := bufio.NewScanner(reader)
+	next := func() map[string]any {
+		t.Helper()
+		if !lines.Scan() {
+			t.Fatalf("Expected a message, got %v", lines.Err())
+		}
+		var message map[string]any
+		if err := json.Unmarshal(lines.Bytes(), &message); err != nil {
+			t.Fatalf("Expected a JSON message, got %q", lines.Text())
+		}
+		return message
+	}
+	send := func(message string) {
+		t.Helper()
+		if _, err := io.WriteString(client, message+"\n"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	send(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{},"clientInfo":{"name":"test","version":"1.0"}}}`)
+	next()
+
+	send(`{"jsonrpc":"2.0","id":2,"method":"logging/setLevel","params":{"level":"loud"}}`)
+	if message := next(); message["error"] == nil {
+		t.Errorf("Expected an unknown level to be rejected, got %v", message)
+	}
+
+	send(`{"jsonrpc":"2.0","id":3,"method":"logging/setLevel","params":{"level":"error"}}`)
+	if message := next(); message["error"] != nil || message["id"] != float64(3) {
+		t.Errorf("Expected the level to be set, got %v", message)
+	}
+
+	srv.Log(slog.LevelWarn, "below the level", nil)
+	srv.Log(slog.LevelError, "at the level", nil)
+	message := next()
+	params, _ := message["params"].(map[string]any)
+	if params["level"] != "error" || !strings.Contains(lines.Text(), "at the level") {
+		t.Errorf("Expected only the error to be sent, got %s", lines.Text())
+	}
+
+	client.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("Expected Listen to return when the client disconnects, got %v", err)
+	}
+	sessions := 0
+	srv.sessions.Range(func(_, _ any) bool {
+		sessions++
+		return true
+	})
+	if sessions != 0 {
+		t.Errorf("Expected the session to be removed, got %d sessions", sessions)
+	}
+}
@@ -0,0 +1,92 @@
+package snapshots
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/dlorenc/wolfi-mcp/pkg/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxCachedHandlers bounds how many historical repositories are kept in memory per tool
+const maxCachedHandlers = 4
+
+// RepositoryFunc returns the repository as it was at the given time, along
+// with a key identifying the snapshots it was built from
+type RepositoryFunc func(asOf time.Time) (*apkindex.Repository, string, error)
+
+// asOfTool wraps a tool so it can be queried against historical snapshots
+type asOfTool struct {
+	tools.Tool
+	at RepositoryFunc
+
+	mu       sync.Mutex
+	handlers map[string]tools.ToolHandler
+}
+
+// WithAsOf adds an optional as_of argument to a tool. When it is given, the
+// tool runs against the repository built from the snapshots current at that time.
+func WithAsOf(tool tools.Tool, at RepositoryFunc) tools.Tool {
+	return &asOfTool{
+		Tool:     tool,
+		at:       at,
+		handlers: make(map[string]tools.ToolHandler),
+	}
+}
+
+// GetTool returns the wrapped tool definition with the as_of argument added
+func (t *asOfTool) GetTool() mcp.Tool {
+	tool := t.Tool.GetTool()
+
+	// Copy the properties so the wrapped tool's definition is left untouched
+	properties := make(map[string]interface{}, len(tool.InputSchema.Properties)+1)
+	for name, property := range tool.InputSchema.Properties {
+		properties[name] = property
+	}
+	properties["as_of"] = map[string]interface{}{
+		"type":        "string",
+		"description": "Query the repository as it was at this date (YYYY-MM-DD) or RFC 3339 time, using the snapshot history",
+	}
+	tool.InputSchema.Properties = properties
+
+	return tool
+}
+
+// GetHandler returns the wrapped handler, switching repositories when as_of is given
+func (t *asOfTool) GetHandler(repo *apkindex.Repository) tools.ToolHandler {
+	current := t.Tool.GetHandler(repo)
+
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		asOf, _ := request.Params.Arguments["as_of"].(string)
+		if asOf == "" {
+			return current(ctx, request)
+		}
+
+		at, err := ParseTime(asOf)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		historical, key, err := t.at(at)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error loading the repository as of %s: %v", asOf, err)), nil
+		}
+
+		// Handlers may index the repository up front, so reuse them per snapshot set
+		t.mu.Lock()
+		handler, ok := t.handlers[key]
+		if !ok {
+			if len(t.handlers) >= maxCachedHandlers {
+				t.handlers = make(map[string]tools.ToolHandler)
+			}
+			handler = t.Tool.GetHandler(historical)
+			t.handlers[key] = handler
+		}
+		t.mu.Unlock()
+
+		return handler(ctx, request)
+	}
+}
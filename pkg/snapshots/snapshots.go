@@ -0,0 +1,267 @@
+package snapshots
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	objectsDir  = "objects"
+	historyFile = "history.json"
+)
+
+// Snapshot is a version of an index as it was fetched at a point in time
+type Snapshot struct {
+	// Location is the index URL or path the snapshot was fetched from
+	Location string `json:"location"`
+
+	// Digest is the sha256 of the index file
+	Digest string `json:"digest"`
+
+	// FetchedAt is when this content was first fetched
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// Retention controls how many snapshots are kept for each location
+type Retention struct {
+	// MaxAge removes snapshots older than this, zero keeps them forever
+	MaxAge time.Duration
+
+	// MaxSnapshots keeps at most this many snapshots, zero keeps all of them
+	MaxSnapshots int
+}
+
+// Store keeps content-addressed copies of fetched indexes along with the
+// time they were fetched
+type Store struct {
+	dir       string
+	retention Retention
+
+	mu        sync.Mutex
+	snapshots []Snapshot
+}
+
+// Open opens the snapshot store in dir, creating it if needed
+func Open(dir string, retention Retention) (*Store, error) {
+	if err := os.MkdirAll(filepath.Join(dir, objectsDir), 0755); err != nil {
+		return nil, fmt.Errorf("error creating snapshot directory: %w", err)
+	}
+
+	s := &Store{dir: dir, retention: retention}
+
+	data, err := os.ReadFile(filepath.Join(dir, historyFile))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("error reading snapshot history: %w", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &s.snapshots); err != nil {
+			return nil, fmt.Errorf("error parsing snapshot history: %w", err)
+		}
+	}
+
+	return s, nil
+}
+
+// Record stores the index file fetched from location. A new snapshot is only
+// added when the content differs from the latest snapshot of the location.
+func (s *Store) Record(location, path string, fetchedAt time.Time) (Snapshot, error) {
+	digest, err := fileDigest(path)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("error hashing %s: %w", path, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if history := s.history(location); len(history) > 0 && history[len(history)-1].Digest == digest {
+		return history[len(history)-1], nil
+	}
+
+	if _, err := os.Stat(s.objectPath(digest)); errors.Is(err, os.ErrNotExist) {
+		if err := copyFile(path, s.objectPath(digest)); err != nil {
+			return Snapshot{}, fmt.Errorf("error storing snapshot: %w", err)
+		}
+	}
+
+	snapshot := Snapshot{Location: location, Digest: digest, FetchedAt: fetchedAt.UTC()}
+	s.snapshots = append(s.snapshots, snapshot)
+	s.prune(fetchedAt)
+
+	if err := s.save(); err != nil {
+		return Snapshot{}, err
+	}
+	return snapshot, nil
+}
+
+// History returns the snapshots of a location, oldest first
+func (s *Store) History(location string) []Snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.history(location)
+}
+
+// Locations returns every location with snapshots, sorted
+func (s *Store) Locations() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen := make(map[string]bool)
+	var result []string
+	for _, snapshot := range s.snapshots {
+		if !seen[snapshot.Location] {
+			seen[snapshot.Location] = true
+			result = append(result, snapshot.Location)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// AsOf returns the snapshot of a location that was current at the given time
+func (s *Store) AsOf(location string, t time.Time) (Snapshot, bool) {
+	history := s.History(location)
+	for i := len(history) - 1; i >= 0; i-- {
+		if !history[i].FetchedAt.After(t) {
+			return history[i], true
+		}
+	}
+	return Snapshot{}, false
+}
+
+// Path returns the local file holding the snapshot's index
+func (s *Store) Path(snapshot Snapshot) string {
+	return s.objectPath(snapshot.Digest)
+}
+
+// history returns the snapshots of a location sorted by fetch time, the lock must be held
+func (s *Store) history(location string) []Snapshot {
+	var result []Snapshot
+	for _, snapshot := range s.snapshots {
+		if snapshot.Location == location {
+			result = append(result, snapshot)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].FetchedAt.Before(result[j].FetchedAt)
+	})
+	return result
+}
+
+// prune applies the retention policy and removes unreferenced objects, the lock must be held
+func (s *Store) prune(now time.Time) {
+	counts := make(map[string]int)
+	for _, snapshot := range s.snapshots {
+		counts[snapshot.Location]++
+	}
+
+	// Snapshots are appended in fetch order, so the oldest come first
+	var kept []Snapshot
+	for _, snapshot := range s.snapshots {
+		latest := counts[snapshot.Location] == 1
+		tooOld := s.retention.MaxAge > 0 && now.Sub(snapshot.FetchedAt) > s.retention.MaxAge
+		tooMany := s.retention.MaxSnapshots > 0 && counts[snapshot.Location] > s.retention.MaxSnapshots
+		if !latest && (tooOld || tooMany) {
+			counts[snapshot.Location]--
+			continue
+		}
+		kept = append(kept, snapshot)
+	}
+	s.snapshots = kept
+
+	referenced := make(map[string]bool)
+	for _, snapshot := range s.snapshots {
+		referenced[snapshot.Digest] = true
+	}
+	entries, err := os.ReadDir(filepath.Join(s.dir, objectsDir))
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		digest := strings.TrimSuffix(entry.Name(), ".tar.gz")
+		if !referenced[digest] {
+			os.Remove(filepath.Join(s.dir, objectsDir, entry.Name()))
+		}
+	}
+}
+
+// save writes the snapshot history, the lock must be held
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s.snapshots, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding snapshot history: %w", err)
+	}
+
+	// Write to a temporary file first so a crash never leaves a truncated history
+	tmp := filepath.Join(s.dir, historyFile+".tmp")
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("error writing snapshot history: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(s.dir, historyFile)); err != nil {
+		return fmt.Errorf("error writing snapshot history: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) objectPath(digest string) string {
+	return filepath.Join(s.dir, objectsDir, digest+".tar.gz")
+}
+
+// ParseTime parses an as_of argument, either a date (meaning the end of that
+// day, UTC) or an RFC 3339 timestamp
+func ParseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	day, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD or an RFC 3339 timestamp", value)
+	}
+	return day.Add(24*time.Hour - time.Nanosecond), nil
+}
+
+func fileDigest(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := dst + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
@@ -0,0 +1,198 @@
+package snapshots
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/dlorenc/wolfi-mcp/pkg/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const testLocation = "https://packages.wolfi.dev/os/x86_64/APKINDEX.tar.gz"
+
+// writeFile writes an index file with the given content and returns its path
+func writeFile(t *testing.T, dir, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, "APKINDEX.tar.gz")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	return path
+}
+
+func TestRecordAndAsOf(t *testing.T) {
+	dir := t.TempDir()
+	store, err := Open(filepath.Join(dir, "snapshots"), Retention{})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	day1 := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+	day3 := day2.Add(24 * time.Hour)
+
+	first, err := store.Record(testLocation, writeFile(t, dir, "first"), day1)
+	if err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+
+	// Unchanged content does not create a new snapshot
+	if again, err := store.Record(testLocation, writeFile(t, dir, "first"), day2); err != nil || !again.FetchedAt.Equal(day1) {
+		t.Errorf("Expected the existing snapshot for unchanged content, got %+v, %v", again, err)
+	}
+
+	second, err := store.Record(testLocation, writeFile(t, dir, "second"), day3)
+	if err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+
+	if history := store.History(testLocation); len(history) != 2 {
+		t.Fatalf("Expected 2 snapshots, got %d", len(history))
+	}
+
+	if snapshot, ok := store.AsOf(testLocation, day2); !ok || snapshot.Digest != first.Digest {
+		t.Errorf("Expected the first snapshot as of day 2, got %+v", snapshot)
+	}
+	if snapshot, ok := store.AsOf(testLocation, day3); !ok || snapshot.Digest != second.Digest {
+		t.Errorf("Expected the second snapshot as of day 3, got %+v", snapshot)
+	}
+	if _, ok := store.AsOf(testLocation, day1.Add(-time.Hour)); ok {
+		t.Error("Expected no snapshot before the first fetch")
+	}
+
+	content, err := os.ReadFile(store.Path(first))
+	if err != nil || string(content) != "first" {
+		t.Errorf("Unexpected snapshot content %q, %v", content, err)
+	}
+
+	// The history survives reopening the store
+	reopened, err := Open(filepath.Join(dir, "snapshots"), Retention{})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if locations := reopened.Locations(); len(locations) != 1 || locations[0] != testLocation {
+		t.Errorf("Unexpected locations after reopening: %v", locations)
+	}
+	if history := reopened.History(testLocation); len(history) != 2 {
+		t.Errorf("Expected 2 snapshots after reopening, got %d", len(history))
+	}
+}
+
+func TestRetention(t *testing.T) {
+	dir := t.TempDir()
+	store, err := Open(filepath.Join(dir, "snapshots"), Retention{MaxAge: 48 * time.Hour, MaxSnapshots: 2})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	var recorded []Snapshot
+	for i, content := range []string{"a", "b", "c"} {
+		snapshot, err := store.Record(testLocation, writeFile(t, dir, content), start.Add(time.Duration(i)*time.Hour))
+		if err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+		recorded = append(recorded, snapshot)
+	}
+
+	history := store.History(testLocation)
+	if len(history) != 2 || history[0].Digest != recorded[1].Digest {
+		t.Fatalf("Expected the 2 newest snapshots, got %+v", history)
+	}
+	if _, err := os.Stat(store.Path(recorded[0])); !os.IsNotExist(err) {
+		t.Errorf("Expected the pruned snapshot's object to be removed, got %v", err)
+	}
+
+	// Everything but the latest snapshot expires
+	if _, err := store.Record(testLocation, writeFile(t, dir, "d"), start.Add(30*24*time.Hour)); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	if history := store.History(testLocation); len(history) != 1 {
+		t.Errorf("Expected only the latest snapshot after expiry, got %+v", history)
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	day, err := ParseTime("2026-10-13")
+	if err != nil {
+		t.Fatalf("ParseTime failed: %v", err)
+	}
+	if day.Format(time.RFC3339) != "2026-10-13T23:59:59Z" {
+		t.Errorf("Expected the end of the day, got %s", day.Format(time.RFC3339))
+	}
+
+	exact, err := ParseTime("2026-10-13T08:30:00Z")
+	if err != nil || exact.Hour() != 8 {
+		t.Errorf("Unexpected RFC 3339 result %v, %v", exact, err)
+	}
+
+	if _, err := ParseTime("last tuesday"); err == nil {
+		t.Error("Expected error for an invalid date")
+	}
+}
+
+// nameTool is a tool that reports the packages of its repository
+type nameTool struct {
+	tools.BaseTool
+}
+
+func (t *nameTool) GetHandler(repo *apkindex.Repository) tools.ToolHandler {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var names []string
+		for _, pkg := range repo.GetAllPackages() {
+			names = append(names, pkg.Name+"-"+pkg.Version)
+		}
+		return mcp.NewToolResultText(strings.Join(names, ",")), nil
+	}
+}
+
+func TestWithAsOf(t *testing.T) {
+	base := &nameTool{BaseTool: tools.BaseTool{Tool: mcp.NewTool("names", mcp.WithString("package"))}}
+
+	loads := 0
+	historical := apkindex.NewRepository([]*apk.Package{{Name: "curl", Version: "8.9.0-r0"}})
+	tool := WithAsOf(base, func(asOf time.Time) (*apkindex.Repository, string, error) {
+		loads++
+		return historical, "key", nil
+	})
+
+	definition := tool.GetTool()
+	if _, ok := definition.InputSchema.Properties["as_of"]; !ok {
+		t.Error("Expected the as_of argument to be added")
+	}
+	if _, ok := base.GetTool().InputSchema.Properties["as_of"]; ok {
+		t.Error("Expected the wrapped tool definition to be left untouched")
+	}
+
+	current := apkindex.NewRepository([]*apk.Package{{Name: "curl", Version: "8.10.0-r0"}})
+	handler := tool.GetHandler(current)
+
+	call := func(args map[string]interface{}) *mcp.CallToolResult {
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = args
+		result, err := handler(context.Background(), req)
+		if err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+		return result
+	}
+
+	if text := call(map[string]interface{}{}).Content[0].(mcp.TextContent).Text; text != "curl-8.10.0-r0" {
+		t.Errorf("Expected the current repository, got %q", text)
+	}
+	if text := call(map[string]interface{}{"as_of": "2026-10-01"}).Content[0].(mcp.TextContent).Text; text != "curl-8.9.0-r0" {
+		t.Errorf("Expected the historical repository, got %q", text)
+	}
+	if result := call(map[string]interface{}{"as_of": "yesterday"}); !result.IsError {
+		t.Error("Expected an error for an invalid as_of value")
+	}
+	if loads != 1 {
+		t.Errorf("Expected the historical repository to be requested once, got %d", loads)
+	}
+}
@@ -0,0 +1,137 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+)
+
+// Index records which loaded index each package came from. Packages are
+// matched by pointer, so only packages of the loaded sources are known. All
+// methods accept a nil *Index, which knows no packages.
+type Index struct {
+	sources []Source
+	source  map[*apk.Package]int
+}
+
+// NewIndex indexes the packages of the loaded sources. A package present in
+// several sources is attributed to the first one.
+func NewIndex(srcs []Source) *Index {
+	ix := &Index{sources: srcs, source: make(map[*apk.Package]int)}
+	for i, src := range srcs {
+		for _, pkg := range src.Packages {
+			if _, ok := ix.source[pkg]; !ok {
+				ix.source[pkg] = i
+			}
+		}
+	}
+	return ix
+}
+
+// Of returns the source a package was loaded from
+func (ix *Index) Of(pkg *apk.Package) (Source, bool) {
+	if ix == nil {
+		return Source{}, false
+	}
+	i, ok := ix.source[pkg]
+	if !ok {
+		return Source{}, false
+	}
+	return ix.sources[i], true
+}
+
+// Label returns the name of the repository a package came from, or an empty
+// string when it is not known
+func (ix *Index) Label(pkg *apk.Package) string {
+	src, ok := ix.Of(pkg)
+	if !ok {
+		return ""
+	}
+	return src.Name()
+}
+
+// Suffix returns " [repository]" for a package of a known repository, to be
+// appended to a package listed in tool output, or an empty string
+func (ix *Index) Suffix(pkg *apk.Package) string {
+	if label := ix.Label(pkg); label != "" {
+		return " [" + label + "]"
+	}
+	return ""
+}
+
+// Describe returns the repository name, index location and index digest of a
+// package, or an empty string when it is not known
+func (ix *Index) Describe(pkg *apk.Package) string {
+	src, ok := ix.Of(pkg)
+	if !ok {
+		return ""
+	}
+	if src.Digest == "" {
+		return fmt.Sprintf("%s (%s)", src.Name(), src.Location)
+	}
+	return fmt.Sprintf("%s (%s, index sha256:%s)", src.Name(), src.Location, shortDigest(src.Digest))
+}
+
+// Names returns the distinct repository names, in source order
+func (ix *Index) Names() []string {
+	if ix == nil {
+		return nil
+	}
+	var names []string
+	seen := make(map[string]bool)
+	for _, src := range ix.sources {
+		if name := src.Name(); !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Scope returns the packages of the named repository, merged from its
+// indexes the same way the loaded indexes are merged. Packages replaced by
+// another repository are kept. The name may also be the location of one of
+// its indexes or its base URL.
+func (ix *Index) Scope(repository string) ([]*apk.Package, error) {
+	if ix == nil {
+		return nil, fmt.Errorf("repository information is not available")
+	}
+
+	var result []*apk.Package
+	found := false
+	for _, src := range ix.sources {
+		if src.Matches(repository) {
+			result = MergePackages(result, src.Packages)
+			found = true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("unknown repository %q (loaded repositories: %s)", repository, strings.Join(ix.Names(), ", "))
+	}
+	return result, nil
+}
+
+// shortDigest abbreviates a digest for display
+func shortDigest(digest string) string {
+	if len(digest) > 12 {
+		return digest[:12]
+	}
+	return digest
+}
+
+// indexKey is the context key of the provenance index
+type indexKey struct{}
+
+// NewContext returns a context carrying the provenance index, so tools can
+// show where the packages they list came from
+func NewContext(ctx context.Context, ix *Index) context.Context {
+	return context.WithValue(ctx, indexKey{}, ix)
+}
+
+// FromContext returns the provenance index of a context, or nil
+func FromContext(ctx context.Context) *Index {
+	ix, _ := ctx.Value(indexKey{}).(*Index)
+	return ix
+}
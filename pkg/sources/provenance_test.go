@@ -0,0 +1,159 @@
+package sources
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/dlorenc/wolfi-mcp/pkg/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// testIndex returns an index of two repositories, the overlay replacing a
+// with a newer version, and the merged packages
+func testIndex() (*Index, []*apk.Package) {
+	a := &apk.Package{Name: "a", Version: "1.0-r0"}
+	b := &apk.Package{Name: "b", Version: "1.0-r0"}
+	newerA := &apk.Package{Name: "a", Version: "2.0-r0"}
+	c := &apk.Package{Name: "c", Version: "1.0-r0"}
+	srcs := []Source{
+		{Location: "https://packages.wolfi.dev/os/x86_64/APKINDEX.tar.gz", Digest: "0123456789abcdef0123", Packages: []*apk.Package{a}},
+		{Location: "https://packages.wolfi.dev/os/aarch64/APKINDEX.tar.gz", Packages: []*apk.Package{b}},
+		{Location: "https://example.com/overlay/x86_64/APKINDEX.tar.gz", Packages: []*apk.Package{newerA, c}},
+	}
+	return NewIndex(srcs), []*apk.Package{newerA, b, c}
+}
+
+func TestIndex(t *testing.T) {
+	ix, packages := testIndex()
+	a, c := ix.sources[0].Packages[0], packages[2]
+
+	if got := ix.Label(a); got != "packages.wolfi.dev/os" {
+		t.Errorf("Label(a) = %q, want the first source", got)
+	}
+	if got := ix.Suffix(c); got != " [example.com/overlay]" {
+		t.Errorf("Suffix(c) = %q", got)
+	}
+	if got := ix.Suffix(&apk.Package{Name: "unknown"}); got != "" {
+		t.Errorf("Suffix() of an unknown package = %q, want empty", got)
+	}
+	want := "packages.wolfi.dev/os (https://packages.wolfi.dev/os/x86_64/APKINDEX.tar.gz, index sha256:0123456789ab)"
+	if got := ix.Describe(a); got != want {
+		t.Errorf("Describe(a) = %q, want %q", got, want)
+	}
+	if got := ix.Names(); !reflect.DeepEqual(got, []string{"packages.wolfi.dev/os", "example.com/overlay"}) {
+		t.Errorf("Names() = %v", got)
+	}
+
+	// A nil index knows no packages
+	var none *Index
+	if got := none.Suffix(a); got != "" {
+		t.Errorf("Suffix() on a nil index = %q, want empty", got)
+	}
+	if got := FromContext(context.Background()); got != nil {
+		t.Errorf("FromContext() without an index = %v, want nil", got)
+	}
+	if got := FromContext(NewContext(context.Background(), ix)); got != ix {
+		t.Errorf("FromContext() did not return the index")
+	}
+}
+
+func TestScope(t *testing.T) {
+	ix, _ := testIndex()
+
+	testCases := []struct {
+		repository string
+		expected   []string
+		errorText  string
+	}{
+		// The upstream a is kept although the overlay replaced it
+		{repository: "packages.wolfi.dev/os", expected: []string{"a-1.0-r0", "b-1.0-r0"}},
+		{repository: "https://packages.wolfi.dev/os/", expected: []string{"a-1.0-r0", "b-1.0-r0"}},
+		{repository: "https://packages.wolfi.dev/os/aarch64/APKINDEX.tar.gz", expected: []string{"b-1.0-r0"}},
+		{repository: "example.com/overlay", expected: []string{"a-2.0-r0", "c-1.0-r0"}},
+		{repository: "example.org", errorText: "loaded repositories: packages.wolfi.dev/os, example.com/overlay"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.repository, func(t *testing.T) {
+			scoped, err := ix.Scope(tc.repository)
+			if tc.errorText != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errorText) {
+					t.Fatalf("Expected error containing %q, got %v", tc.errorText, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Scope() error = %v", err)
+			}
+
+			var names []string
+			for _, pkg := range scoped {
+				names = append(names, pkg.Name+"-"+pkg.Version)
+			}
+			sort.Strings(names)
+			if !reflect.DeepEqual(names, tc.expected) {
+				t.Errorf("Scope() = %v, want %v", names, tc.expected)
+			}
+		})
+	}
+}
+
+// listTool lists the names of the packages of the repository
+type listTool struct {
+	tools.BaseTool
+}
+
+func (t *listTool) GetHandler(repo *apkindex.Repository) tools.ToolHandler {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var names []string
+		for _, pkg := range repo.GetAllPackages() {
+			names = append(names, pkg.Name+"-"+pkg.Version+FromContext(ctx).Suffix(pkg))
+		}
+		sort.Strings(names)
+		return mcp.NewToolResultText(strings.Join(names, ",")), nil
+	}
+}
+
+func TestWithRepository(t *testing.T) {
+	ix, packages := testIndex()
+	tool := WithRepository(&listTool{BaseTool: tools.BaseTool{Tool: mcp.NewTool("list_packages")}}, ix)
+
+	if _, ok := tool.GetTool().InputSchema.Properties["repository"]; !ok {
+		t.Errorf("Expected a repository argument")
+	}
+
+	handler := Middleware(ix)(tool.GetTool(), tool.GetHandler(apkindex.NewRepository(packages)))
+	call := func(arguments map[string]interface{}) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = arguments
+		result, err := handler(context.Background(), request)
+		if err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+		return result
+	}
+	text := func(result *mcp.CallToolResult) string {
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	if got := text(call(map[string]interface{}{})); got != "a-2.0-r0 [example.com/overlay],b-1.0-r0 [packages.wolfi.dev/os],c-1.0-r0 [example.com/overlay]" {
+		t.Errorf("Unscoped result = %q", got)
+	}
+	if got := text(call(map[string]interface{}{"repository": "example.com/overlay"})); got != "a-2.0-r0 [example.com/overlay],c-1.0-r0 [example.com/overlay]" {
+		t.Errorf("Scoped result = %q", got)
+	}
+	if got := text(call(map[string]interface{}{"repository": "packages.wolfi.dev/os"})); got != "a-1.0-r0 [packages.wolfi.dev/os],b-1.0-r0 [packages.wolfi.dev/os]" {
+		t.Errorf("Expected the packages replaced by the overlay to be kept, got %q", got)
+	}
+	if result := call(map[string]interface{}{"repository": "example.org"}); !result.IsError {
+		t.Errorf("Expected an error for an unknown repository")
+	}
+	if result := call(map[string]interface{}{"repository": "example.com/overlay", "as_of": "2024-01-01"}); !result.IsError {
+		t.Errorf("Expected an error when combining repository and as_of")
+	}
+}
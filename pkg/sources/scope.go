@@ -0,0 +1,86 @@
+package sources
+
+import (
+	"context"
+	"sync"
+
+	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/dlorenc/wolfi-mcp/pkg/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Middleware makes the provenance index available to every tool call through
+// FromContext
+func Middleware(ix *Index) tools.Middleware {
+	return func(tool mcp.Tool, next tools.ToolHandler) tools.ToolHandler {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return next(NewContext(ctx, ix), request)
+		}
+	}
+}
+
+// repositoryTool wraps a tool so its queries can be scoped to one repository
+type repositoryTool struct {
+	tools.Tool
+	index *Index
+}
+
+// WithRepository adds an optional repository argument to a tool. When it is
+// given, the tool runs against the packages of that repository only.
+func WithRepository(tool tools.Tool, ix *Index) tools.Tool {
+	return &repositoryTool{Tool: tool, index: ix}
+}
+
+// GetTool returns the wrapped tool definition with the repository argument added
+func (t *repositoryTool) GetTool() mcp.Tool {
+	tool := t.Tool.GetTool()
+
+	// Copy the properties so the wrapped tool's definition is left untouched
+	properties := make(map[string]interface{}, len(tool.InputSchema.Properties)+1)
+	for name, property := range tool.InputSchema.Properties {
+		properties[name] = property
+	}
+	properties["repository"] = map[string]interface{}{
+		"type":        "string",
+		"description": "Only consider packages from this repository, by name, index location or base URL",
+		"examples":    t.index.Names(),
+	}
+	tool.InputSchema.Properties = properties
+
+	return tool
+}
+
+// GetHandler returns the wrapped handler, scoping the repository when the
+// repository argument is given
+func (t *repositoryTool) GetHandler(repo *apkindex.Repository) tools.ToolHandler {
+	current := t.Tool.GetHandler(repo)
+
+	// Handlers may index the repository up front, so build one per repository
+	var mu sync.Mutex
+	handlers := make(map[string]tools.ToolHandler)
+
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		repository, _ := request.Params.Arguments["repository"].(string)
+		if repository == "" {
+			return current(ctx, request)
+		}
+		if asOf, _ := request.Params.Arguments["as_of"].(string); asOf != "" {
+			return mcp.NewToolResultError("The repository and as_of arguments cannot be combined"), nil
+		}
+
+		mu.Lock()
+		handler, ok := handlers[repository]
+		if !ok {
+			packages, err := t.index.Scope(repository)
+			if err != nil {
+				mu.Unlock()
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			handler = t.Tool.GetHandler(apkindex.NewRepository(packages))
+			handlers[repository] = handler
+		}
+		mu.Unlock()
+
+		return handler(ctx, request)
+	}
+}
@@ -0,0 +1,126 @@
+package sources
+
+import (
+	"strings"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"github.com/dlorenc/wolfi-mcp/pkg/resolve"
+)
+
+// Source is a single loaded APKINDEX and the packages it contained
+type Source struct {
+	// Location is the index as it was given, either a URL or a local path
+	Location string
+
+	// Path is the local file the index was loaded from
+	Path string
+
+	// Digest is the sha256 of the index file, if known
+	Digest string
+
+	// Packages holds the packages parsed from the index, before merging
+	Packages []*apk.Package
+}
+
+// Arch returns the architecture directory of the index location, if it follows
+// the standard <repository>/<arch>/APKINDEX.tar.gz layout
+func (s Source) Arch() string {
+	parts := strings.Split(strings.TrimSuffix(s.Location, "/"), "/")
+	if len(parts) < 2 || !strings.HasPrefix(parts[len(parts)-1], "APKINDEX") {
+		return ""
+	}
+	return parts[len(parts)-2]
+}
+
+// Repository returns the repository base URL or directory of the index location,
+// i.e. the location without the trailing <arch>/APKINDEX.tar.gz
+func (s Source) Repository() string {
+	location := strings.TrimSuffix(s.Location, "/")
+	if s.Arch() == "" {
+		return location
+	}
+	parts := strings.Split(location, "/")
+	return strings.Join(parts[:len(parts)-2], "/")
+}
+
+// Name returns a short name for the repository of the source: its base URL
+// without the scheme, or its base directory
+func (s Source) Name() string {
+	repository := s.Repository()
+	if _, rest, ok := strings.Cut(repository, "://"); ok {
+		return rest
+	}
+	return repository
+}
+
+// Matches reports whether the source belongs to a repository given by name,
+// base URL or index location
+func (s Source) Matches(repository string) bool {
+	repository = strings.TrimSuffix(repository, "/")
+	return s.Name() == repository || s.Repository() == repository || s.Location == repository
+}
+
+// ForRepository returns the sources that belong to the given repository
+// base URL, optionally restricted to one architecture
+func ForRepository(srcs []Source, repository, arch string) []Source {
+	repository = strings.TrimSuffix(repository, "/")
+
+	var result []Source
+	for _, src := range srcs {
+		if src.Repository() != repository {
+			continue
+		}
+		if arch != "" && src.Arch() != "" && src.Arch() != arch {
+			continue
+		}
+		result = append(result, src)
+	}
+	return result
+}
+
+// AllPackages returns the packages of every source, in source order
+func AllPackages(srcs []Source) []*apk.Package {
+	var result []*apk.Package
+	for _, src := range srcs {
+		result = append(result, src.Packages...)
+	}
+	return result
+}
+
+// MergePackages combines packages from multiple APKINDEX files following Alpine merging semantics:
+// 1. When a package name exists in multiple indexes, the index with the highest version wins
+// 2. If versions are equal, the most recently indexed one wins
+// The winning index keeps all of its versions of the name, so merging a
+// single index leaves it untouched. Packages keep their index order.
+func MergePackages(existing []*apk.Package, new []*apk.Package) []*apk.Package {
+	// Find the highest version of each name on both sides
+	highest := func(packages []*apk.Package) map[string]string {
+		versions := make(map[string]string, len(packages))
+		for _, pkg := range packages {
+			if version, ok := versions[pkg.Name]; !ok || resolve.CompareVersions(pkg.Version, version) > 0 {
+				versions[pkg.Name] = pkg.Version
+			}
+		}
+		return versions
+	}
+	old, incoming := highest(existing), highest(new)
+
+	// The new index shadows the names it has a higher or the same version of
+	shadows := func(name string) bool {
+		version, ok := old[name]
+		return !ok || resolve.CompareVersions(incoming[name], version) >= 0
+	}
+
+	result := make([]*apk.Package, 0, len(existing)+len(new))
+	for _, pkg := range existing {
+		if _, ok := incoming[pkg.Name]; !ok || !shadows(pkg.Name) {
+			result = append(result, pkg)
+		}
+	}
+	for _, pkg := range new {
+		if shadows(pkg.Name) {
+			result = append(result, pkg)
+		}
+	}
+	return result
+}
@@ -0,0 +1,224 @@
+package sources
+
+import (
+	"testing"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+)
+
+func TestSourceLayout(t *testing.T) {
+	testCases := []struct {
+		location   string
+		arch       string
+		repository string
+	}{
+		{"https://packages.wolfi.dev/os/x86_64/APKINDEX.tar.gz", "x86_64", "https://packages.wolfi.dev/os"},
+		{"/tmp/packages/aarch64/APKINDEX.tar.gz", "aarch64", "/tmp/packages"},
+		{"APKINDEX.tar.gz", "", "APKINDEX.tar.gz"},
+		{"https://example.com/index", "", "https://example.com/index"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.location, func(t *testing.T) {
+			src := Source{Location: tc.location}
+			if got := src.Arch(); got != tc.arch {
+				t.Errorf("Arch() = %q, want %q", got, tc.arch)
+			}
+			if got := src.Repository(); got != tc.repository {
+				t.Errorf("Repository() = %q, want %q", got, tc.repository)
+			}
+		})
+	}
+}
+
+func TestForRepository(t *testing.T) {
+	srcs := []Source{
+		{Location: "https://packages.wolfi.dev/os/x86_64/APKINDEX.tar.gz", Packages: []*apk.Package{{Name: "a"}}},
+		{Location: "https://packages.wolfi.dev/os/aarch64/APKINDEX.tar.gz", Packages: []*apk.Package{{Name: "b"}}},
+		{Location: "https://example.com/overlay/x86_64/APKINDEX.tar.gz", Packages: []*apk.Package{{Name: "c"}}},
+	}
+
+	if got := ForRepository(srcs, "https://packages.wolfi.dev/os/", ""); len(got) != 2 {
+		t.Errorf("Expected 2 sources for all archs, got %d", len(got))
+	}
+	if got := ForRepository(srcs, "https://packages.wolfi.dev/os", "aarch64"); len(got) != 1 || got[0].Packages[0].Name != "b" {
+		t.Errorf("Expected the aarch64 source, got %v", got)
+	}
+	if got := ForRepository(srcs, "https://example.com/other", ""); len(got) != 0 {
+		t.Errorf("Expected no sources, got %v", got)
+	}
+
+	if got := AllPackages(srcs); len(got) != 3 || got[2].Name != "c" {
+		t.Errorf("Expected all packages in source order, got %v", got)
+	}
+}
+
+func TestMergePackages(t *testing.T) {
+	testCases := []struct {
+		name     string
+		existing []*apk.Package
+		new      []*apk.Package
+		expected []*apk.Package
+	}{
+		{
+			name:     "Empty existing packages",
+			existing: []*apk.Package{},
+			new: []*apk.Package{
+				{Name: "pkg1", Version: "1.0.0"},
+				{Name: "pkg2", Version: "2.0.0"},
+			},
+			expected: []*apk.Package{
+				{Name: "pkg1", Version: "1.0.0"},
+				{Name: "pkg2", Version: "2.0.0"},
+			},
+		},
+		{
+			name: "Empty new packages",
+			existing: []*apk.Package{
+				{Name: "pkg1", Version: "1.0.0"},
+				{Name: "pkg2", Version: "2.0.0"},
+			},
+			new: []*apk.Package{},
+			expected: []*apk.Package{
+				{Name: "pkg1", Version: "1.0.0"},
+				{Name: "pkg2", Version: "2.0.0"},
+			},
+		},
+		{
+			name: "New package not in existing",
+			existing: []*apk.Package{
+				{Name: "pkg1", Version: "1.0.0"},
+			},
+			new: []*apk.Package{
+				{Name: "pkg2", Version: "2.0.0"},
+			},
+			expected: []*apk.Package{
+				{Name: "pkg1", Version: "1.0.0"},
+				{Name: "pkg2", Version: "2.0.0"},
+			},
+		},
+		{
+			name: "Higher version in new",
+			existing: []*apk.Package{
+				{Name: "pkg1", Version: "1.0.0"},
+			},
+			new: []*apk.Package{
+				{Name: "pkg1", Version: "1.1.0"},
+			},
+			expected: []*apk.Package{
+				{Name: "pkg1", Version: "1.1.0"},
+			},
+		},
+		{
+			name: "Lower version in new",
+			existing: []*apk.Package{
+				{Name: "pkg1", Version: "1.1.0"},
+			},
+			new: []*apk.Package{
+				{Name: "pkg1", Version: "1.0.0"},
+			},
+			expected: []*apk.Package{
+				{Name: "pkg1", Version: "1.1.0"},
+			},
+		},
+		{
+			name: "Numeric version comparison",
+			existing: []*apk.Package{
+				{Name: "curl", Version: "8.10.0-r0"},
+			},
+			new: []*apk.Package{
+				{Name: "curl", Version: "8.9.1-r0"},
+			},
+			expected: []*apk.Package{
+				{Name: "curl", Version: "8.10.0-r0"},
+			},
+		},
+		{
+			name: "Every version of the winning index is kept",
+			existing: []*apk.Package{
+				{Name: "pkg1", Version: "1.0.0"},
+				{Name: "pkg2", Version: "2.0.0"},
+			},
+			new: []*apk.Package{
+				{Name: "pkg1", Version: "1.1.0"},
+				{Name: "pkg1", Version: "1.2.0"},
+			},
+			expected: []*apk.Package{
+				{Name: "pkg1", Version: "1.1.0"},
+				{Name: "pkg1", Version: "1.2.0"},
+				{Name: "pkg2", Version: "2.0.0"},
+			},
+		},
+		{
+			name: "Same version, take new",
+			existing: []*apk.Package{
+				{Name: "pkg1", Version: "1.0.0", Description: "Old description"},
+			},
+			new: []*apk.Package{
+				{Name: "pkg1", Version: "1.0.0", Description: "New description"},
+			},
+			expected: []*apk.Package{
+				{Name: "pkg1", Version: "1.0.0", Description: "New description"},
+			},
+		},
+		{
+			name: "Complex mix of scenarios",
+			existing: []*apk.Package{
+				{Name: "pkg1", Version: "1.0.0"},
+				{Name: "pkg2", Version: "2.0.0"},
+				{Name: "pkg3", Version: "3.1.0"},
+				{Name: "pkg4", Version: "4.0.0", Description: "Old pkg4"},
+			},
+			new: []*apk.Package{
+				{Name: "pkg1", Version: "1.1.0"},
+				{Name: "pkg2", Version: "1.9.0"},
+				{Name: "pkg4", Version: "4.0.0", Description: "New pkg4"},
+				{Name: "pkg5", Version: "5.0.0"},
+			},
+			expected: []*apk.Package{
+				{Name: "pkg1", Version: "1.1.0"},
+				{Name: "pkg2", Version: "2.0.0"},
+				{Name: "pkg3", Version: "3.1.0"},
+				{Name: "pkg4", Version: "4.0.0", Description: "New pkg4"},
+				{Name: "pkg5", Version: "5.0.0"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := MergePackages(tc.existing, tc.new)
+
+			// Compare the packages by name and version, whatever their order
+
+			expectedMap := make(map[string]*apk.Package)
+			for _, pkg := range tc.expected {
+				expectedMap[pkg.Name+"-"+pkg.Version] = pkg
+			}
+
+			resultMap := make(map[string]*apk.Package)
+			for _, pkg := range result {
+				resultMap[pkg.Name+"-"+pkg.Version] = pkg
+			}
+
+			// Check number of packages
+			if len(result) != len(tc.expected) {
+				t.Errorf("Expected %d packages, got %d", len(tc.expected), len(result))
+			}
+
+			// Check each package
+			for name, expectedPkg := range expectedMap {
+				resultPkg, exists := resultMap[name]
+				if !exists {
+					t.Errorf("Expected package %s not found in result", name)
+					continue
+				}
+
+				if expectedPkg.Description != "" && resultPkg.Description != expectedPkg.Description {
+					t.Errorf("Package %s description mismatch. Expected %s, got %s",
+						name, expectedPkg.Description, resultPkg.Description)
+				}
+			}
+		})
+	}
+}
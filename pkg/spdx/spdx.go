@@ -0,0 +1,251 @@
+package spdx
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Expression is a node of a parsed SPDX license expression. Leaves hold a
+// license identifier, other nodes combine their children with AND or OR.
+type Expression struct {
+	// Operator is "AND" or "OR", empty for a license leaf
+	Operator string
+
+	// Children holds the operands of AND and OR nodes
+	Children []*Expression
+
+	// License is the license identifier of a leaf, e.g. "Apache-2.0"
+	License string
+
+	// Exception is the exception of a "<license> WITH <exception>" leaf
+	Exception string
+}
+
+// Parse parses an SPDX license expression such as "MIT OR (Apache-2.0 AND BSD-3-Clause)"
+func Parse(expression string) (*Expression, error) {
+	p := &parser{tokens: tokenize(expression)}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("empty license expression")
+	}
+
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in license expression", p.tokens[p.pos])
+	}
+	return e, nil
+}
+
+// Licenses returns the distinct license identifiers used in the expression, sorted
+func (e *Expression) Licenses() []string {
+	seen := make(map[string]bool)
+	var result []string
+	var walk func(*Expression)
+	walk = func(e *Expression) {
+		if e.Operator == "" {
+			if !seen[e.License] {
+				seen[e.License] = true
+				result = append(result, e.License)
+			}
+			return
+		}
+		for _, child := range e.Children {
+			walk(child)
+		}
+	}
+	walk(e)
+	sort.Strings(result)
+	return result
+}
+
+// Satisfiable reports whether licenses can be chosen from the expression so
+// that every chosen license is accepted: one branch of each OR, and all
+// operands of each AND
+func (e *Expression) Satisfiable(accept func(license string) bool) bool {
+	switch e.Operator {
+	case "AND":
+		for _, child := range e.Children {
+			if !child.Satisfiable(accept) {
+				return false
+			}
+		}
+		return true
+	case "OR":
+		for _, child := range e.Children {
+			if child.Satisfiable(accept) {
+				return true
+			}
+		}
+		return false
+	default:
+		return accept(e.License)
+	}
+}
+
+// String formats the expression back into SPDX syntax
+func (e *Expression) String() string {
+	if e.Operator == "" {
+		if e.Exception != "" {
+			return e.License + " WITH " + e.Exception
+		}
+		return e.License
+	}
+
+	parts := make([]string, 0, len(e.Children))
+	for _, child := range e.Children {
+		if child.Operator != "" && child.Operator != e.Operator {
+			parts = append(parts, "("+child.String()+")")
+		} else {
+			parts = append(parts, child.String())
+		}
+	}
+	return strings.Join(parts, " "+e.Operator+" ")
+}
+
+// Policy decides which licenses are acceptable
+type Policy struct {
+	// Allow lists the acceptable licenses, any license is acceptable when empty
+	Allow []string
+
+	// Deny lists licenses that are never acceptable
+	Deny []string
+}
+
+// Empty reports whether the policy has no rules
+func (p Policy) Empty() bool {
+	return len(p.Allow) == 0 && len(p.Deny) == 0
+}
+
+// Accepts reports whether a single license identifier is acceptable.
+// Patterns may use shell wildcards, e.g. "AGPL-*", and like license
+// identifiers they are matched case-insensitively.
+func (p Policy) Accepts(license string) bool {
+	if matchAny(p.Deny, license) {
+		return false
+	}
+	return len(p.Allow) == 0 || matchAny(p.Allow, license)
+}
+
+func matchAny(patterns []string, license string) bool {
+	license = strings.ToLower(license)
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		if pattern == license {
+			return true
+		}
+		if ok, _ := path.Match(pattern, license); ok {
+			return true
+		}
+	}
+	return false
+}
+
+type parser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *parser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *parser) next() string {
+	token := p.peek()
+	p.pos++
+	return token
+}
+
+func (p *parser) parseOr() (*Expression, error) {
+	return p.parseBinary("OR", p.parseAnd)
+}
+
+func (p *parser) parseAnd() (*Expression, error) {
+	return p.parseBinary("AND", p.parseWith)
+}
+
+// parseBinary parses operands separated by the operator, flattening them into one node
+func (p *parser) parseBinary(operator string, operand func() (*Expression, error)) (*Expression, error) {
+	first, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(p.peek(), operator) {
+		return first, nil
+	}
+
+	e := &Expression{Operator: operator, Children: []*Expression{first}}
+	for strings.EqualFold(p.peek(), operator) {
+		p.next()
+		child, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		e.Children = append(e.Children, child)
+	}
+	return e, nil
+}
+
+func (p *parser) parseWith() (*Expression, error) {
+	e, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(p.peek(), "WITH") {
+		if e.Operator != "" {
+			return nil, fmt.Errorf("WITH must follow a license identifier")
+		}
+		p.next()
+		exception := p.next()
+		if !isIdentifier(exception) {
+			return nil, fmt.Errorf("expected an exception after WITH")
+		}
+		e.Exception = exception
+	}
+	return e, nil
+}
+
+func (p *parser) parsePrimary() (*Expression, error) {
+	token := p.next()
+	switch {
+	case token == "(":
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("missing closing parenthesis in license expression")
+		}
+		return e, nil
+	case isIdentifier(token):
+		return &Expression{License: token}, nil
+	case token == "":
+		return nil, fmt.Errorf("unexpected end of license expression")
+	default:
+		return nil, fmt.Errorf("unexpected %q in license expression", token)
+	}
+}
+
+// isIdentifier reports whether a token is a license or exception identifier
+func isIdentifier(token string) bool {
+	if token == "" || token == "(" || token == ")" {
+		return false
+	}
+	switch strings.ToUpper(token) {
+	case "AND", "OR", "WITH":
+		return false
+	}
+	return true
+}
+
+// tokenize splits an expression into parentheses and words
+func tokenize(expression string) []string {
+	expression = strings.NewReplacer("(", " ( ", ")", " ) ").Replace(expression)
+	return strings.Fields(expression)
+}
@@ -0,0 +1,94 @@
+package spdx
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		expression string
+		formatted  string
+		licenses   []string
+		wantErr    bool
+	}{
+		{expression: "MIT", formatted: "MIT", licenses: []string{"MIT"}},
+		{expression: "Apache-2.0 AND MIT", formatted: "Apache-2.0 AND MIT", licenses: []string{"Apache-2.0", "MIT"}},
+		{expression: "MIT or GPL-2.0-only", formatted: "MIT OR GPL-2.0-only", licenses: []string{"GPL-2.0-only", "MIT"}},
+		{
+			expression: "(MIT OR Apache-2.0) AND BSD-3-Clause",
+			formatted:  "(MIT OR Apache-2.0) AND BSD-3-Clause",
+			licenses:   []string{"Apache-2.0", "BSD-3-Clause", "MIT"},
+		},
+		{
+			expression: "GPL-2.0-or-later WITH Classpath-exception-2.0 OR MIT AND MIT",
+			formatted:  "GPL-2.0-or-later WITH Classpath-exception-2.0 OR (MIT AND MIT)",
+			licenses:   []string{"GPL-2.0-or-later", "MIT"},
+		},
+		{expression: "", wantErr: true},
+		{expression: "MIT AND", wantErr: true},
+		{expression: "(MIT", wantErr: true},
+		{expression: "MIT Apache-2.0", wantErr: true},
+		{expression: "(MIT OR BSD) WITH foo", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.expression, func(t *testing.T) {
+			e, err := Parse(tc.expression)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("Expected error, got %v", e)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if got := e.String(); got != tc.formatted {
+				t.Errorf("String() = %q, want %q", got, tc.formatted)
+			}
+			if got := e.Licenses(); !reflect.DeepEqual(got, tc.licenses) {
+				t.Errorf("Licenses() = %v, want %v", got, tc.licenses)
+			}
+		})
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	policy := Policy{Deny: []string{"AGPL-*", "SSPL-1.0"}}
+
+	testCases := []struct {
+		expression string
+		accepted   bool
+	}{
+		{"MIT", true},
+		{"AGPL-3.0-only", false},
+		{"AGPL-3.0-only OR MIT", true},
+		{"AGPL-3.0-only AND MIT", false},
+		{"(SSPL-1.0 OR AGPL-3.0-or-later) AND MIT", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.expression, func(t *testing.T) {
+			e, err := Parse(tc.expression)
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if got := e.Satisfiable(policy.Accepts); got != tc.accepted {
+				t.Errorf("Satisfiable() = %v, want %v", got, tc.accepted)
+			}
+		})
+	}
+
+	allow := Policy{Allow: []string{"MIT", "apache-2.0"}}
+	if !allow.Accepts("Apache-2.0") || allow.Accepts("GPL-2.0-only") {
+		t.Error("Unexpected result for the allow list")
+	}
+	wildcard := Policy{Deny: []string{"gpl-*"}}
+	if wildcard.Accepts("GPL-2.0-only") || !wildcard.Accepts("LGPL-2.1-only") {
+		t.Errorf("Expected wildcard patterns to match regardless of case")
+	}
+	if !(Policy{}).Empty() || allow.Empty() {
+		t.Error("Unexpected result for Empty()")
+	}
+}
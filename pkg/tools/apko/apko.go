@@ -0,0 +1,167 @@
+package apko
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/dlorenc/wolfi-mcp/pkg/apkoconfig"
+	"github.com/dlorenc/wolfi-mcp/pkg/resolve"
+	"github.com/dlorenc/wolfi-mcp/pkg/sources"
+	"github.com/dlorenc/wolfi-mcp/pkg/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Tool implements the apko configuration analyzer tool
+type Tool struct {
+	tools.BaseTool
+	sources []sources.Source
+}
+
+// arguments are the arguments of the apko configuration analyzer tool
+type arguments struct {
+	Config string `arg:"config" description:"The apko YAML configuration, inline"`
+	Path   string `arg:"path" description:"Path to a local apko YAML configuration (used when config is not given)"`
+	Arch   string `arg:"arch" description:"Architecture to resolve for, e.g. x86_64 or aarch64 (default: the single arch of the config, if any)"`
+}
+
+// New creates a new apko configuration analyzer tool. The loaded sources are
+// used to pick the indexes matching the repositories of the configuration.
+func New(srcs []sources.Source) *Tool {
+	tool := mcp.NewTool("analyze_apko_config",
+		mcp.WithDescription("Resolve the packages of an apko configuration against the loaded indexes and report problems, the install closure and its size"),
+		tools.WithArguments(arguments{}),
+	)
+
+	return &Tool{
+		BaseTool: tools.BaseTool{Tool: tool},
+		sources:  srcs,
+	}
+}
+
+// GetHandler returns the handler function for the apko configuration analyzer tool
+func (t *Tool) GetHandler(repo *apkindex.Repository) tools.ToolHandler {
+	return tools.Bind(func(ctx context.Context, args arguments) (*mcp.CallToolResult, error) {
+		provenance := sources.FromContext(ctx)
+		inline := args.Config
+		path := args.Path
+		cfg, err := apkoconfig.Read(inline, path)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error reading apko configuration: %v", err)), nil
+		}
+
+		arch := args.Arch
+		if arch == "" {
+			arch = cfg.Arch()
+		}
+
+		var sb strings.Builder
+
+		// Pick the loaded indexes that belong to the configured repositories
+		sb.WriteString("Repositories:\n")
+		var selected []sources.Source
+		repositories := cfg.AllRepositories()
+		for _, repository := range repositories {
+			location := apkoconfig.RepositoryLocation(repository)
+			matched := sources.ForRepository(t.sources, location, arch)
+			if len(matched) == 0 {
+				sb.WriteString(fmt.Sprintf("- %s: not loaded\n", repository))
+				continue
+			}
+			for _, src := range matched {
+				sb.WriteString(fmt.Sprintf("- %s: %d packages from %s\n", repository, len(src.Packages), src.Location))
+			}
+			selected = append(selected, matched...)
+		}
+
+		target := repo
+		if len(selected) == 0 {
+			sb.WriteString("None of the configured repositories are loaded, resolving against all loaded indexes.\n")
+		} else {
+			target = apkindex.NewRepository(sources.AllPackages(selected))
+		}
+
+		if missing := missingKeys(repositories, cfg.Contents.Keyring); len(missing) > 0 {
+			sb.WriteString("\nRepositories without a keyring entry on the same host:\n")
+			for _, repository := range missing {
+				sb.WriteString(fmt.Sprintf("- %s\n", repository))
+			}
+		}
+
+		if len(cfg.Contents.Packages) == 0 {
+			sb.WriteString("\nThe configuration does not list any packages.\n")
+			return mcp.NewToolResultText(sb.String()), nil
+		}
+
+		closure := resolve.New(target).Closure(cfg.Contents.Packages)
+
+		var unknown, unsatisfiable []resolve.Problem
+		for _, problem := range closure.Problems {
+			if problem.RequiredBy == "" && errors.Is(problem.Err, resolve.ErrNotFound) {
+				unknown = append(unknown, problem)
+			} else {
+				unsatisfiable = append(unsatisfiable, problem)
+			}
+		}
+
+		sb.WriteString(fmt.Sprintf("\nRequested packages: %d\n", len(cfg.Contents.Packages)))
+
+		if len(unknown) > 0 {
+			sb.WriteString("\nUnknown packages:\n")
+			for _, problem := range unknown {
+				sb.WriteString(fmt.Sprintf("- %s\n", problem.Dependency))
+			}
+		}
+
+		if len(unsatisfiable) > 0 {
+			sb.WriteString("\nUnsatisfiable constraints:\n")
+			for _, problem := range unsatisfiable {
+				sb.WriteString(fmt.Sprintf("- %s\n", problem))
+			}
+		}
+
+		if len(closure.Ambiguities) > 0 {
+			sb.WriteString("\nDuplicate providers:\n")
+			for _, ambiguity := range closure.Ambiguities {
+				sb.WriteString(fmt.Sprintf("- %s: chose %s from %s\n",
+					ambiguity.Dependency, ambiguity.Chosen, strings.Join(ambiguity.Candidates, ", ")))
+			}
+		}
+
+		sb.WriteString(fmt.Sprintf("\nInstall closure (%d packages):\n", len(closure.Packages)))
+		for i, pkg := range closure.Packages {
+			sb.WriteString(fmt.Sprintf("%d. %s (%s)%s - %d bytes installed\n", i+1, pkg.Name, pkg.Version, provenance.Suffix(pkg), pkg.InstalledSize))
+		}
+		sb.WriteString(fmt.Sprintf("\nTotal installed size: %d bytes\n", closure.InstalledSize()))
+		sb.WriteString(fmt.Sprintf("Total download size: %d bytes\n", closure.Size()))
+
+		return mcp.NewToolResultText(sb.String()), nil
+	})
+}
+
+// missingKeys returns the remote repositories for which no keyring entry is
+// served from the same host
+func missingKeys(repositories, keyring []string) []string {
+	hosts := make(map[string]bool)
+	for _, key := range keyring {
+		if u, err := url.Parse(key); err == nil && u.Host != "" {
+			hosts[u.Host] = true
+		}
+	}
+
+	var missing []string
+	for _, repository := range repositories {
+		u, err := url.Parse(apkoconfig.RepositoryLocation(repository))
+		if err != nil || u.Host == "" {
+			// Local repositories are not signed
+			continue
+		}
+		if !hosts[u.Host] {
+			missing = append(missing, repository)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,129 @@
+package apko
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/dlorenc/wolfi-mcp/pkg/sources"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const testConfig = `
+contents:
+  keyring:
+    - https://packages.wolfi.dev/os/wolfi-signing.rsa.pub
+  repositories:
+    - https://packages.wolfi.dev/os
+    - https://example.com/overlay
+  packages:
+    - app
+    - lib>3
+    - unknown-package
+    - cmd:sh
+archs:
+  - amd64
+`
+
+func TestApkoTool(t *testing.T) {
+	wolfi := sources.Source{
+		Location: "https://packages.wolfi.dev/os/x86_64/APKINDEX.tar.gz",
+		Packages: []*apk.Package{
+			{Name: "app", Version: "1.0-r0", Dependencies: []string{"lib"}, InstalledSize: 100},
+			{Name: "lib", Version: "2.0-r0", InstalledSize: 50},
+			{Name: "bash", Version: "5.2-r0", Provides: []string{"cmd:sh=5.2-r0"}},
+			{Name: "busybox", Version: "1.36-r0", Provides: []string{"cmd:sh=1.36-r0"}},
+		},
+	}
+	other := sources.Source{
+		Location: "https://example.com/unrelated/x86_64/APKINDEX.tar.gz",
+		Packages: []*apk.Package{{Name: "unknown-package", Version: "1.0-r0"}},
+	}
+
+	// Create tool
+	tool := New([]sources.Source{wolfi, other})
+
+	// Check tool name
+	if tool.GetTool().Name != "analyze_apko_config" {
+		t.Errorf("Expected tool name to be 'analyze_apko_config', got '%s'", tool.GetTool().Name)
+	}
+
+	repo := apkindex.NewRepository(append(wolfi.Packages, other.Packages...))
+	handler := tool.GetHandler(repo)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "apko.yaml")
+	if err := os.WriteFile(path, []byte(testConfig), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	testCases := []struct {
+		name              string
+		args              map[string]interface{}
+		checkText         []string
+		expectedErrorFlag bool
+	}{
+		{
+			name: "inline config",
+			args: map[string]interface{}{"config": testConfig},
+			checkText: []string{
+				"https://example.com/overlay: not loaded",
+				"Repositories without a keyring entry",
+				"Unknown packages:\\n- unknown-package",
+				"lib\\u003e3: no available version satisfies the constraint",
+				"cmd:sh: chose bash from bash, busybox",
+				"Install closure (3 packages)",
+				"Total installed size: 150 bytes",
+			},
+		},
+		{
+			name:      "config path",
+			args:      map[string]interface{}{"path": path},
+			checkText: []string{"Install closure (3 packages)"},
+		},
+		{
+			name:              "missing config",
+			args:              map[string]interface{}{},
+			expectedErrorFlag: true,
+		},
+		{
+			name:              "invalid yaml",
+			args:              map[string]interface{}{"config": "contents: ["},
+			expectedErrorFlag: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := mcp.CallToolRequest{}
+			req.Params.Name = "analyze_apko_config"
+			req.Params.Arguments = tc.args
+
+			result, err := handler(context.Background(), req)
+			if err != nil {
+				t.Fatalf("Handler returned error: %v", err)
+			}
+
+			if result.IsError != tc.expectedErrorFlag {
+				t.Fatalf("Expected IsError=%v, got %v", tc.expectedErrorFlag, result.IsError)
+			}
+
+			jsonData, err := json.Marshal(result)
+			if err != nil {
+				t.Fatalf("Failed to marshal result: %v", err)
+			}
+
+			jsonStr := string(jsonData)
+			for _, text := range tc.checkText {
+				if !strings.Contains(jsonStr, text) {
+					t.Errorf("Expected result to contain '%s', got: %s", text, jsonStr)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,347 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Tool arguments are declared as struct fields tagged with:
+//
+//	arg:"name"          the argument name, fields without it are ignored
+//	required:"true"     the argument must be given
+//	description:"..."   the description shown to clients
+//	enum:"a,b,c"        the only accepted values of a string argument
+//	default:"..."       the value used when the argument is not given
+//	minimum:"1"         the lowest accepted value of a numeric argument
+//	maximum:"5"         the highest accepted value of a numeric argument
+//
+// Supported field types are string, bool, integers, floats and []string.
+
+// WithArguments adds the arguments declared by the fields of args, a struct
+// value, to the input schema of a tool
+func WithArguments(args interface{}) mcp.ToolOption {
+	fields, err := argumentFields(reflect.TypeOf(args))
+	if err != nil {
+		// Argument structs are fixed at compile time, so this is a programming error
+		panic(err)
+	}
+
+	return func(t *mcp.Tool) {
+		for _, f := range fields {
+			t.InputSchema.Properties[f.name] = f.schema()
+			if f.required {
+				t.InputSchema.Required = append(t.InputSchema.Required, f.name)
+			}
+		}
+	}
+}
+
+// Bind adapts a handler taking typed arguments to a ToolHandler. The request
+// arguments are decoded and validated first, and invalid arguments are
+// reported to the client as a tool error without calling fn.
+func Bind[T any](fn func(ctx context.Context, args T) (*mcp.CallToolResult, error)) ToolHandler {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var args T
+		if err := Decode(request.Params.Arguments, &args); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return fn(ctx, args)
+	}
+}
+
+// ArgumentError is returned when an argument is missing or invalid
+type ArgumentError struct {
+	Name   string
+	Reason string
+}
+
+func (e *ArgumentError) Error() string {
+	return fmt.Sprintf("Invalid argument %q: %s", e.Name, e.Reason)
+}
+
+// Decode validates the arguments of a request and stores them in the struct
+// pointed to by dst
+func Decode(arguments map[string]interface{}, dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("decode target must be a pointer to a struct, got %T", dst)
+	}
+	fields, err := argumentFields(v.Elem().Type())
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		value, ok := arguments[f.name]
+		if !ok || value == nil {
+			if f.required {
+				return &ArgumentError{Name: f.name, Reason: "the argument is required"}
+			}
+			if f.defaultValue == "" {
+				continue
+			}
+			value = f.defaultValue
+		}
+
+		if err := f.set(v.Elem().Field(f.index), value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// field describes a tagged argument field
+type field struct {
+	index        int
+	kind         reflect.Kind
+	name         string
+	required     bool
+	description  string
+	enum         []string
+	defaultValue string
+	minimum      *float64
+	maximum      *float64
+}
+
+// argumentFields returns the tagged argument fields of a struct type
+func argumentFields(t reflect.Type) ([]field, error) {
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("arguments must be declared in a struct, got %v", t)
+	}
+
+	var fields []field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name := sf.Tag.Get("arg")
+		if name == "" {
+			continue
+		}
+
+		f := field{
+			index:        i,
+			kind:         sf.Type.Kind(),
+			name:         name,
+			required:     sf.Tag.Get("required") == "true",
+			description:  sf.Tag.Get("description"),
+			defaultValue: sf.Tag.Get("default"),
+		}
+		if enum := sf.Tag.Get("enum"); enum != "" {
+			f.enum = strings.Split(enum, ",")
+		}
+		for tag, bound := range map[string]**float64{"minimum": &f.minimum, "maximum": &f.maximum} {
+			if value := sf.Tag.Get(tag); value != "" {
+				n, err := strconv.ParseFloat(value, 64)
+				if err != nil {
+					return nil, fmt.Errorf("invalid %s tag on %s.%s: %w", tag, t.Name(), sf.Name, err)
+				}
+				*bound = &n
+			}
+		}
+
+		if f.kind == reflect.Slice && sf.Type.Elem().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported argument type %v for %s.%s", sf.Type, t.Name(), sf.Name)
+		}
+		if f.jsonType() == "" {
+			return nil, fmt.Errorf("unsupported argument type %v for %s.%s", sf.Type, t.Name(), sf.Name)
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// jsonType returns the JSON schema type of the field
+func (f field) jsonType() string {
+	switch f.kind {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice:
+		return "array"
+	default:
+		return ""
+	}
+}
+
+// schema returns the JSON schema of the field
+func (f field) schema() map[string]interface{} {
+	schema := map[string]interface{}{"type": f.jsonType()}
+	if f.description != "" {
+		schema["description"] = f.description
+	}
+	if len(f.enum) > 0 {
+		schema["enum"] = f.enum
+	}
+	if f.kind == reflect.Slice {
+		schema["items"] = map[string]interface{}{"type": "string"}
+	}
+	if f.minimum != nil {
+		schema["minimum"] = *f.minimum
+	}
+	if f.maximum != nil {
+		schema["maximum"] = *f.maximum
+	}
+	if f.defaultValue != "" {
+		if value, err := f.convert(f.defaultValue); err == nil {
+			schema["default"] = value
+		}
+	}
+	return schema
+}
+
+// set validates a raw argument value and stores it in the field
+func (f field) set(target reflect.Value, value interface{}) error {
+	converted, err := f.convert(value)
+	if err != nil {
+		return &ArgumentError{Name: f.name, Reason: err.Error()}
+	}
+
+	switch v := converted.(type) {
+	case string:
+		if len(f.enum) > 0 {
+			canonical, ok := "", false
+			for _, allowed := range f.enum {
+				if strings.EqualFold(v, allowed) {
+					canonical, ok = allowed, true
+					break
+				}
+			}
+			if !ok {
+				return &ArgumentError{Name: f.name, Reason: fmt.Sprintf("must be one of %s, got %q", strings.Join(f.enum, ", "), v)}
+			}
+			v = canonical
+		}
+		target.SetString(v)
+	case bool:
+		target.SetBool(v)
+	case float64:
+		if f.minimum != nil && v < *f.minimum {
+			return &ArgumentError{Name: f.name, Reason: fmt.Sprintf("must be at least %v, got %v", *f.minimum, v)}
+		}
+		if f.maximum != nil && v > *f.maximum {
+			return &ArgumentError{Name: f.name, Reason: fmt.Sprintf("must be at most %v, got %v", *f.maximum, v)}
+		}
+		switch f.jsonType() {
+		case "integer":
+			if v != math.Trunc(v) {
+				return &ArgumentError{Name: f.name, Reason: fmt.Sprintf("must be an integer, got %v", v)}
+			}
+			if target.CanInt() {
+				if target.OverflowInt(int64(v)) {
+					return &ArgumentError{Name: f.name, Reason: fmt.Sprintf("%v is out of range", v)}
+				}
+				target.SetInt(int64(v))
+			} else {
+				if v < 0 || target.OverflowUint(uint64(v)) {
+					return &ArgumentError{Name: f.name, Reason: fmt.Sprintf("%v is out of range", v)}
+				}
+				target.SetUint(uint64(v))
+			}
+		default:
+			target.SetFloat(v)
+		}
+	case []string:
+		target.Set(reflect.ValueOf(v))
+	}
+	return nil
+}
+
+// convert turns a raw JSON value into the Go type of the field. Numbers and
+// booleans may also be given as strings, and lists as comma or whitespace
+// separated strings as read by SplitList, for clients that send every
+// argument as a string.
+func (f field) convert(value interface{}) (interface{}, error) {
+	switch f.jsonType() {
+	case "string":
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+		return nil, fmt.Errorf("must be a string, got %s", describe(value))
+
+	case "boolean":
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("must be a boolean, got %q", v)
+			}
+			return b, nil
+		}
+		return nil, fmt.Errorf("must be a boolean, got %s", describe(value))
+
+	case "integer", "number":
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case int:
+			return float64(v), nil
+		case int64:
+			return float64(v), nil
+		case json.Number:
+			n, err := v.Float64()
+			if err != nil {
+				return nil, fmt.Errorf("must be a number, got %q", v)
+			}
+			return n, nil
+		case string:
+			n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return nil, fmt.Errorf("must be a number, got %q", v)
+			}
+			return n, nil
+		}
+		return nil, fmt.Errorf("must be a number, got %s", describe(value))
+
+	case "array":
+		switch v := value.(type) {
+		case string:
+			return SplitList(v), nil
+		case []string:
+			return v, nil
+		case []interface{}:
+			result := make([]string, 0, len(v))
+			for _, item := range v {
+				s, ok := item.(string)
+				if !ok {
+					return nil, fmt.Errorf("must be a list of strings, got an item of type %s", describe(item))
+				}
+				result = append(result, s)
+			}
+			return result, nil
+		}
+		return nil, fmt.Errorf("must be a list of strings, got %s", describe(value))
+	}
+	return nil, fmt.Errorf("unsupported argument type")
+}
+
+// describe names the JSON type of a raw value for error messages
+func describe(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	case float64, int, int64, json.Number:
+		return "a number"
+	case []interface{}:
+		return "an array"
+	case map[string]interface{}:
+		return "an object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
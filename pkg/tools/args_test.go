@@ -0,0 +1,136 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+type testArguments struct {
+	Package  string   `arg:"package" required:"true" description:"The package"`
+	Mode     string   `arg:"mode" enum:"fast,slow" default:"fast" description:"The mode"`
+	Depth    int      `arg:"depth" default:"1" minimum:"1" maximum:"5"`
+	Ratio    float64  `arg:"ratio"`
+	Verbose  bool     `arg:"verbose"`
+	Packages []string `arg:"packages"`
+	internal string
+}
+
+func TestWithArguments(t *testing.T) {
+	tool := mcp.NewTool("test", WithArguments(testArguments{}))
+
+	if !reflect.DeepEqual(tool.InputSchema.Required, []string{"package"}) {
+		t.Errorf("Required = %v, want [package]", tool.InputSchema.Required)
+	}
+	if len(tool.InputSchema.Properties) != 6 {
+		t.Errorf("Expected 6 properties, got %v", tool.InputSchema.Properties)
+	}
+
+	expected := map[string]map[string]interface{}{
+		"package":  {"type": "string", "description": "The package"},
+		"mode":     {"type": "string", "description": "The mode", "enum": []string{"fast", "slow"}, "default": "fast"},
+		"depth":    {"type": "integer", "default": float64(1), "minimum": float64(1), "maximum": float64(5)},
+		"ratio":    {"type": "number"},
+		"verbose":  {"type": "boolean"},
+		"packages": {"type": "array", "items": map[string]interface{}{"type": "string"}},
+	}
+	for name, want := range expected {
+		if got := tool.InputSchema.Properties[name]; !reflect.DeepEqual(got, want) {
+			t.Errorf("Schema of %s = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	testCases := []struct {
+		name      string
+		args      map[string]interface{}
+		expected  testArguments
+		errorText string
+	}{
+		{
+			name:     "defaults",
+			args:     map[string]interface{}{"package": "a"},
+			expected: testArguments{Package: "a", Mode: "fast", Depth: 1},
+		},
+		{
+			name: "JSON values",
+			args: map[string]interface{}{
+				"package": "a", "mode": "slow", "depth": float64(3), "ratio": 0.5,
+				"verbose": true, "packages": []interface{}{"b", "c"},
+			},
+			expected: testArguments{Package: "a", Mode: "slow", Depth: 3, Ratio: 0.5, Verbose: true, Packages: []string{"b", "c"}},
+		},
+		{
+			name: "string values",
+			args: map[string]interface{}{
+				"package": "a", "mode": "SLOW", "depth": "2", "verbose": "true", "packages": "b, c d",
+			},
+			expected: testArguments{Package: "a", Mode: "slow", Depth: 2, Verbose: true, Packages: []string{"b", "c", "d"}},
+		},
+		{
+			name:     "json.Number",
+			args:     map[string]interface{}{"package": "a", "depth": json.Number("4")},
+			expected: testArguments{Package: "a", Mode: "fast", Depth: 4},
+		},
+		{name: "missing required", args: map[string]interface{}{}, errorText: `"package": the argument is required`},
+		{name: "wrong type", args: map[string]interface{}{"package": 1.0}, errorText: `"package": must be a string, got a number`},
+		{name: "not in enum", args: map[string]interface{}{"package": "a", "mode": "medium"}, errorText: "must be one of fast, slow"},
+		{name: "not a number", args: map[string]interface{}{"package": "a", "depth": "deep"}, errorText: `must be a number, got "deep"`},
+		{name: "not an integer", args: map[string]interface{}{"package": "a", "depth": 1.5}, errorText: "must be an integer"},
+		{name: "below minimum", args: map[string]interface{}{"package": "a", "depth": 0.0}, errorText: "must be at least 1"},
+		{name: "above maximum", args: map[string]interface{}{"package": "a", "depth": 9.0}, errorText: "must be at most 5"},
+		{name: "not a boolean", args: map[string]interface{}{"package": "a", "verbose": "maybe"}, errorText: "must be a boolean"},
+		{name: "not a list of strings", args: map[string]interface{}{"package": "a", "packages": []interface{}{1.0}}, errorText: "must be a list of strings"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var args testArguments
+			err := Decode(tc.args, &args)
+			if tc.errorText != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errorText) {
+					t.Fatalf("Expected error containing %q, got %v", tc.errorText, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if !reflect.DeepEqual(args, tc.expected) {
+				t.Errorf("Decode() = %+v, want %+v", args, tc.expected)
+			}
+		})
+	}
+}
+
+func TestBind(t *testing.T) {
+	called := false
+	handler := Bind(func(ctx context.Context, args testArguments) (*mcp.CallToolResult, error) {
+		called = true
+		return mcp.NewToolResultText(args.Package), nil
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"depth": "x"}
+	result, err := handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	if !result.IsError || called {
+		t.Errorf("Expected invalid arguments to be reported without calling the handler")
+	}
+
+	request.Params.Arguments = map[string]interface{}{"package": "a"}
+	result, err = handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	if result.IsError || result.Content[0].(mcp.TextContent).Text != "a" {
+		t.Errorf("Expected the decoded arguments to be passed to the handler, got %+v", result)
+	}
+}
@@ -0,0 +1,47 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/dlorenc/wolfi-mcp/pkg/indexaudit"
+	"github.com/dlorenc/wolfi-mcp/pkg/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Tool implements the index audit tool
+type Tool struct {
+	tools.BaseTool
+}
+
+// arguments are the arguments of the index audit tool
+type arguments struct {
+	AllVersions bool `arg:"all_versions" description:"Audit every version of each package instead of only the latest one"`
+}
+
+// New creates a new index audit tool
+func New() *Tool {
+	tool := mcp.NewTool("audit_index",
+		mcp.WithDescription("Check every dependency of every package in the loaded indexes and report missing providers, unsatisfiable constraints, dangling so: requirements and install closures that fail to resolve"),
+		tools.WithArguments(arguments{}),
+	)
+
+	return &Tool{
+		BaseTool: tools.BaseTool{Tool: tool},
+	}
+}
+
+// GetHandler returns the handler function for the index audit tool
+func (t *Tool) GetHandler(repo *apkindex.Repository) tools.ToolHandler {
+	return tools.Bind(func(ctx context.Context, args arguments) (*mcp.CallToolResult, error) {
+		allVersions := args.AllVersions
+
+		packages := repo.GetAllPackages()
+		if !allVersions {
+			packages = indexaudit.Latest(packages)
+		}
+
+		report := indexaudit.Run(repo, packages)
+		return mcp.NewToolResultText(report.String()), nil
+	})
+}
@@ -0,0 +1,68 @@
+package audit
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestAuditTool(t *testing.T) {
+	// Create tool
+	tool := New()
+
+	// Check tool name
+	if tool.GetTool().Name != "audit_index" {
+		t.Errorf("Expected tool name to be 'audit_index', got '%s'", tool.GetTool().Name)
+	}
+
+	// Create mock repository
+	mockPackages := []*apk.Package{
+		{Name: "app", Version: "2.0-r0", Dependencies: []string{"lib"}},
+		{Name: "app", Version: "1.0-r0", Dependencies: []string{"old-lib"}},
+		{Name: "lib", Version: "1.0-r0"},
+	}
+	repo := apkindex.NewRepository(mockPackages)
+
+	// Get handler
+	handler := tool.GetHandler(repo)
+
+	testCases := []struct {
+		name      string
+		args      map[string]interface{}
+		checkText []string
+	}{
+		{
+			name:      "latest versions",
+			args:      map[string]interface{}{},
+			checkText: []string{"Audited the dependencies of 2 packages.", "No issues found."},
+		},
+		{
+			name:      "all versions",
+			args:      map[string]interface{}{"all_versions": true},
+			checkText: []string{"Audited the dependencies of 3 packages.", "- app (1.0-r0): old-lib"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = tc.args
+
+			result, err := handler(context.Background(), request)
+			if err != nil {
+				t.Fatalf("Handler returned error: %v", err)
+			}
+
+			text := result.Content[0].(mcp.TextContent).Text
+			for _, check := range tc.checkText {
+				if !strings.Contains(text, check) {
+					t.Errorf("Expected result to contain %q, got: %s", check, text)
+				}
+			}
+		})
+	}
+}
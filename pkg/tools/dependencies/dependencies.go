@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/dlorenc/wolfi-mcp/pkg/sources"
 	"github.com/dlorenc/wolfi-mcp/pkg/tools"
 	"github.com/mark3labs/mcp-go/mcp"
 )
@@ -15,14 +16,16 @@ type Tool struct {
 	tools.BaseTool
 }
 
+// arguments are the arguments of the dependencies tool
+type arguments struct {
+	Package string `arg:"package" required:"true" description:"The exact package name"`
+}
+
 // New creates a new dependencies tool
 func New() *Tool {
 	tool := mcp.NewTool("package_dependencies",
 		mcp.WithDescription("List dependencies for a package"),
-		mcp.WithString("package",
-			mcp.Required(),
-			mcp.Description("The exact package name"),
-		),
+		tools.WithArguments(arguments{}),
 	)
 
 	return &Tool{
@@ -32,8 +35,9 @@ func New() *Tool {
 
 // GetHandler returns the handler function for the dependencies tool
 func (t *Tool) GetHandler(repo *apkindex.Repository) tools.ToolHandler {
-	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		packageName := request.Params.Arguments["package"].(string)
+	return tools.Bind(func(ctx context.Context, args arguments) (*mcp.CallToolResult, error) {
+		provenance := sources.FromContext(ctx)
+		packageName := args.Package
 		pkg := repo.GetPackageInfo(packageName)
 
 		if pkg == nil {
@@ -42,7 +46,7 @@ func (t *Tool) GetHandler(repo *apkindex.Repository) tools.ToolHandler {
 
 		// Extract dependencies
 		var sb strings.Builder
-		sb.WriteString(fmt.Sprintf("Dependencies for %s (%s):\n\n", pkg.Name, pkg.Version))
+		sb.WriteString(fmt.Sprintf("Dependencies for %s (%s)%s:\n\n", pkg.Name, pkg.Version, provenance.Suffix(pkg)))
 
 		if len(pkg.Dependencies) == 0 {
 			sb.WriteString("No dependencies found.\n")
@@ -61,7 +65,7 @@ func (t *Tool) GetHandler(repo *apkindex.Repository) tools.ToolHandler {
 
 				depPkg := repo.GetPackageInfo(depName)
 				if depPkg != nil {
-					sb.WriteString(fmt.Sprintf("   Available: %s (%s)\n", depPkg.Name, depPkg.Version))
+					sb.WriteString(fmt.Sprintf("   Available: %s (%s)%s\n", depPkg.Name, depPkg.Version, provenance.Suffix(depPkg)))
 				} else {
 					sb.WriteString("   Not found in index\n")
 				}
@@ -69,5 +73,5 @@ func (t *Tool) GetHandler(repo *apkindex.Repository) tools.ToolHandler {
 		}
 
 		return mcp.NewToolResultText(sb.String()), nil
-	}
+	})
 }
@@ -0,0 +1,66 @@
+package diff
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/dlorenc/wolfi-mcp/pkg/indexdiff"
+	"github.com/dlorenc/wolfi-mcp/pkg/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// LoadFunc loads the packages of an APKINDEX from a local path or URL
+type LoadFunc func(location string) ([]*apk.Package, error)
+
+// Tool implements the index diff tool
+type Tool struct {
+	tools.BaseTool
+	load LoadFunc
+}
+
+// arguments are the arguments of the index diff tool
+type arguments struct {
+	Old string `arg:"old" required:"true" description:"The old snapshot, as a local APKINDEX.tar.gz path or URL, or location@date (a YYYY-MM-DD date or RFC 3339 time) for the recorded snapshot of a loaded index current at that date"`
+	New string `arg:"new" required:"true" description:"The new snapshot, as a local APKINDEX.tar.gz path or URL, or location@date (a YYYY-MM-DD date or RFC 3339 time) for the recorded snapshot of a loaded index current at that date"`
+}
+
+// New creates a new index diff tool that uses load to read the snapshots
+func New(load LoadFunc) *Tool {
+	tool := mcp.NewTool("diff_indexes",
+		mcp.WithDescription("Compare two APKINDEX snapshots and report packages added, removed, upgraded and downgraded, and dependency and provides changes"),
+		tools.WithArguments(arguments{}),
+	)
+
+	return &Tool{
+		BaseTool: tools.BaseTool{Tool: tool},
+		load:     load,
+	}
+}
+
+// GetHandler returns the handler function for the index diff tool
+func (t *Tool) GetHandler(repo *apkindex.Repository) tools.ToolHandler {
+	return tools.Bind(func(ctx context.Context, args arguments) (*mcp.CallToolResult, error) {
+		oldLocation := strings.TrimSpace(args.Old)
+		newLocation := strings.TrimSpace(args.New)
+
+		// An empty location would fall back to downloading the default index
+		if oldLocation == "" || newLocation == "" {
+			return mcp.NewToolResultError("Both the old and the new snapshot must be given"), nil
+		}
+
+		oldPackages, err := t.load(oldLocation)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error loading old snapshot %s: %v", oldLocation, err)), nil
+		}
+		newPackages, err := t.load(newLocation)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error loading new snapshot %s: %v", newLocation, err)), nil
+		}
+
+		report := indexdiff.Compare(oldPackages, newPackages)
+		return mcp.NewToolResultText(report.String()), nil
+	})
+}
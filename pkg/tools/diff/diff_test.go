@@ -0,0 +1,102 @@
+package diff
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestDiffTool(t *testing.T) {
+	snapshots := map[string][]*apk.Package{
+		"yesterday": {
+			{Name: "curl", Version: "8.9.0-r0"},
+			{Name: "gone", Version: "1.0-r0"},
+		},
+		"today": {
+			{Name: "curl", Version: "8.10.0-r0"},
+			{Name: "new-package", Version: "1.0-r0"},
+		},
+	}
+	load := func(location string) ([]*apk.Package, error) {
+		packages, ok := snapshots[location]
+		if !ok {
+			return nil, errors.New("no such snapshot")
+		}
+		return packages, nil
+	}
+
+	// Create tool
+	tool := New(load)
+
+	// Check tool name
+	if tool.GetTool().Name != "diff_indexes" {
+		t.Errorf("Expected tool name to be 'diff_indexes', got '%s'", tool.GetTool().Name)
+	}
+
+	// Both snapshots may refer to the history
+	for _, name := range []string{"old", "new"} {
+		property, _ := tool.GetTool().InputSchema.Properties[name].(map[string]interface{})
+		if description, _ := property["description"].(string); !strings.Contains(description, "location@date") {
+			t.Errorf("Expected the %s argument to document location@date, got %q", name, description)
+		}
+	}
+
+	handler := tool.GetHandler(apkindex.NewRepository(nil))
+
+	req := mcp.CallToolRequest{}
+	req.Params.Name = "diff_indexes"
+	req.Params.Arguments = map[string]interface{}{
+		"old": "yesterday",
+		"new": "today",
+	}
+
+	result, err := handler(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("Expected successful result, got error")
+	}
+
+	text := result.Content[0].(mcp.TextContent).Text
+	for _, expected := range []string{
+		"Added (1):\n- new-package (1.0-r0)",
+		"Removed (1):\n- gone (1.0-r0)",
+		"- curl 8.9.0-r0 -> 8.10.0-r0",
+	} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("Expected result to contain '%s', got: %s", expected, text)
+		}
+	}
+
+	// Test with a snapshot that cannot be loaded
+	req.Params.Arguments = map[string]interface{}{
+		"old": "last-week",
+		"new": "today",
+	}
+	result, err = handler(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	if !result.IsError {
+		t.Errorf("Expected error result for a missing snapshot")
+	}
+
+	// Test with an empty location, which must not load the default index
+	req.Params.Arguments = map[string]interface{}{
+		"old": " ",
+		"new": "today",
+	}
+	result, err = handler(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	if !result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, "must be given") {
+		t.Errorf("Expected error result for an empty location")
+	}
+}
@@ -8,6 +8,8 @@ import (
 
 	"chainguard.dev/apko/pkg/apk/apk"
 	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/dlorenc/wolfi-mcp/pkg/resolve"
+	"github.com/dlorenc/wolfi-mcp/pkg/sources"
 	"github.com/dlorenc/wolfi-mcp/pkg/tools"
 	"github.com/mark3labs/mcp-go/mcp"
 )
@@ -17,21 +19,18 @@ type Tool struct {
 	tools.BaseTool
 }
 
+// arguments are the arguments of the graph tool
+type arguments struct {
+	Package   string `arg:"package" required:"true" description:"The package name to start the graph query from"`
+	QueryType string `arg:"query_type" required:"true" enum:"requires,dependencies,provides,depends_on,required_by,what_provides" description:"The type of query to perform ('dependencies' is an alias of 'requires')"`
+	Depth     int    `arg:"depth" default:"1" minimum:"1" maximum:"5" description:"Maximum depth of the graph traversal"`
+}
+
 // New creates a new graph tool
 func New() *Tool {
 	tool := mcp.NewTool("package_graph",
 		mcp.WithDescription("Query the package dependency graph using provides and requires relationships"),
-		mcp.WithString("package",
-			mcp.Required(),
-			mcp.Description("The package name to start the graph query from"),
-		),
-		mcp.WithString("query_type",
-			mcp.Required(),
-			mcp.Description("The type of query to perform: 'requires', 'provides', 'depends_on', 'required_by', 'what_provides'"),
-		),
-		mcp.WithString("depth",
-			mcp.Description("Maximum depth of the graph traversal (default: 1)"),
-		),
+		tools.WithArguments(arguments{}),
 	)
 
 	return &Tool{
@@ -41,45 +40,20 @@ func New() *Tool {
 
 // GetHandler returns the handler function for the graph tool
 func (t *Tool) GetHandler(repo *apkindex.Repository) tools.ToolHandler {
-	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		packageName := request.Params.Arguments["package"].(string)
-		queryType := request.Params.Arguments["query_type"].(string)
-
-		// Parse depth parameter if provided
-		depth := 1
-		if depthStr, ok := request.Params.Arguments["depth"]; ok {
-			if depthVal, ok := depthStr.(string); ok {
-				if _, err := fmt.Sscanf(depthVal, "%d", &depth); err != nil {
-					return mcp.NewToolResultError(
-						fmt.Sprintf("Invalid depth value '%s': %v", depthVal, err),
-					), nil
-				}
-			}
-		}
+	return tools.Bind(func(ctx context.Context, args arguments) (*mcp.CallToolResult, error) {
+		provenance := sources.FromContext(ctx)
+		packageName := args.Package
+		queryType := args.QueryType
 
-		// Ensure depth is between 1 and 5 (for performance and readability)
-		if depth < 1 {
-			depth = 1
-		} else if depth > 5 {
-			depth = 5
-		}
+		depth := args.Depth
 
 		var sb strings.Builder
 
 		// Special case for what_provides query type
-		if strings.ToLower(queryType) == "what_provides" {
-			// Shows what packages provide a certain capability
+		if queryType == "what_provides" {
+			// Shows what packages provide a certain capability, and which one apk would pick
 			sb.WriteString(fmt.Sprintf("Packages that provide %s:\n\n", packageName))
-			providers := findPackagesProviding(repo, packageName)
-			if len(providers) == 0 {
-				sb.WriteString("No packages found that provide this capability.\n")
-			} else {
-				// Sort for predictable output
-				sort.Strings(providers)
-				for i, provider := range providers {
-					sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, provider))
-				}
-			}
+			writeProviders(&sb, repo, provenance, packageName)
 			return mcp.NewToolResultText(sb.String()), nil
 		}
 
@@ -89,10 +63,10 @@ func (t *Tool) GetHandler(repo *apkindex.Repository) tools.ToolHandler {
 			return mcp.NewToolResultText(fmt.Sprintf("Package '%s' not found.", packageName)), nil
 		}
 
-		switch strings.ToLower(queryType) {
+		switch queryType {
 		case "requires", "dependencies":
 			// Shows what dependencies a package has (direct requirements)
-			sb.WriteString(fmt.Sprintf("Dependencies required by %s (%s):\n\n", pkg.Name, pkg.Version))
+			sb.WriteString(fmt.Sprintf("Dependencies required by %s (%s)%s:\n\n", pkg.Name, pkg.Version, provenance.Suffix(pkg)))
 			if len(pkg.Dependencies) == 0 {
 				sb.WriteString("No dependencies found.\n")
 			} else {
@@ -103,7 +77,7 @@ func (t *Tool) GetHandler(repo *apkindex.Repository) tools.ToolHandler {
 
 		case "provides":
 			// Shows what capabilities a package provides
-			sb.WriteString(fmt.Sprintf("Capabilities provided by %s (%s):\n\n", pkg.Name, pkg.Version))
+			sb.WriteString(fmt.Sprintf("Capabilities provided by %s (%s)%s:\n\n", pkg.Name, pkg.Version, provenance.Suffix(pkg)))
 			if len(pkg.Provides) == 0 {
 				sb.WriteString("No explicit provides found.\n")
 				sb.WriteString(fmt.Sprintf("This package implicitly provides: %s=%s\n", pkg.Name, pkg.Version))
@@ -117,7 +91,7 @@ func (t *Tool) GetHandler(repo *apkindex.Repository) tools.ToolHandler {
 			// Recursive dependency graph
 			sb.WriteString(fmt.Sprintf("Dependency graph for %s (%s) with depth %d:\n\n", pkg.Name, pkg.Version, depth))
 			visited := make(map[string]bool)
-			getDependencyGraph(repo, pkg, &sb, "", 0, depth, visited)
+			getDependencyGraph(repo, provenance, pkg, &sb, "", 0, depth, visited)
 
 		case "required_by":
 			// Shows what packages depend on this package
@@ -133,18 +107,14 @@ func (t *Tool) GetHandler(repo *apkindex.Repository) tools.ToolHandler {
 				}
 			}
 
-		default:
-			return mcp.NewToolResultError(
-				fmt.Sprintf("Unknown query type: %s. Supported types: requires, provides, depends_on, required_by, what_provides", queryType),
-			), nil
 		}
 
 		return mcp.NewToolResultText(sb.String()), nil
-	}
+	})
 }
 
 // getDependencyGraph recursively builds a dependency tree for visualization
-func getDependencyGraph(repo *apkindex.Repository, pkg *apk.Package, sb *strings.Builder, prefix string, currentDepth, maxDepth int, visited map[string]bool) {
+func getDependencyGraph(repo *apkindex.Repository, provenance *sources.Index, pkg *apk.Package, sb *strings.Builder, prefix string, currentDepth, maxDepth int, visited map[string]bool) {
 	if currentDepth > maxDepth {
 		return
 	}
@@ -154,9 +124,9 @@ func getDependencyGraph(repo *apkindex.Repository, pkg *apk.Package, sb *strings
 
 	// Print this package
 	if currentDepth == 0 {
-		sb.WriteString(fmt.Sprintf("%s (%s)\n", pkg.Name, pkg.Version))
+		sb.WriteString(fmt.Sprintf("%s (%s)%s\n", pkg.Name, pkg.Version, provenance.Suffix(pkg)))
 	} else {
-		sb.WriteString(fmt.Sprintf("%s├─ %s (%s)\n", prefix, pkg.Name, pkg.Version))
+		sb.WriteString(fmt.Sprintf("%s├─ %s (%s)%s\n", prefix, pkg.Name, pkg.Version, provenance.Suffix(pkg)))
 	}
 
 	// Don't continue if we've reached max depth
@@ -187,7 +157,7 @@ func getDependencyGraph(repo *apkindex.Repository, pkg *apk.Package, sb *strings
 
 		// If found, recursively process it
 		if depPkg != nil {
-			getDependencyGraph(repo, depPkg, sb, childPrefix, currentDepth+1, maxDepth, visited)
+			getDependencyGraph(repo, provenance, depPkg, sb, childPrefix, currentDepth+1, maxDepth, visited)
 		} else {
 			sb.WriteString(fmt.Sprintf("%s├─ %s [not found in index]\n", childPrefix, depName))
 		}
@@ -230,30 +200,52 @@ func findPackagesRequiring(repo *apkindex.Repository, packageName string) []stri
 	return requiringPackages
 }
 
-// findPackagesProviding finds all packages that provide the given capability
-func findPackagesProviding(repo *apkindex.Repository, capability string) []string {
-	var providingPackages []string
-	allPackages := repo.GetAllPackages()
+// writeProviders lists the providers of a capability with their version and
+// provider_priority, in the order apk would prefer them
+func writeProviders(sb *strings.Builder, repo *apkindex.Repository, provenance *sources.Index, capability string) {
+	choice, err := resolve.New(repo).Resolve(capability)
+	if err != nil {
+		sb.WriteString("No packages found that provide this capability.\n")
+		return
+	}
 
-	for _, pkg := range allPackages {
-		// Check if the package name itself matches the capability
-		if pkg.Name == capability {
-			providingPackages = append(providingPackages, pkg.Name)
-			continue
+	// Only the best version of each package competes with the other packages
+	providers := resolve.Distinct(choice.Candidates)
+	name := apk.ResolvePackageNameVersionPin(capability).Name
+	for i, pkg := range providers {
+		sb.WriteString(fmt.Sprintf("%d. %s (%s)%s", i+1, pkg.Name, pkg.Version, provenance.Suffix(pkg)))
+		if version := providedVersion(pkg, name); version != "" && version != pkg.Version {
+			sb.WriteString(fmt.Sprintf(" provides %s=%s", name, version))
+		}
+		sb.WriteString(fmt.Sprintf(", provider_priority %d", pkg.ProviderPriority))
+		if pkg == choice.Package {
+			sb.WriteString(" [selected]")
 		}
+		sb.WriteString("\n")
+	}
 
-		// Check the provides list
-		for _, provide := range pkg.Provides {
-			// Extract the capability name (handle version constraints)
-			provideParts := strings.Split(provide, "=")
-			provideName := provideParts[0]
+	sb.WriteString(fmt.Sprintf("\napk would pick %s (%s)", choice.Package.Name, choice.Package.Version))
+	switch {
+	case choice.Package.Name == name:
+		sb.WriteString(", the package with the requested name.\n")
+	case len(providers) == 1:
+		sb.WriteString(", the only provider.\n")
+	case choice.Ambiguous:
+		sb.WriteString(".\nWarning: several packages share the highest provider_priority, so there is no clear winner and the choice is arbitrary. Set provider_priority or depend on a package name.\n")
+	default:
+		sb.WriteString(", the provider with the highest provider_priority.\n")
+	}
+}
 
-			if provideName == capability {
-				providingPackages = append(providingPackages, pkg.Name)
-				break
-			}
+// providedVersion returns the version at which a package provides a name
+func providedVersion(pkg *apk.Package, name string) string {
+	if pkg.Name == name {
+		return pkg.Version
+	}
+	for _, provide := range pkg.Provides {
+		if provideName, version, _ := strings.Cut(provide, "="); provideName == name {
+			return version
 		}
 	}
-
-	return providingPackages
+	return ""
 }
@@ -43,24 +43,36 @@ func TestGraphTool(t *testing.T) {
 	// Get handler
 	handler := tool.GetHandler(repo)
 
-	// Test the findPackagesProviding function directly
-	t.Run("findPackagesProviding", func(t *testing.T) {
-		// Find packages providing a capability that exists
-		providers := findPackagesProviding(repo, "lib-capability")
-		if len(providers) != 1 || providers[0] != "lib-package" {
-			t.Errorf("Expected [lib-package], got %v", providers)
+	// Test the writeProviders function directly
+	t.Run("writeProviders", func(t *testing.T) {
+		providersRepo := apkindex.NewRepository([]*apk.Package{
+			{Name: "busybox", Version: "1.36-r0", Provides: []string{"cmd:sh=1.36-r0"}, ProviderPriority: 100},
+			{Name: "bash", Version: "5.2-r0", Provides: []string{"cmd:sh=5.2-r0"}, ProviderPriority: 10},
+			{Name: "bash", Version: "5.1-r0", Provides: []string{"cmd:sh=5.1-r0"}, ProviderPriority: 10},
+			{Name: "libfoo-a", Version: "1.0-r0", Provides: []string{"so:libfoo.so.1=1"}},
+			{Name: "libfoo-b", Version: "1.0-r0", Provides: []string{"so:libfoo.so.1=1"}},
+		})
+
+		var sb strings.Builder
+		writeProviders(&sb, providersRepo, nil, "cmd:sh")
+		expected := "1. busybox (1.36-r0), provider_priority 100 [selected]\n" +
+			"2. bash (5.2-r0), provider_priority 10\n\n" +
+			"apk would pick busybox (1.36-r0), the provider with the highest provider_priority.\n"
+		if sb.String() != expected {
+			t.Errorf("Unexpected providers of cmd:sh:\n%s", sb.String())
 		}
 
-		// Find packages providing a capability that doesn't exist
-		providers = findPackagesProviding(repo, "nonexistent-capability")
-		if len(providers) != 0 {
-			t.Errorf("Expected empty slice, got %v", providers)
+		sb.Reset()
+		writeProviders(&sb, providersRepo, nil, "so:libfoo.so.1")
+		if !strings.Contains(sb.String(), "provides so:libfoo.so.1=1, provider_priority 0") || !strings.Contains(sb.String(), "no clear winner") {
+			t.Errorf("Expected an ambiguity warning, got:\n%s", sb.String())
 		}
 
 		// Find packages by name match (implicit provides)
-		providers = findPackagesProviding(repo, "lib-package")
-		if len(providers) != 1 || providers[0] != "lib-package" {
-			t.Errorf("Expected [lib-package], got %v", providers)
+		sb.Reset()
+		writeProviders(&sb, repo, nil, "lib-package")
+		if !strings.Contains(sb.String(), "the package with the requested name") {
+			t.Errorf("Expected lib-package to be picked by name, got:\n%s", sb.String())
 		}
 	})
 
@@ -79,6 +91,12 @@ func TestGraphTool(t *testing.T) {
 			queryType: "requires",
 			checkText: "lib-package",
 		},
+		{
+			name:      "dependencies alias",
+			pkg:       "base-package",
+			queryType: "dependencies",
+			checkText: "lib-package",
+		},
 		{
 			name:      "provides",
 			pkg:       "lib-package",
@@ -114,7 +132,7 @@ func TestGraphTool(t *testing.T) {
 			name:              "invalid query type",
 			pkg:               "base-package",
 			queryType:         "invalid_type",
-			checkText:         "Unknown query type",
+			checkText:         "must be one of",
 			expectedErrorFlag: true,
 		},
 		{
@@ -125,6 +143,14 @@ func TestGraphTool(t *testing.T) {
 			checkText:         "base-package",
 			expectedErrorFlag: true,
 		},
+		{
+			name:              "depth above the maximum",
+			pkg:               "app-package",
+			queryType:         "depends_on",
+			depth:             "6",
+			checkText:         "must be at most 5",
+			expectedErrorFlag: true,
+		},
 		{
 			name:              "package not found",
 			pkg:               "nonexistent-package",
@@ -0,0 +1,190 @@
+package history
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/dlorenc/wolfi-mcp/pkg/resolve"
+	"github.com/dlorenc/wolfi-mcp/pkg/snapshots"
+	"github.com/dlorenc/wolfi-mcp/pkg/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// LoadFunc loads the packages of a local APKINDEX file
+type LoadFunc func(path string) ([]*apk.Package, error)
+
+// Tool implements the package history tool
+type Tool struct {
+	tools.BaseTool
+	store *snapshots.Store
+	load  LoadFunc
+
+	// parsed holds the versions of each package in a snapshot, by snapshot
+	// digest. Snapshots never change, so each one is parsed only once.
+	mu     sync.Mutex
+	parsed map[string]map[string][]string
+}
+
+// arguments are the arguments of the package history tool
+type arguments struct {
+	Package string `arg:"package" required:"true" description:"The exact package name"`
+}
+
+// New creates a new package history tool over the snapshot store
+func New(store *snapshots.Store, load LoadFunc) *Tool {
+	tool := mcp.NewTool("package_history",
+		mcp.WithDescription("Show when each version of a package first appeared in and disappeared from the indexes, using the snapshot history"),
+		tools.WithArguments(arguments{}),
+	)
+
+	return &Tool{
+		BaseTool: tools.BaseTool{Tool: tool},
+		store:    store,
+		load:     load,
+		parsed:   make(map[string]map[string][]string),
+	}
+}
+
+// versionHistory tracks the lifetime of a single version across snapshots
+type versionHistory struct {
+	version     string
+	firstSeen   time.Time
+	initial     bool
+	disappeared time.Time
+}
+
+// GetHandler returns the handler function for the package history tool
+func (t *Tool) GetHandler(repo *apkindex.Repository) tools.ToolHandler {
+	return tools.Bind(func(ctx context.Context, args arguments) (*mcp.CallToolResult, error) {
+		packageName := args.Package
+
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("Version history of %s:\n", packageName))
+
+		found := false
+		live := make(map[string]bool)
+		for _, location := range t.store.Locations() {
+			history := t.store.History(location)
+			for _, snapshot := range history {
+				live[snapshot.Digest] = true
+			}
+
+			versions, err := t.versions(history, packageName)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Error reading snapshots of %s: %v", location, err)), nil
+			}
+			if len(versions) == 0 {
+				continue
+			}
+			found = true
+
+			sb.WriteString(fmt.Sprintf("\n%s (%d snapshots, %s to %s):\n", location, len(history),
+				formatTime(history[0].FetchedAt), formatTime(history[len(history)-1].FetchedAt)))
+			for _, v := range versions {
+				since := "first seen " + formatTime(v.firstSeen)
+				if v.initial {
+					since = "present since at least " + formatTime(v.firstSeen)
+				}
+				until := "still present"
+				if !v.disappeared.IsZero() {
+					until = "disappeared " + formatTime(v.disappeared)
+				}
+				sb.WriteString(fmt.Sprintf("- %s: %s, %s\n", v.version, since, until))
+			}
+		}
+
+		t.prune(live)
+
+		if !found {
+			return mcp.NewToolResultText(fmt.Sprintf("Package '%s' not found in any snapshot.", packageName)), nil
+		}
+
+		return mcp.NewToolResultText(sb.String()), nil
+	})
+}
+
+// versions walks the snapshots of a location in order and records when each
+// version of the package appeared and disappeared
+func (t *Tool) versions(history []snapshots.Snapshot, packageName string) ([]*versionHistory, error) {
+	byVersion := make(map[string]*versionHistory)
+	var result []*versionHistory
+
+	for i, snapshot := range history {
+		index, err := t.snapshotVersions(snapshot)
+		if err != nil {
+			return nil, err
+		}
+
+		present := make(map[string]bool)
+		for _, version := range index[packageName] {
+			present[version] = true
+		}
+
+		for version := range present {
+			v, ok := byVersion[version]
+			if !ok {
+				v = &versionHistory{version: version, firstSeen: snapshot.FetchedAt, initial: i == 0}
+				byVersion[version] = v
+				result = append(result, v)
+			}
+			// A version may come back after being removed
+			v.disappeared = time.Time{}
+		}
+		for version, v := range byVersion {
+			if !present[version] && v.disappeared.IsZero() {
+				v.disappeared = snapshot.FetchedAt
+			}
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if !result[i].firstSeen.Equal(result[j].firstSeen) {
+			return result[i].firstSeen.Before(result[j].firstSeen)
+		}
+		return resolve.CompareVersions(result[i].version, result[j].version) < 0
+	})
+
+	return result, nil
+}
+
+// snapshotVersions returns the versions of each package in a snapshot,
+// parsing the snapshot the first time it is needed
+func (t *Tool) snapshotVersions(snapshot snapshots.Snapshot) (map[string][]string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if index, ok := t.parsed[snapshot.Digest]; ok {
+		return index, nil
+	}
+
+	packages, err := t.load(t.store.Path(snapshot))
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[string][]string)
+	for _, pkg := range packages {
+		index[pkg.Name] = append(index[pkg.Name], pkg.Version)
+	}
+	t.parsed[snapshot.Digest] = index
+	return index, nil
+}
+
+// prune forgets the parsed snapshots the store no longer keeps
+func (t *Tool) prune(live map[string]bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for digest := range t.parsed {
+		if !live[digest] {
+			delete(t.parsed, digest)
+		}
+	}
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
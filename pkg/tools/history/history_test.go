@@ -0,0 +1,102 @@
+package history
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/dlorenc/wolfi-mcp/pkg/snapshots"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestHistoryTool(t *testing.T) {
+	dir := t.TempDir()
+	store, err := snapshots.Open(filepath.Join(dir, "snapshots"), snapshots.Retention{})
+	if err != nil {
+		t.Fatalf("Failed to open snapshot store: %v", err)
+	}
+
+	// Each snapshot file holds the versions of curl it contains, one per line
+	contents := []string{"8.9.0-r0", "8.9.0-r0\n8.10.0-r0", "8.10.0-r0"}
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	for i, content := range contents {
+		path := filepath.Join(dir, "index")
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write index: %v", err)
+		}
+		if _, err := store.Record("https://example.com/os/x86_64/APKINDEX.tar.gz", path, start.Add(time.Duration(i)*24*time.Hour)); err != nil {
+			t.Fatalf("Failed to record snapshot: %v", err)
+		}
+	}
+
+	loads := 0
+	load := func(path string) ([]*apk.Package, error) {
+		loads++
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var packages []*apk.Package
+		for _, version := range strings.Split(string(data), "\n") {
+			packages = append(packages, &apk.Package{Name: "curl", Version: version})
+		}
+		return packages, nil
+	}
+
+	// Create tool
+	tool := New(store, load)
+
+	// Check tool name
+	if tool.GetTool().Name != "package_history" {
+		t.Errorf("Expected tool name to be 'package_history', got '%s'", tool.GetTool().Name)
+	}
+
+	handler := tool.GetHandler(apkindex.NewRepository(nil))
+
+	req := mcp.CallToolRequest{}
+	req.Params.Name = "package_history"
+	req.Params.Arguments = map[string]interface{}{
+		"package": "curl",
+	}
+
+	result, err := handler(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("Expected successful result, got error")
+	}
+
+	text := result.Content[0].(mcp.TextContent).Text
+	for _, expected := range []string{
+		"https://example.com/os/x86_64/APKINDEX.tar.gz (3 snapshots, 2026-10-01T00:00:00Z to 2026-10-03T00:00:00Z)",
+		"- 8.9.0-r0: present since at least 2026-10-01T00:00:00Z, disappeared 2026-10-03T00:00:00Z",
+		"- 8.10.0-r0: first seen 2026-10-02T00:00:00Z, still present",
+	} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("Expected result to contain '%s', got: %s", expected, text)
+		}
+	}
+
+	// Test with a package that never existed
+	req.Params.Arguments = map[string]interface{}{
+		"package": "nonexistent",
+	}
+	result, err = handler(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	if text := result.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "not found in any snapshot") {
+		t.Errorf("Expected not found message, got: %s", text)
+	}
+
+	// Each snapshot is parsed once, however many calls read it
+	if loads != len(contents) {
+		t.Errorf("Expected %d snapshot loads, got %d", len(contents), loads)
+	}
+}
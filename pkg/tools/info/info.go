@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"chainguard.dev/apko/pkg/apk/apk"
 	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/dlorenc/wolfi-mcp/pkg/sources"
 	"github.com/dlorenc/wolfi-mcp/pkg/tools"
 	"github.com/mark3labs/mcp-go/mcp"
 )
@@ -15,14 +17,16 @@ type Tool struct {
 	tools.BaseTool
 }
 
+// arguments are the arguments of the info tool
+type arguments struct {
+	Package string `arg:"package" required:"true" description:"The exact package name"`
+}
+
 // New creates a new info tool
 func New() *Tool {
 	tool := mcp.NewTool("package_info",
 		mcp.WithDescription("Get detailed information about a specific package"),
-		mcp.WithString("package",
-			mcp.Required(),
-			mcp.Description("The exact package name"),
-		),
+		tools.WithArguments(arguments{}),
 	)
 
 	return &Tool{
@@ -32,8 +36,8 @@ func New() *Tool {
 
 // GetHandler returns the handler function for the info tool
 func (t *Tool) GetHandler(repo *apkindex.Repository) tools.ToolHandler {
-	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		packageName := request.Params.Arguments["package"].(string)
+	return tools.Bind(func(ctx context.Context, args arguments) (*mcp.CallToolResult, error) {
+		packageName := args.Package
 		pkg := repo.GetPackageInfo(packageName)
 
 		if pkg == nil {
@@ -41,11 +45,39 @@ func (t *Tool) GetHandler(repo *apkindex.Repository) tools.ToolHandler {
 		}
 
 		// Format the package details as JSON for a more structured response
-		details, err := json.MarshalIndent(pkg, "", "  ")
+		details, err := json.MarshalIndent(withRepository(pkg, sources.FromContext(ctx)), "", "  ")
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Error formatting package details: %v", err)), nil
 		}
 
 		return mcp.NewToolResultText(string(details)), nil
+	})
+}
+
+// repository describes the repository a package was loaded from
+type repository struct {
+	Name   string
+	URL    string
+	Index  string
+	Digest string `json:",omitempty"`
+}
+
+// details are the package details, with the repository when it is known
+type details struct {
+	*apk.Package
+	Repository *repository `json:",omitempty"`
+}
+
+// withRepository returns the package details to show for a package
+func withRepository(pkg *apk.Package, provenance *sources.Index) details {
+	d := details{Package: pkg}
+	if src, ok := provenance.Of(pkg); ok {
+		d.Repository = &repository{
+			Name:   src.Name(),
+			URL:    src.Repository(),
+			Index:  src.Location,
+			Digest: src.Digest,
+		}
 	}
+	return d
 }
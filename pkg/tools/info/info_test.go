@@ -8,6 +8,7 @@ import (
 
 	"chainguard.dev/apko/pkg/apk/apk"
 	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/dlorenc/wolfi-mcp/pkg/sources"
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
@@ -86,3 +87,38 @@ func TestInfoTool(t *testing.T) {
 		t.Errorf("Expected 'not found' message for nonexistent package, got: %s", jsonStr)
 	}
 }
+
+func TestInfoRepository(t *testing.T) {
+	pkg := &apk.Package{Name: "curl", Version: "8.0.0-r0"}
+	provenance := sources.NewIndex([]sources.Source{
+		{Location: "https://packages.wolfi.dev/os/x86_64/APKINDEX.tar.gz", Digest: "abc123", Packages: []*apk.Package{pkg}},
+	})
+	handler := New().GetHandler(apkindex.NewRepository([]*apk.Package{pkg}))
+
+	req := mcp.CallToolRequest{}
+	req.Params.Name = "package_info"
+	req.Params.Arguments = map[string]interface{}{
+		"package": "curl",
+	}
+
+	result, err := handler(sources.NewContext(context.Background(), provenance), req)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	var details struct {
+		Name       string
+		Repository struct {
+			Name, URL, Index, Digest string
+		}
+	}
+	if err := json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &details); err != nil {
+		t.Fatalf("Failed to parse package details: %v", err)
+	}
+	if details.Name != "curl" {
+		t.Errorf("Expected the package name to be kept, got %q", details.Name)
+	}
+	if details.Repository.Name != "packages.wolfi.dev/os" || details.Repository.URL != "https://packages.wolfi.dev/os" || details.Repository.Digest != "abc123" {
+		t.Errorf("Unexpected repository details: %+v", details.Repository)
+	}
+}
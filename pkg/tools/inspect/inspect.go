@@ -0,0 +1,173 @@
+package inspect
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"github.com/dlorenc/wolfi-mcp/pkg/apkfile"
+	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/dlorenc/wolfi-mcp/pkg/sources"
+	"github.com/dlorenc/wolfi-mcp/pkg/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Tool implements the .apk inspection tool
+type Tool struct {
+	tools.BaseTool
+}
+
+// arguments are the arguments of the .apk inspection tool
+type arguments struct {
+	Path    string   `arg:"path" required:"true" description:"Path to a local .apk file"`
+	Keyring []string `arg:"keyring" description:"Paths to local RSA public keys to verify the signatures with, matched by file name"`
+	Limit   int      `arg:"limit" default:"200" minimum:"0" description:"Maximum number of files listed, 0 for no limit"`
+}
+
+// New creates a new .apk inspection tool
+func New() *Tool {
+	tool := mcp.NewTool("inspect_apk",
+		mcp.WithDescription("Open a local .apk file and show its .PKGINFO fields, signatures, install scripts and file listing, and compare its metadata with the index entry of the same name and version"),
+		tools.WithArguments(arguments{}),
+	)
+
+	return &Tool{
+		BaseTool: tools.BaseTool{Tool: tool},
+	}
+}
+
+// GetHandler returns the handler function for the .apk inspection tool
+func (t *Tool) GetHandler(repo *apkindex.Repository) tools.ToolHandler {
+	return tools.Bind(func(ctx context.Context, args arguments) (*mcp.CallToolResult, error) {
+		provenance := sources.FromContext(ctx)
+
+		file, err := apkfile.Open(args.Path)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error reading %s: %v", args.Path, err)), nil
+		}
+
+		keys := make(map[string][]byte, len(args.Keyring))
+		for _, path := range args.Keyring {
+			key, err := os.ReadFile(path)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Error reading key %s: %v", path, err)), nil
+			}
+			keys[filepath.Base(path)] = key
+		}
+
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("%s (%d bytes): %s %s\n", args.Path, file.Size, file.Package.Name, file.Package.Version))
+
+		sb.WriteString("\n.PKGINFO:\n")
+		for _, field := range file.Fields {
+			sb.WriteString(fmt.Sprintf("  %s = %s\n", field.Key, field.Value))
+		}
+
+		writeSignatures(&sb, file, keys)
+
+		switch {
+		case file.Package.DataHash == "":
+			sb.WriteString(fmt.Sprintf("\nData hash: sha256:%s (.PKGINFO has no datahash)\n", file.DataHash))
+		case file.Package.DataHash == file.DataHash:
+			sb.WriteString(fmt.Sprintf("\nData hash: sha256:%s, matches .PKGINFO\n", file.DataHash))
+		default:
+			sb.WriteString(fmt.Sprintf("\nData hash: sha256:%s, DOES NOT MATCH .PKGINFO datahash %s\n", file.DataHash, file.Package.DataHash))
+		}
+
+		if len(file.Scripts) > 0 {
+			sb.WriteString(fmt.Sprintf("\nInstall scripts (%d):\n", len(file.Scripts)))
+			for _, script := range file.Scripts {
+				sb.WriteString(fmt.Sprintf("--- %s\n%s", script.Name, script.Content))
+				if !strings.HasSuffix(script.Content, "\n") {
+					sb.WriteString("\n")
+				}
+			}
+		}
+
+		sb.WriteString(fmt.Sprintf("\nFiles (%d):\n", len(file.Entries)))
+		for i, entry := range file.Entries {
+			if args.Limit > 0 && i == args.Limit {
+				sb.WriteString(fmt.Sprintf("... and %d more\n", len(file.Entries)-args.Limit))
+				break
+			}
+			sb.WriteString(fmt.Sprintf("%s %d:%d %10d %s", entry.Mode, entry.UID, entry.GID, entry.Size, entry.Name))
+			if entry.Link != "" {
+				sb.WriteString(" -> " + entry.Link)
+			}
+			sb.WriteString("\n")
+		}
+
+		writeComparison(&sb, repo, provenance, file)
+
+		return mcp.NewToolResultText(sb.String()), nil
+	})
+}
+
+// writeSignatures lists the signatures and whether they verify against the keyring
+func writeSignatures(sb *strings.Builder, file *apkfile.File, keys map[string][]byte) {
+	if len(file.Signatures) == 0 {
+		sb.WriteString("\nSignatures: none, the package is unsigned\n")
+		return
+	}
+
+	sb.WriteString(fmt.Sprintf("\nSignatures (%d):\n", len(file.Signatures)))
+	for _, sig := range file.Signatures {
+		algorithm := "RSA/SHA-1"
+		if sig.Hash == crypto.SHA256 {
+			algorithm = "RSA/SHA-256"
+		}
+		sb.WriteString(fmt.Sprintf("- %s (%s, %d bytes): ", sig.KeyName, algorithm, len(sig.Value)))
+
+		key, ok := keys[sig.KeyName]
+		if !ok {
+			sb.WriteString("not verified, the key is not in the keyring\n")
+			continue
+		}
+		if err := file.Verify(sig, key); err != nil {
+			sb.WriteString(fmt.Sprintf("INVALID: %v\n", err))
+		} else {
+			sb.WriteString("verified\n")
+		}
+	}
+}
+
+// writeComparison compares the package with the index entry of the same name
+// and version
+func writeComparison(sb *strings.Builder, repo *apkindex.Repository, provenance *sources.Index, file *apkfile.File) {
+	pkg := file.Package
+	sb.WriteString("\nIndex comparison:\n")
+
+	versions := repo.GetPackageVersions(pkg.Name)
+	var entry *apk.Package
+	var available []string
+	for _, candidate := range versions {
+		if candidate.Version == pkg.Version {
+			entry = candidate
+			break
+		}
+		available = append(available, candidate.Version)
+	}
+
+	switch {
+	case len(versions) == 0:
+		sb.WriteString(fmt.Sprintf("%s is not in the index.\n", pkg.Name))
+		return
+	case entry == nil:
+		sb.WriteString(fmt.Sprintf("%s %s is not in the index, which has %s.\n", pkg.Name, pkg.Version, strings.Join(available, ", ")))
+		return
+	}
+
+	mismatches := file.Compare(entry)
+	if len(mismatches) == 0 {
+		sb.WriteString(fmt.Sprintf("Matches the index entry of %s %s%s.\n", entry.Name, entry.Version, provenance.Suffix(entry)))
+		return
+	}
+	sb.WriteString(fmt.Sprintf("Differs from the index entry of %s %s%s (%d):\n", entry.Name, entry.Version, provenance.Suffix(entry), len(mismatches)))
+	for _, mismatch := range mismatches {
+		sb.WriteString(fmt.Sprintf("- %s: %q in the file, %q in the index\n", mismatch.Field, mismatch.File, mismatch.Index))
+	}
+}
@@ -0,0 +1,121 @@
+package inspect
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// writeAPK writes an unsigned .apk file with the given .PKGINFO and one file
+func writeAPK(t *testing.T, pkginfo string) string {
+	segment := func(name, content string) []byte {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(zw)
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+		if err := tw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	path := filepath.Join(t.TempDir(), "hello.apk")
+	content := append(segment(".PKGINFO", pkginfo), segment("etc/hello.conf", "greeting=hello\n")...)
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestInspectTool(t *testing.T) {
+	// Create tool
+	tool := New()
+
+	// Check tool name
+	if tool.GetTool().Name != "inspect_apk" {
+		t.Errorf("Expected tool name to be 'inspect_apk', got '%s'", tool.GetTool().Name)
+	}
+
+	path := writeAPK(t, "pkgname = hello\npkgver = 1.0-r0\narch = x86_64\nlicense = MIT\nsize = 15\n")
+
+	testCases := []struct {
+		name      string
+		packages  []*apk.Package
+		args      map[string]interface{}
+		isError   bool
+		checkText []string
+	}{
+		{
+			name:     "differs from the index",
+			packages: []*apk.Package{{Name: "hello", Version: "1.0-r0", Arch: "x86_64", License: "Apache-2.0", InstalledSize: 15}},
+			args:     map[string]interface{}{"path": path},
+			checkText: []string{
+				"hello 1.0-r0",
+				"  license = MIT",
+				"Signatures: none, the package is unsigned",
+				"(.PKGINFO has no datahash)",
+				"Files (1):\n-rw-r--r-- 0:0         15 etc/hello.conf",
+				"Differs from the index entry of hello 1.0-r0",
+				`- license: "MIT" in the file, "Apache-2.0" in the index`,
+			},
+		},
+		{
+			name:      "other version in the index",
+			packages:  []*apk.Package{{Name: "hello", Version: "0.9-r0"}},
+			args:      map[string]interface{}{"path": path},
+			checkText: []string{"hello 1.0-r0 is not in the index, which has 0.9-r0."},
+		},
+		{
+			name:      "not in the index",
+			args:      map[string]interface{}{"path": path, "limit": 0},
+			checkText: []string{"hello is not in the index."},
+		},
+		{
+			name:      "missing file",
+			args:      map[string]interface{}{"path": filepath.Join(t.TempDir(), "missing.apk")},
+			isError:   true,
+			checkText: []string{"Error reading"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := tool.GetHandler(apkindex.NewRepository(tc.packages))
+
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = tc.args
+
+			result, err := handler(context.Background(), request)
+			if err != nil {
+				t.Fatalf("Handler returned error: %v", err)
+			}
+			if result.IsError != tc.isError {
+				t.Errorf("IsError = %v, want %v", result.IsError, tc.isError)
+			}
+
+			text := result.Content[0].(mcp.TextContent).Text
+			for _, check := range tc.checkText {
+				if !strings.Contains(text, check) {
+					t.Errorf("Expected result to contain %q, got: %s", check, text)
+				}
+			}
+		})
+	}
+}
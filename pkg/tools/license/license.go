@@ -0,0 +1,124 @@
+package license
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/dlorenc/wolfi-mcp/pkg/resolve"
+	"github.com/dlorenc/wolfi-mcp/pkg/sources"
+	"github.com/dlorenc/wolfi-mcp/pkg/spdx"
+	"github.com/dlorenc/wolfi-mcp/pkg/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Tool implements the license report tool
+type Tool struct {
+	tools.BaseTool
+}
+
+// arguments are the arguments of the license report tool
+type arguments struct {
+	Packages []string `arg:"packages" required:"true" description:"The packages, as a list or a comma separated string"`
+	Allow    []string `arg:"allow" description:"Allowed SPDX license identifiers, wildcards such as 'BSD-*' are supported (default: any)"`
+	Deny     []string `arg:"deny" description:"Denied SPDX license identifiers, wildcards such as 'AGPL-*' are supported"`
+}
+
+// New creates a new license report tool
+func New() *Tool {
+	tool := mcp.NewTool("license_report",
+		mcp.WithDescription("Group the runtime closure of a set of packages by license, optionally checking it against an allow/deny policy"),
+		tools.WithArguments(arguments{}),
+	)
+
+	return &Tool{
+		BaseTool: tools.BaseTool{Tool: tool},
+	}
+}
+
+// GetHandler returns the handler function for the license report tool
+func (t *Tool) GetHandler(repo *apkindex.Repository) tools.ToolHandler {
+	return tools.Bind(func(ctx context.Context, args arguments) (*mcp.CallToolResult, error) {
+		provenance := sources.FromContext(ctx)
+		packages := args.Packages
+		if len(packages) == 0 {
+			return mcp.NewToolResultError("At least one package must be provided"), nil
+		}
+
+		policy := spdx.Policy{Allow: args.Allow, Deny: args.Deny}
+
+		closure := resolve.New(repo).Closure(packages)
+
+		byLicense := make(map[string][]*apk.Package)
+		var unparsed, violations []*apk.Package
+		expressions := make(map[*apk.Package]*spdx.Expression)
+		for _, pkg := range closure.Packages {
+			e, err := spdx.Parse(pkg.License)
+			if err != nil {
+				unparsed = append(unparsed, pkg)
+				continue
+			}
+			expressions[pkg] = e
+			for _, license := range e.Licenses() {
+				byLicense[license] = append(byLicense[license], pkg)
+			}
+			if !policy.Empty() && !e.Satisfiable(policy.Accepts) {
+				violations = append(violations, pkg)
+			}
+		}
+
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("License report for the runtime closure of %s (%d packages):\n", strings.Join(packages, ", "), len(closure.Packages)))
+
+		licenses := make([]string, 0, len(byLicense))
+		for license := range byLicense {
+			licenses = append(licenses, license)
+		}
+		sort.Strings(licenses)
+
+		for _, license := range licenses {
+			sb.WriteString(fmt.Sprintf("\n%s (%d):\n", license, len(byLicense[license])))
+			for _, pkg := range byLicense[license] {
+				sb.WriteString(fmt.Sprintf("- %s (%s)%s", pkg.Name, pkg.Version, provenance.Suffix(pkg)))
+				if e := expressions[pkg]; e.Operator != "" || e.Exception != "" {
+					sb.WriteString(fmt.Sprintf(" [%s]", e))
+				}
+				sb.WriteString("\n")
+			}
+		}
+
+		if len(unparsed) > 0 {
+			sb.WriteString(fmt.Sprintf("\nLicense not a valid SPDX expression (%d):\n", len(unparsed)))
+			for _, pkg := range unparsed {
+				sb.WriteString(fmt.Sprintf("- %s (%s)%s: %q\n", pkg.Name, pkg.Version, provenance.Suffix(pkg), pkg.License))
+			}
+		}
+
+		if !policy.Empty() {
+			if len(violations) == 0 {
+				sb.WriteString("\nNo policy violations found.\n")
+			} else {
+				sb.WriteString(fmt.Sprintf("\nPolicy violations (%d):\n", len(violations)))
+				for _, pkg := range violations {
+					sb.WriteString(fmt.Sprintf("- %s (%s)%s: %s\n", pkg.Name, pkg.Version, provenance.Suffix(pkg), expressions[pkg]))
+					sb.WriteString(fmt.Sprintf("  Pulled in by: %s\n", strings.Join(closure.Path(pkg.Name), " -> ")))
+				}
+			}
+			if len(unparsed) > 0 {
+				sb.WriteString("Packages without a valid SPDX expression need a manual review.\n")
+			}
+		}
+
+		if len(closure.Problems) > 0 {
+			sb.WriteString("\nUnresolved dependencies (not included in the report):\n")
+			for _, problem := range closure.Problems {
+				sb.WriteString(fmt.Sprintf("- %s\n", problem))
+			}
+		}
+
+		return mcp.NewToolResultText(sb.String()), nil
+	})
+}
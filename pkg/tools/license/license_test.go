@@ -0,0 +1,94 @@
+package license
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestLicenseTool(t *testing.T) {
+	// Create tool
+	tool := New()
+
+	// Check tool name
+	if tool.GetTool().Name != "license_report" {
+		t.Errorf("Expected tool name to be 'license_report', got '%s'", tool.GetTool().Name)
+	}
+
+	// Create mock repository
+	mockPackages := []*apk.Package{
+		{Name: "app", Version: "1.0-r0", License: "Apache-2.0", Dependencies: []string{"lib", "tool"}},
+		{Name: "lib", Version: "2.0-r0", License: "MIT OR AGPL-3.0-only", Dependencies: []string{"deep"}},
+		{Name: "deep", Version: "3.0-r0", License: "AGPL-3.0-or-later AND MIT"},
+		{Name: "tool", Version: "4.0-r0", License: "Custom license text"},
+	}
+	repo := apkindex.NewRepository(mockPackages)
+
+	// Get handler
+	handler := tool.GetHandler(repo)
+
+	testCases := []struct {
+		name      string
+		args      map[string]interface{}
+		checkText []string
+		absent    []string
+	}{
+		{
+			name: "grouping",
+			args: map[string]interface{}{"packages": "app"},
+			checkText: []string{
+				"runtime closure of app (4 packages)",
+				"AGPL-3.0-only (1):\n- lib (2.0-r0) [MIT OR AGPL-3.0-only]",
+				"Apache-2.0 (1):\n- app (1.0-r0)\n",
+				"MIT (2):",
+				"License not a valid SPDX expression (1):\n- tool (4.0-r0): \"Custom license text\"",
+			},
+			absent: []string{"Policy violations", "No policy violations"},
+		},
+		{
+			name: "deny policy",
+			args: map[string]interface{}{"packages": "app", "deny": "AGPL-*"},
+			checkText: []string{
+				"Policy violations (1):\n- deep (3.0-r0): AGPL-3.0-or-later AND MIT\n  Pulled in by: app -> lib -> deep",
+				"need a manual review",
+			},
+		},
+		{
+			name:      "allow policy",
+			args:      map[string]interface{}{"packages": "lib", "allow": "MIT, AGPL-3.0-or-later"},
+			checkText: []string{"No policy violations found."},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := mcp.CallToolRequest{}
+			req.Params.Name = "license_report"
+			req.Params.Arguments = tc.args
+
+			result, err := handler(context.Background(), req)
+			if err != nil {
+				t.Fatalf("Handler returned error: %v", err)
+			}
+			if result.IsError {
+				t.Fatalf("Expected successful result, got error")
+			}
+
+			text := result.Content[0].(mcp.TextContent).Text
+			for _, expected := range tc.checkText {
+				if !strings.Contains(text, expected) {
+					t.Errorf("Expected result to contain '%s', got: %s", expected, text)
+				}
+			}
+			for _, unexpected := range tc.absent {
+				if strings.Contains(text, unexpected) {
+					t.Errorf("Expected result not to contain '%s', got: %s", unexpected, text)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,279 @@
+package tools
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"golang.org/x/time/rate"
+)
+
+// Middleware wraps the handler of a tool. It is called once per tool when the
+// tools are registered, so any state shared between calls of every tool must
+// be created outside of the returned function.
+type Middleware func(tool mcp.Tool, next ToolHandler) ToolHandler
+
+// Chain combines middleware into one, the first being the outermost. Nil
+// entries are skipped.
+func Chain(middleware ...Middleware) Middleware {
+	return func(tool mcp.Tool, next ToolHandler) ToolHandler {
+		for i := len(middleware) - 1; i >= 0; i-- {
+			if middleware[i] != nil {
+				next = middleware[i](tool, next)
+			}
+		}
+		return next
+	}
+}
+
+// ClientID identifies the client session a call was made from
+func ClientID(ctx context.Context) string {
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		return session.SessionID()
+	}
+	return "local"
+}
+
+// Recover turns a panicking handler into a tool error, so a bug in one tool
+// does not take the server down
+func Recover(logger *slog.Logger) Middleware {
+	return func(tool mcp.Tool, next ToolHandler) ToolHandler {
+		return func(ctx context.Context, request mcp.CallToolRequest) (result *mcp.CallToolResult, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					if logger != nil {
+						logger.Error("tool panicked", "tool", tool.Name, "panic", fmt.Sprint(r), "stack", string(debug.Stack()))
+					}
+					result, err = mcp.NewToolResultError(fmt.Sprintf("Internal error in %s: %v", tool.Name, r)), nil
+				}
+			}()
+			return next(ctx, request)
+		}
+	}
+}
+
+// Timing reports the duration and outcome of every call to observe
+func Timing(observe func(tool string, elapsed time.Duration, result *mcp.CallToolResult, err error)) Middleware {
+	return func(tool mcp.Tool, next ToolHandler) ToolHandler {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			start := time.Now()
+			result, err := next(ctx, request)
+			observe(tool.Name, time.Since(start), result, err)
+			return result, err
+		}
+	}
+}
+
+// Logging logs every call with its arguments, duration and outcome
+func Logging(logger *slog.Logger) Middleware {
+	return func(tool mcp.Tool, next ToolHandler) ToolHandler {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			start := time.Now()
+			result, err := next(ctx, request)
+
+			attrs := []any{
+				"tool", tool.Name,
+				"client", ClientID(ctx),
+				"arguments", request.Params.Arguments,
+				"duration", time.Since(start).Round(time.Microsecond),
+			}
+			switch {
+			case err != nil:
+				logger.ErrorContext(ctx, "tool call failed", append(attrs, "error", err)...)
+			case result != nil && result.IsError:
+				logger.WarnContext(ctx, "tool call returned an error", append(attrs, "output_bytes", textSize(result))...)
+			default:
+				logger.InfoContext(ctx, "tool call", append(attrs, "output_bytes", textSize(result))...)
+			}
+			return result, err
+		}
+	}
+}
+
+// maxRateLimitedClients bounds the number of client limiters kept in memory
+const maxRateLimitedClients = 1024
+
+// RateLimit allows each client perSecond calls per second across all tools,
+// with bursts of up to burst calls. Calls over the limit are rejected with a
+// tool error telling the client when to retry.
+func RateLimit(perSecond float64, burst int) Middleware {
+	var mu sync.Mutex
+	limiters := make(map[string]*rate.Limiter)
+
+	limiter := func(client string) *rate.Limiter {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if l, ok := limiters[client]; ok {
+			return l
+		}
+		if len(limiters) >= maxRateLimitedClients {
+			// Forget clients that have been idle long enough to have a full bucket
+			for id, l := range limiters {
+				if l.Tokens() >= float64(burst) {
+					delete(limiters, id)
+				}
+			}
+		}
+		l := rate.NewLimiter(rate.Limit(perSecond), burst)
+		limiters[client] = l
+		return l
+	}
+
+	return func(tool mcp.Tool, next ToolHandler) ToolHandler {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			reservation := limiter(ClientID(ctx)).Reserve()
+			if delay := reservation.Delay(); delay > 0 {
+				reservation.Cancel()
+				return mcp.NewToolResultError(fmt.Sprintf("Rate limit exceeded, retry in %s", delay.Round(time.Millisecond))), nil
+			}
+			return next(ctx, request)
+		}
+	}
+}
+
+// cacheEntry is a cached tool result
+type cacheEntry struct {
+	key     string
+	result  *mcp.CallToolResult
+	expires time.Time
+}
+
+// Cache keeps up to size successful results, keyed by tool name and
+// arguments, and serves repeated calls from memory. Results expire after ttl,
+// or never when ttl is 0. The least recently used results are evicted first.
+// Only the tools for which cacheable returns true are cached, so tools reading
+// state that changes while the server runs keep calling their handler. If
+// lookup is not nil, it is told whether each call was served from the cache.
+func Cache(size int, ttl time.Duration, cacheable func(tool mcp.Tool) bool, lookup func(hit bool)) Middleware {
+	var mu sync.Mutex
+	entries := make(map[string]*list.Element)
+	order := list.New()
+
+	get := func(key string) (*mcp.CallToolResult, bool) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		element, ok := entries[key]
+		if !ok {
+			return nil, false
+		}
+		entry := element.Value.(*cacheEntry)
+		if ttl > 0 && time.Now().After(entry.expires) {
+			order.Remove(element)
+			delete(entries, key)
+			return nil, false
+		}
+		order.MoveToFront(element)
+		return entry.result, true
+	}
+
+	put := func(key string, result *mcp.CallToolResult) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if element, ok := entries[key]; ok {
+			order.Remove(element)
+		}
+		entries[key] = order.PushFront(&cacheEntry{key: key, result: result, expires: time.Now().Add(ttl)})
+		for order.Len() > size {
+			oldest := order.Back()
+			order.Remove(oldest)
+			delete(entries, oldest.Value.(*cacheEntry).key)
+		}
+	}
+
+	return func(tool mcp.Tool, next ToolHandler) ToolHandler {
+		if !cacheable(tool) {
+			return next
+		}
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			// Maps are marshalled with sorted keys, so equal arguments give equal keys
+			arguments, err := json.Marshal(request.Params.Arguments)
+			if err != nil {
+				return next(ctx, request)
+			}
+			key := tool.Name + "\x00" + string(arguments)
+
+			result, ok := get(key)
+			if lookup != nil {
+				lookup(ok)
+			}
+			if ok {
+				return result, nil
+			}
+			result, err = next(ctx, request)
+			if err == nil && result != nil && !result.IsError {
+				put(key, result)
+			}
+			return result, err
+		}
+	}
+}
+
+// LimitOutput truncates the text of results longer than maxBytes, telling
+// the client how much was left out
+func LimitOutput(maxBytes int) Middleware {
+	return func(tool mcp.Tool, next ToolHandler) ToolHandler {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			result, err := next(ctx, request)
+			if err != nil || result == nil {
+				return result, err
+			}
+			total := textSize(result)
+			if total <= maxBytes {
+				return result, nil
+			}
+
+			// Results may be shared with the cache, so build a new one
+			truncated := *result
+			truncated.Content = make([]mcp.Content, 0, len(result.Content))
+			remaining := maxBytes
+			for _, content := range result.Content {
+				text, ok := content.(mcp.TextContent)
+				if !ok {
+					truncated.Content = append(truncated.Content, content)
+					continue
+				}
+				if remaining <= 0 {
+					continue
+				}
+				if len(text.Text) > remaining {
+					cut := remaining
+					for cut > 0 && !utf8.RuneStart(text.Text[cut]) {
+						cut--
+					}
+					text.Text = text.Text[:cut]
+				}
+				remaining -= len(text.Text)
+				truncated.Content = append(truncated.Content, text)
+			}
+			truncated.Content = append(truncated.Content, mcp.NewTextContent(
+				fmt.Sprintf("\n[output truncated: %d of %d bytes shown, narrow the query to see the rest]", maxBytes-remaining, total),
+			))
+			return &truncated, nil
+		}
+	}
+}
+
+// textSize returns the total size of the text content of a result
+func textSize(result *mcp.CallToolResult) int {
+	if result == nil {
+		return 0
+	}
+	size := 0
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			size += len(text.Text)
+		}
+	}
+	return size
+}
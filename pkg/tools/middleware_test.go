@@ -0,0 +1,172 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// call invokes a handler with the given arguments and fails the test on a Go error
+func call(t *testing.T, handler ToolHandler, args map[string]interface{}) *mcp.CallToolResult {
+	t.Helper()
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = args
+	result, err := handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	return result
+}
+
+func resultText(result *mcp.CallToolResult) string {
+	var sb strings.Builder
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			sb.WriteString(text.Text)
+		}
+	}
+	return sb.String()
+}
+
+func TestChain(t *testing.T) {
+	var order []string
+	trace := func(name string) Middleware {
+		return func(tool mcp.Tool, next ToolHandler) ToolHandler {
+			return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				order = append(order, name)
+				return next(ctx, request)
+			}
+		}
+	}
+
+	handler := Chain(trace("outer"), nil, trace("inner"))(mcp.NewTool("test"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		order = append(order, "handler")
+		return mcp.NewToolResultText("ok"), nil
+	})
+	call(t, handler, nil)
+
+	if strings.Join(order, ",") != "outer,inner,handler" {
+		t.Errorf("Expected middleware to run outermost first, got %v", order)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	handler := Recover(nil)(mcp.NewTool("broken"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		panic("boom")
+	})
+
+	result := call(t, handler, nil)
+	if !result.IsError || !strings.Contains(resultText(result), "Internal error in broken: boom") {
+		t.Errorf("Expected the panic to be reported as a tool error, got %+v", result)
+	}
+}
+
+func TestTimingAndLogging(t *testing.T) {
+	var observed string
+	timing := Timing(func(tool string, elapsed time.Duration, result *mcp.CallToolResult, err error) {
+		observed = tool
+	})
+
+	var logs bytes.Buffer
+	logging := Logging(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	handler := Chain(timing, logging)(mcp.NewTool("search_packages"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultError("not found"), nil
+	})
+	call(t, handler, map[string]interface{}{"query": "yaml"})
+
+	if observed != "search_packages" {
+		t.Errorf("Expected the call to be observed, got %q", observed)
+	}
+	for _, check := range []string{"level=WARN", "tool=search_packages", "client=local", "map[query:yaml]", "duration="} {
+		if !strings.Contains(logs.String(), check) {
+			t.Errorf("Expected log to contain %q, got: %s", check, logs.String())
+		}
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	limit := RateLimit(0.001, 2)
+	ok := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("ok"), nil
+	}
+	first := limit(mcp.NewTool("a"), ok)
+	second := limit(mcp.NewTool("b"), ok)
+
+	// The burst is shared between the tools of a client
+	if call(t, first, nil).IsError || call(t, second, nil).IsError {
+		t.Fatalf("Expected calls within the burst to succeed")
+	}
+	result := call(t, first, nil)
+	if !result.IsError || !strings.Contains(resultText(result), "Rate limit exceeded") {
+		t.Errorf("Expected the third call to be rate limited, got %+v", result)
+	}
+}
+
+func TestCache(t *testing.T) {
+	calls, hits := 0, 0
+	cache := Cache(2, time.Hour, func(tool mcp.Tool) bool {
+		return tool.Name != "volatile"
+	}, func(hit bool) {
+		if hit {
+			hits++
+		}
+	})
+	next := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		calls++
+		if request.Params.Arguments["fail"] == true {
+			return mcp.NewToolResultError("failed"), nil
+		}
+		return mcp.NewToolResultText("ok"), nil
+	}
+	handler := cache(mcp.NewTool("test"), next)
+
+	call(t, handler, map[string]interface{}{"a": "1", "b": "2"})
+	call(t, handler, map[string]interface{}{"b": "2", "a": "1"})
+	if calls != 1 || hits != 1 {
+		t.Errorf("Expected identical arguments to be served from the cache, got %d calls and %d hits", calls, hits)
+	}
+
+	call(t, handler, map[string]interface{}{"fail": true})
+	call(t, handler, map[string]interface{}{"fail": true})
+	if calls != 3 {
+		t.Errorf("Expected errors not to be cached, got %d calls", calls)
+	}
+
+	// Two more entries evict the least recently used one
+	call(t, handler, map[string]interface{}{"a": "2"})
+	call(t, handler, map[string]interface{}{"a": "3"})
+	call(t, handler, map[string]interface{}{"a": "1", "b": "2"})
+	if calls != 6 {
+		t.Errorf("Expected the oldest entry to be evicted, got %d calls", calls)
+	}
+
+	// Tools that are not cacheable always call their handler
+	volatile := cache(mcp.NewTool("volatile"), next)
+	call(t, volatile, map[string]interface{}{"a": "1"})
+	call(t, volatile, map[string]interface{}{"a": "1"})
+	if calls != 8 {
+		t.Errorf("Expected calls of a tool that is not cacheable to be run, got %d calls", calls)
+	}
+}
+
+func TestLimitOutput(t *testing.T) {
+	original := mcp.NewToolResultText("héllo world")
+	handler := LimitOutput(2)(mcp.NewTool("test"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return original, nil
+	})
+
+	result := call(t, handler, nil)
+	text := resultText(result)
+	if !strings.HasPrefix(text, "h\n[output truncated: 1 of 12 bytes shown") {
+		t.Errorf("Expected the output to be cut on a rune boundary, got %q", text)
+	}
+	if resultText(original) != "héllo world" {
+		t.Errorf("Expected the original result to be left untouched, got %q", resultText(original))
+	}
+}
@@ -0,0 +1,354 @@
+package migrate
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/dlorenc/wolfi-mcp/pkg/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"gopkg.in/yaml.v3"
+)
+
+// renamesData holds the bundled mapping of known package renames
+//
+//go:embed renames.yaml
+var renamesData []byte
+
+// managers maps package manager commands to the subcommand that installs packages
+var managers = map[string]string{
+	"apk":      "add",
+	"apt-get":  "install",
+	"apt":      "install",
+	"yum":      "install",
+	"dnf":      "install",
+	"microdnf": "install",
+}
+
+// valueOptions lists the options that consume the following argument, so it
+// is not mistaken for a package name
+var valueOptions = map[string]bool{
+	// apk
+	"-X": true, "--repository": true, "-t": true, "--virtual": true,
+	"-p": true, "--root": true, "--arch": true, "--keys-dir": true, "--cache-dir": true,
+	// apt
+	"-o": true, "--option": true, "--target-release": true, "--default-release": true,
+	// yum, dnf and microdnf
+	"-c": true, "--config": true, "--installroot": true, "--releasever": true,
+	"--enablerepo": true, "--disablerepo": true, "--setopt": true,
+}
+
+// commandSeparator splits a shell command line into simple commands
+var commandSeparator = regexp.MustCompile(`&&|\|\||[;|]`)
+
+// sharedLibrary matches Debian style shared library package names such as libssl3
+var sharedLibrary = regexp.MustCompile(`^(lib.+?)(\d+)$`)
+
+// Request is a package requested by a package manager invocation in a Dockerfile
+type Request struct {
+	// Name is the requested package name, without any version
+	Name string
+
+	// Manager is the package manager command that requested it
+	Manager string
+
+	// Line is the line of the RUN instruction
+	Line int
+}
+
+// Match is the result of mapping a requested package to Wolfi
+type Match struct {
+	Request
+
+	// Candidates holds the Wolfi packages that may replace the requested package
+	Candidates []string
+
+	// Method describes how the candidates were found
+	Method string
+}
+
+// Tool implements the Dockerfile migration tool
+type Tool struct {
+	tools.BaseTool
+	renames map[string][]string
+}
+
+// arguments are the arguments of the Dockerfile migration tool
+type arguments struct {
+	Dockerfile string `arg:"dockerfile" description:"The Dockerfile contents, inline"`
+	Path       string `arg:"path" description:"Path to a local Dockerfile (used when dockerfile is not given)"`
+}
+
+// New creates a new Dockerfile migration tool
+func New() *Tool {
+	tool := mcp.NewTool("migrate_dockerfile",
+		mcp.WithDescription("Find the packages installed by a Dockerfile (apk, apt-get, yum, dnf, microdnf) and map each of them to Wolfi packages"),
+		tools.WithArguments(arguments{}),
+	)
+
+	renames := make(map[string][]string)
+	if err := yaml.Unmarshal(renamesData, &renames); err != nil {
+		// The mapping is bundled with the binary, so this is a programming error
+		panic(fmt.Sprintf("invalid bundled renames: %v", err))
+	}
+
+	return &Tool{
+		BaseTool: tools.BaseTool{Tool: tool},
+		renames:  renames,
+	}
+}
+
+// GetHandler returns the handler function for the Dockerfile migration tool
+func (t *Tool) GetHandler(repo *apkindex.Repository) tools.ToolHandler {
+	// Index the shared library and command provides once, they are used to
+	// find equivalents for packages that were renamed
+	provided := make(map[string][]string)
+	for _, pkg := range repo.GetAllPackages() {
+		for _, provide := range pkg.Provides {
+			name, _, _ := strings.Cut(provide, "=")
+			if strings.HasPrefix(name, "so:") || strings.HasPrefix(name, "cmd:") {
+				provided[name] = appendUnique(provided[name], pkg.Name)
+			}
+		}
+	}
+
+	return tools.Bind(func(ctx context.Context, args arguments) (*mcp.CallToolResult, error) {
+		dockerfile, err := readDockerfile(args.Dockerfile, args.Path)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		requests := ParseDockerfile(dockerfile)
+		if len(requests) == 0 {
+			return mcp.NewToolResultText("No package installations found in the Dockerfile."), nil
+		}
+
+		var confident, ambiguous, missing []Match
+		for _, req := range requests {
+			match := t.match(repo, provided, req)
+			switch len(match.Candidates) {
+			case 0:
+				missing = append(missing, match)
+			case 1:
+				confident = append(confident, match)
+			default:
+				ambiguous = append(ambiguous, match)
+			}
+		}
+
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("Found %d requested packages.\n", len(requests)))
+
+		sb.WriteString(fmt.Sprintf("\nConfident matches (%d):\n", len(confident)))
+		for _, m := range confident {
+			sb.WriteString(fmt.Sprintf("- %s (%s, line %d) -> %s [%s]\n", m.Name, m.Manager, m.Line, m.Candidates[0], m.Method))
+		}
+
+		sb.WriteString(fmt.Sprintf("\nAmbiguous matches (%d):\n", len(ambiguous)))
+		for _, m := range ambiguous {
+			sb.WriteString(fmt.Sprintf("- %s (%s, line %d) -> one of %s [%s]\n", m.Name, m.Manager, m.Line, strings.Join(m.Candidates, ", "), m.Method))
+		}
+
+		sb.WriteString(fmt.Sprintf("\nNo Wolfi equivalent (%d):\n", len(missing)))
+		for _, m := range missing {
+			sb.WriteString(fmt.Sprintf("- %s (%s, line %d)\n", m.Name, m.Manager, m.Line))
+		}
+
+		var packages []string
+		for _, m := range confident {
+			packages = appendUnique(packages, m.Candidates[0])
+		}
+		if len(packages) > 0 {
+			sb.WriteString(fmt.Sprintf("\nSuggested Wolfi command:\nRUN apk add --no-cache %s\n", strings.Join(packages, " ")))
+		}
+
+		return mcp.NewToolResultText(sb.String()), nil
+	})
+}
+
+// match maps a requested package to Wolfi candidates, trying the exact name,
+// the bundled renames and finally the shared library and command provides
+func (t *Tool) match(repo *apkindex.Repository, provided map[string][]string, req Request) Match {
+	m := Match{Request: req}
+
+	if repo.GetPackageInfo(req.Name) != nil {
+		m.Candidates = []string{req.Name}
+		m.Method = "exact name"
+		return m
+	}
+
+	// Copy the shared renames, since handlers run concurrently
+	renames := append([]string(nil), t.renames[req.Name]...)
+	if strings.HasSuffix(req.Name, "-devel") {
+		renames = append(renames, strings.TrimSuffix(req.Name, "-devel")+"-dev")
+	}
+	for _, name := range renames {
+		if repo.GetPackageInfo(name) != nil {
+			m.Candidates = appendUnique(m.Candidates, name)
+		}
+	}
+	if len(m.Candidates) > 0 {
+		m.Method = "known rename"
+		return m
+	}
+
+	var capabilities []string
+	if parts := sharedLibrary.FindStringSubmatch(req.Name); parts != nil {
+		capabilities = append(capabilities, fmt.Sprintf("so:%s.so.%s", parts[1], parts[2]))
+	}
+	capabilities = append(capabilities, "cmd:"+req.Name)
+	for _, capability := range capabilities {
+		for _, name := range provided[capability] {
+			m.Candidates = appendUnique(m.Candidates, name)
+		}
+		if len(m.Candidates) > 0 {
+			sort.Strings(m.Candidates)
+			m.Method = "provides " + capability
+			return m
+		}
+	}
+
+	return m
+}
+
+// readDockerfile returns the inline Dockerfile or the contents of the Dockerfile at path
+func readDockerfile(dockerfile, path string) (string, error) {
+	if dockerfile != "" {
+		return dockerfile, nil
+	}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("Error reading Dockerfile: %v", err)
+		}
+		return string(data), nil
+	}
+	return "", errors.New("Either dockerfile or path must be provided")
+}
+
+// ParseDockerfile returns the packages installed by the RUN instructions of a
+// Dockerfile, in order of first appearance
+func ParseDockerfile(dockerfile string) []Request {
+	var requests []Request
+	seen := make(map[string]bool)
+
+	for _, instruction := range runInstructions(dockerfile) {
+		for _, command := range commandSeparator.Split(instruction.command, -1) {
+			manager, packages := parseCommand(strings.Fields(command))
+			for _, name := range packages {
+				if seen[name] {
+					continue
+				}
+				seen[name] = true
+				requests = append(requests, Request{Name: name, Manager: manager, Line: instruction.line})
+			}
+		}
+	}
+
+	return requests
+}
+
+// runInstruction is the shell command of a RUN instruction and the line it starts on
+type runInstruction struct {
+	command string
+	line    int
+}
+
+// runInstructions joins continuation lines and returns the RUN instructions of a Dockerfile
+func runInstructions(dockerfile string) []runInstruction {
+	var result []runInstruction
+	var current strings.Builder
+	start := 0
+
+	lines := strings.Split(dockerfile, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if current.Len() == 0 {
+			start = i + 1
+		}
+
+		continued := strings.HasSuffix(trimmed, "\\")
+		current.WriteString(strings.TrimSuffix(trimmed, "\\"))
+		current.WriteString(" ")
+		if continued && i < len(lines)-1 {
+			continue
+		}
+
+		instruction := strings.TrimSpace(current.String())
+		current.Reset()
+
+		keyword, rest, _ := strings.Cut(instruction, " ")
+		if !strings.EqualFold(keyword, "RUN") {
+			continue
+		}
+		result = append(result, runInstruction{command: execForm(strings.TrimSpace(rest)), line: start})
+	}
+
+	return result
+}
+
+// execForm converts the JSON exec form of a RUN instruction to a shell command
+func execForm(command string) string {
+	if !strings.HasPrefix(command, "[") {
+		return command
+	}
+	var args []string
+	if err := json.Unmarshal([]byte(command), &args); err != nil {
+		return command
+	}
+	return strings.Join(args, " ")
+}
+
+// parseCommand returns the package manager and the requested packages of a
+// simple command, if it installs packages
+func parseCommand(fields []string) (string, []string) {
+	for i, field := range fields {
+		subcommand, ok := managers[field]
+		if !ok {
+			continue
+		}
+
+		var packages []string
+		foundSubcommand := false
+		for j := i + 1; j < len(fields); j++ {
+			arg := strings.Trim(fields[j], `"'`)
+			switch {
+			case valueOptions[arg]:
+				j++
+			case strings.HasPrefix(arg, "-"), arg == "", strings.Contains(arg, "$"):
+			case !foundSubcommand:
+				if arg != subcommand {
+					return "", nil
+				}
+				foundSubcommand = true
+			default:
+				if idx := strings.IndexAny(arg, "=<>~"); idx > 0 {
+					arg = arg[:idx]
+				}
+				packages = append(packages, arg)
+			}
+		}
+		return field, packages
+	}
+	return "", nil
+}
+
+// appendUnique appends value to values unless it is already present
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
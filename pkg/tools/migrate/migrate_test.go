@@ -0,0 +1,128 @@
+package migrate
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const testDockerfile = `FROM debian:bookworm
+# RUN apt-get install commented-out
+RUN apt-get update && \
+    DEBIAN_FRONTEND=noninteractive apt-get install -y --no-install-recommends \
+      curl=7.88.1-10 build-essential libssl3 sqlite3 python3 $EXTRA \
+    && rm -rf /var/lib/apt/lists/*
+RUN ["dnf", "install", "-y", "--setopt=tsflags=nodocs", "openssl-devel"]
+RUN apk --no-cache add --virtual .build-deps curl made-up-package
+run microdnf install -y made-up-package
+`
+
+func TestParseDockerfile(t *testing.T) {
+	requests := ParseDockerfile(testDockerfile)
+
+	expected := []Request{
+		{Name: "curl", Manager: "apt-get", Line: 3},
+		{Name: "build-essential", Manager: "apt-get", Line: 3},
+		{Name: "libssl3", Manager: "apt-get", Line: 3},
+		{Name: "sqlite3", Manager: "apt-get", Line: 3},
+		{Name: "python3", Manager: "apt-get", Line: 3},
+		{Name: "openssl-devel", Manager: "dnf", Line: 7},
+		{Name: "made-up-package", Manager: "apk", Line: 8},
+	}
+	if !reflect.DeepEqual(requests, expected) {
+		t.Errorf("ParseDockerfile() =\n%v\nwant\n%v", requests, expected)
+	}
+}
+
+func TestMigrateTool(t *testing.T) {
+	// Create tool
+	tool := New()
+
+	// Check tool name
+	if tool.GetTool().Name != "migrate_dockerfile" {
+		t.Errorf("Expected tool name to be 'migrate_dockerfile', got '%s'", tool.GetTool().Name)
+	}
+
+	// Create mock repository
+	mockPackages := []*apk.Package{
+		{Name: "curl", Version: "8.10-r0"},
+		{Name: "build-base", Version: "1-r8"},
+		{Name: "libssl3", Version: "3.4-r0", Provides: []string{"so:libssl.so.3=3"}},
+		{Name: "sqlite", Version: "3.46-r0", Provides: []string{"cmd:sqlite3=3.46-r0"}},
+		{Name: "openssl-dev", Version: "3.4-r0"},
+		{Name: "python-3.12", Version: "3.12.7-r0"},
+		{Name: "python-3.13", Version: "3.13.0-r0"},
+	}
+	repo := apkindex.NewRepository(mockPackages)
+
+	// Get handler
+	handler := tool.GetHandler(repo)
+
+	req := mcp.CallToolRequest{}
+	req.Params.Name = "migrate_dockerfile"
+	req.Params.Arguments = map[string]interface{}{
+		"dockerfile": testDockerfile,
+	}
+
+	result, err := handler(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	if result.IsError {
+		t.Fatalf("Expected successful result, got error")
+	}
+
+	text := result.Content[0].(mcp.TextContent).Text
+	for _, expected := range []string{
+		"Confident matches (5)",
+		"curl (apt-get, line 3) -> curl [exact name]",
+		"build-essential (apt-get, line 3) -> build-base [known rename]",
+		"libssl3 (apt-get, line 3) -> libssl3 [exact name]",
+		"sqlite3 (apt-get, line 3) -> sqlite [provides cmd:sqlite3]",
+		"openssl-devel (dnf, line 7) -> openssl-dev [known rename]",
+		"Ambiguous matches (1)",
+		"python3 (apt-get, line 3) -> one of python-3.13, python-3.12",
+		"No Wolfi equivalent (1)",
+		"made-up-package (apk, line 8)",
+		"RUN apk add --no-cache curl build-base libssl3 sqlite openssl-dev",
+	} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("Expected result to contain '%s', got: %s", expected, text)
+		}
+	}
+
+	// Test without a Dockerfile
+	req.Params.Arguments = map[string]interface{}{}
+	result, err = handler(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	if !result.IsError {
+		t.Errorf("Expected error result when no Dockerfile is provided")
+	}
+}
+
+func TestMatchSharedLibrary(t *testing.T) {
+	tool := New()
+	repo := apkindex.NewRepository([]*apk.Package{
+		{Name: "libcrypto3", Version: "3.4-r0", Provides: []string{"so:libcrypto.so.3=3"}},
+	})
+	provided := map[string][]string{"so:libcrypto.so.3": {"libcrypto3"}}
+
+	match := tool.match(repo, provided, Request{Name: "libcrypto3"})
+	if match.Method != "exact name" {
+		t.Errorf("Expected exact name match, got %q", match.Method)
+	}
+
+	provided = map[string][]string{"so:libfoo.so.1": {"foo-libs"}}
+	match = tool.match(repo, provided, Request{Name: "libfoo1"})
+	if !reflect.DeepEqual(match.Candidates, []string{"foo-libs"}) || match.Method != "provides so:libfoo.so.1" {
+		t.Errorf("Unexpected match for libfoo1: %+v", match)
+	}
+}
@@ -0,0 +1,105 @@
+package origin
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/dlorenc/wolfi-mcp/pkg/resolve"
+	"github.com/dlorenc/wolfi-mcp/pkg/sources"
+	"github.com/dlorenc/wolfi-mcp/pkg/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Tool implements the origin browsing tool
+type Tool struct {
+	tools.BaseTool
+}
+
+// arguments are the arguments of the origin tool
+type arguments struct {
+	Package string `arg:"package" required:"true" description:"An origin name (e.g. 'openssl') or the name of any package built from it (e.g. 'libssl3')"`
+}
+
+// New creates a new origin tool
+func New() *Tool {
+	tool := mcp.NewTool("origin_packages",
+		mcp.WithDescription("List every subpackage built from an origin, or find the origin and siblings of a package"),
+		tools.WithArguments(arguments{}),
+	)
+
+	return &Tool{
+		BaseTool: tools.BaseTool{Tool: tool},
+	}
+}
+
+// GetHandler returns the handler function for the origin tool
+func (t *Tool) GetHandler(repo *apkindex.Repository) tools.ToolHandler {
+	return tools.Bind(func(ctx context.Context, args arguments) (*mcp.CallToolResult, error) {
+		provenance := sources.FromContext(ctx)
+		name := args.Package
+
+		var sb strings.Builder
+
+		origin := name
+		subpackages := packagesFromOrigin(repo, origin)
+		if len(subpackages) == 0 {
+			// Not an origin, go from the package to its origin instead
+			pkg := repo.GetPackageInfo(name)
+			if pkg == nil {
+				return mcp.NewToolResultText(fmt.Sprintf("No origin or package named '%s' found.", name)), nil
+			}
+			origin = originOf(pkg)
+			sb.WriteString(fmt.Sprintf("%s (%s)%s is built from origin %s.\n\n", pkg.Name, pkg.Version, provenance.Suffix(pkg), origin))
+			subpackages = packagesFromOrigin(repo, origin)
+		}
+
+		var size, installedSize uint64
+		sb.WriteString(fmt.Sprintf("Packages built from origin %s (%d):\n\n", origin, len(subpackages)))
+		for i, pkg := range subpackages {
+			marker := ""
+			if pkg.Name == name && name != origin {
+				marker = " [requested]"
+			}
+			sb.WriteString(fmt.Sprintf("%d. %s (%s)%s%s\n", i+1, pkg.Name, pkg.Version, provenance.Suffix(pkg), marker))
+			sb.WriteString(fmt.Sprintf("   Size: %d bytes, Installed: %d bytes\n", pkg.Size, pkg.InstalledSize))
+			size += pkg.Size
+			installedSize += pkg.InstalledSize
+		}
+
+		sb.WriteString(fmt.Sprintf("\nTotal size: %d bytes, Installed: %d bytes\n", size, installedSize))
+
+		return mcp.NewToolResultText(sb.String()), nil
+	})
+}
+
+// originOf returns the origin of a package, which defaults to its own name
+func originOf(pkg *apk.Package) string {
+	if pkg.Origin == "" {
+		return pkg.Name
+	}
+	return pkg.Origin
+}
+
+// packagesFromOrigin returns every package built from the origin, sorted by
+// name and version
+func packagesFromOrigin(repo *apkindex.Repository, origin string) []*apk.Package {
+	var result []*apk.Package
+	for _, pkg := range repo.GetAllPackages() {
+		if originOf(pkg) == origin {
+			result = append(result, pkg)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Name != result[j].Name {
+			return result[i].Name < result[j].Name
+		}
+		return resolve.CompareVersions(result[i].Version, result[j].Version) < 0
+	})
+
+	return result
+}
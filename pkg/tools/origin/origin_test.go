@@ -0,0 +1,113 @@
+package origin
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestOriginTool(t *testing.T) {
+	// Create tool
+	tool := New()
+
+	// Check tool name
+	if tool.GetTool().Name != "origin_packages" {
+		t.Errorf("Expected tool name to be 'origin_packages', got '%s'", tool.GetTool().Name)
+	}
+
+	// Create mock repository
+	mockPackages := []*apk.Package{
+		{Name: "openssl", Version: "3.4.0-r1", Origin: "openssl", Size: 10, InstalledSize: 100},
+		{Name: "libssl3", Version: "3.4.0-r1", Origin: "openssl", Size: 20, InstalledSize: 200},
+		{Name: "libcrypto3", Version: "3.4.0-r1", Origin: "openssl", Size: 30, InstalledSize: 300},
+		{Name: "openssl-dev", Version: "3.4.0-r1", Origin: "openssl", Size: 40, InstalledSize: 400},
+		{Name: "curl", Version: "8.10.0-r0", Origin: "curl"},
+		{Name: "standalone", Version: "1.0-r0"},
+	}
+	repo := apkindex.NewRepository(mockPackages)
+
+	// Get handler
+	handler := tool.GetHandler(repo)
+
+	testCases := []struct {
+		name      string
+		pkg       string
+		checkText []string
+	}{
+		{
+			name: "origin",
+			pkg:  "openssl",
+			checkText: []string{
+				"Packages built from origin openssl (4)",
+				"1. libcrypto3 (3.4.0-r1)",
+				"4. openssl-dev (3.4.0-r1)",
+				"Total size: 100 bytes, Installed: 1000 bytes",
+			},
+		},
+		{
+			name: "subpackage",
+			pkg:  "libssl3",
+			checkText: []string{
+				"libssl3 (3.4.0-r1) is built from origin openssl",
+				"libssl3 (3.4.0-r1) [requested]",
+				"openssl-dev",
+			},
+		},
+		{
+			name:      "package without origin",
+			pkg:       "standalone",
+			checkText: []string{"Packages built from origin standalone (1)"},
+		},
+		{
+			name:      "not found",
+			pkg:       "nonexistent",
+			checkText: []string{"No origin or package named 'nonexistent' found"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := mcp.CallToolRequest{}
+			req.Params.Name = "origin_packages"
+			req.Params.Arguments = map[string]interface{}{
+				"package": tc.pkg,
+			}
+
+			result, err := handler(context.Background(), req)
+			if err != nil {
+				t.Fatalf("Handler returned error: %v", err)
+			}
+
+			if result.IsError {
+				t.Fatalf("Expected successful result, got error")
+			}
+
+			text := result.Content[0].(mcp.TextContent).Text
+			for _, expected := range tc.checkText {
+				if !strings.Contains(text, expected) {
+					t.Errorf("Expected result to contain '%s', got: %s", expected, text)
+				}
+			}
+		})
+	}
+}
+
+func TestPackagesFromOrigin(t *testing.T) {
+	repo := apkindex.NewRepository([]*apk.Package{
+		{Name: "curl", Version: "8.10.0-r0", Origin: "curl"},
+		{Name: "curl", Version: "8.9.1-r0", Origin: "curl"},
+		{Name: "libcurl4", Version: "8.9.1-r0", Origin: "curl"},
+	})
+
+	var got []string
+	for _, pkg := range packagesFromOrigin(repo, "curl") {
+		got = append(got, pkg.Name+"-"+pkg.Version)
+	}
+	if want := "curl-8.9.1-r0,curl-8.10.0-r0,libcurl4-8.9.1-r0"; strings.Join(got, ",") != want {
+		t.Errorf("packagesFromOrigin() = %v, want %s", got, want)
+	}
+}
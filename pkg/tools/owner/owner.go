@@ -0,0 +1,86 @@
+package owner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/dlorenc/wolfi-mcp/pkg/contents"
+	"github.com/dlorenc/wolfi-mcp/pkg/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Tool implements the file ownership tool
+type Tool struct {
+	tools.BaseTool
+	index *contents.Index
+}
+
+// arguments are the arguments of the file ownership tool
+type arguments struct {
+	Path  string `arg:"path" required:"true" description:"An absolute path such as /usr/bin/envsubst, a file name in any directory such as envsubst, or a glob such as 'envsubst*' or '/usr/lib/libssl.so.*'"`
+	Limit int    `arg:"limit" default:"100" minimum:"0" description:"Maximum number of files listed, 0 for no limit"`
+}
+
+// New creates a new file ownership tool querying the contents index
+func New(index *contents.Index) *Tool {
+	tool := mcp.NewTool("who_owns",
+		mcp.WithDescription("Find the packages that install a file, by exact path or glob, using the file lists of the scanned .apk archives"),
+		tools.WithArguments(arguments{}),
+	)
+
+	return &Tool{
+		BaseTool: tools.BaseTool{Tool: tool},
+		index:    index,
+	}
+}
+
+// GetHandler returns the handler function for the file ownership tool
+func (t *Tool) GetHandler(repo *apkindex.Repository) tools.ToolHandler {
+	return tools.Bind(func(ctx context.Context, args arguments) (*mcp.CallToolResult, error) {
+		pattern := args.Path
+
+		// Paths are looked up directly, file names and globs are matched
+		var owners []contents.Owner
+		if strings.Contains(pattern, "/") && !strings.ContainsAny(pattern, "*?[") {
+			owners = t.index.Owners(pattern)
+		} else {
+			var err error
+			if owners, err = t.index.Glob(pattern); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		}
+
+		if len(owners) == 0 {
+			return mcp.NewToolResultText(fmt.Sprintf("No file matching '%s' in the %d scanned packages.", pattern, len(t.index.Packages))), nil
+		}
+
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("Files matching '%s' (%d):\n", pattern, len(owners)))
+		for i, owner := range owners {
+			if args.Limit > 0 && i == args.Limit {
+				sb.WriteString(fmt.Sprintf("... and %d more\n", len(owners)-args.Limit))
+				break
+			}
+			pkg := owner.Package
+			sb.WriteString(fmt.Sprintf("- /%s: %s %s (%s) from %s", owner.File, pkg.Name, pkg.Version, pkg.Arch, pkg.Path))
+			if !inIndex(repo, pkg) {
+				sb.WriteString(" [not in the loaded indexes]")
+			}
+			sb.WriteString("\n")
+		}
+
+		return mcp.NewToolResultText(sb.String()), nil
+	})
+}
+
+// inIndex reports whether the version of a scanned package is in the repository
+func inIndex(repo *apkindex.Repository, pkg *contents.Package) bool {
+	for _, candidate := range repo.GetPackageVersions(pkg.Name) {
+		if candidate.Version == pkg.Version {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package owner
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/dlorenc/wolfi-mcp/pkg/contents"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestOwnerTool(t *testing.T) {
+	index := &contents.Index{Packages: []*contents.Package{
+		{Name: "gettext", Version: "0.22-r0", Arch: "x86_64", Path: "packages/x86_64/gettext-0.22-r0.apk", Files: []string{"usr/bin/envsubst", "usr/bin/gettext"}},
+		{Name: "hello", Version: "1.0-r0", Arch: "x86_64", Path: "packages/x86_64/hello-1.0-r0.apk", Files: []string{"usr/bin/hello"}},
+	}}
+
+	// Create tool
+	tool := New(index)
+
+	// Check tool name
+	if tool.GetTool().Name != "who_owns" {
+		t.Errorf("Expected tool name to be 'who_owns', got '%s'", tool.GetTool().Name)
+	}
+
+	// Only gettext is in the loaded indexes
+	handler := tool.GetHandler(apkindex.NewRepository([]*apk.Package{{Name: "gettext", Version: "0.22-r0"}}))
+
+	testCases := []struct {
+		name      string
+		args      map[string]interface{}
+		isError   bool
+		checkText []string
+	}{
+		{
+			name:      "exact path",
+			args:      map[string]interface{}{"path": "/usr/bin/envsubst"},
+			checkText: []string{"Files matching '/usr/bin/envsubst' (1):\n- /usr/bin/envsubst: gettext 0.22-r0 (x86_64) from packages/x86_64/gettext-0.22-r0.apk\n"},
+		},
+		{
+			name:      "glob",
+			args:      map[string]interface{}{"path": "/usr/bin/*", "limit": 2},
+			checkText: []string{"(3):", "/usr/bin/gettext", "... and 1 more"},
+		},
+		{
+			name:      "package not in the index",
+			args:      map[string]interface{}{"path": "hello"},
+			checkText: []string{"/usr/bin/hello: hello 1.0-r0 (x86_64) from packages/x86_64/hello-1.0-r0.apk [not in the loaded indexes]"},
+		},
+		{
+			name:      "no match",
+			args:      map[string]interface{}{"path": "/usr/bin/missing"},
+			checkText: []string{"No file matching '/usr/bin/missing' in the 2 scanned packages."},
+		},
+		{
+			name:    "invalid pattern",
+			args:    map[string]interface{}{"path": "[usr"},
+			isError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = tc.args
+
+			result, err := handler(context.Background(), request)
+			if err != nil {
+				t.Fatalf("Handler returned error: %v", err)
+			}
+			if result.IsError != tc.isError {
+				t.Errorf("IsError = %v, want %v", result.IsError, tc.isError)
+			}
+
+			text := result.Content[0].(mcp.TextContent).Text
+			for _, check := range tc.checkText {
+				if !strings.Contains(text, check) {
+					t.Errorf("Expected result to contain %q, got: %s", check, text)
+				}
+			}
+		})
+	}
+}
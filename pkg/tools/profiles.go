@@ -0,0 +1,133 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ProfileAll is the profile exposing every available tool
+const ProfileAll = "all"
+
+// lookupTools are the cheap lookups every profile starts from
+var lookupTools = []string{
+	"search_packages",
+	"package_info",
+	"package_dependencies",
+	"compare_versions",
+}
+
+// Profiles names focused sets of tools. Tools that are not available, such as
+// package_vulnerabilities without an advisory feed, are left out silently.
+var Profiles = map[string][]string{
+	// Quick package lookups, for small-context clients and shared servers
+	"minimal": lookupTools,
+
+	// Everything that resolves closures or compares whole indexes
+	"analysis": append(append([]string(nil), lookupTools...),
+		"package_graph",
+		"closure_size",
+		"analyze_apko_config",
+		"audit_providers",
+		"audit_index",
+		"diff_indexes",
+		"version_skew",
+	),
+
+	// Local build output: .apk archives, their files and melange definitions
+	"local": append(append([]string(nil), lookupTools...),
+		"inspect_apk",
+		"who_owns",
+		"package_source",
+	),
+
+	// Vulnerability, license and supply chain questions
+	"security": append(append([]string(nil), lookupTools...),
+		"package_vulnerabilities",
+		"license_report",
+		"generate_sbom",
+		"origin_packages",
+		"package_history",
+		"diff_indexes",
+		"inspect_apk",
+		"package_source",
+	),
+}
+
+// Select returns the tools of a profile, plus the enabled and minus the
+// disabled tools, keeping the order of available. An empty profile means
+// ProfileAll. Unknown tool or profile names are an error, and so is enabling
+// a known tool that is not available.
+func Select(available []Tool, profile string, enable, disable []string) ([]Tool, error) {
+	byName := make(map[string]bool)
+	for _, tool := range available {
+		byName[tool.GetTool().Name] = true
+	}
+	known := make(map[string]bool)
+	for name := range byName {
+		known[name] = true
+	}
+	for _, names := range Profiles {
+		for _, name := range names {
+			known[name] = true
+		}
+	}
+
+	selected := make(map[string]bool)
+	switch {
+	case profile == "" || profile == ProfileAll:
+		for name := range byName {
+			selected[name] = true
+		}
+	case Profiles[profile] != nil:
+		for _, name := range Profiles[profile] {
+			selected[name] = true
+		}
+	default:
+		return nil, fmt.Errorf("unknown tool profile %q (use %s)", profile, strings.Join(ProfileNames(), ", "))
+	}
+
+	for _, name := range enable {
+		if !known[name] {
+			return nil, unknownTool(name, known)
+		}
+		if !byName[name] {
+			return nil, fmt.Errorf("tool %q is not available with the current flags", name)
+		}
+		selected[name] = true
+	}
+	for _, name := range disable {
+		if !known[name] {
+			return nil, unknownTool(name, known)
+		}
+		delete(selected, name)
+	}
+
+	var result []Tool
+	for _, tool := range available {
+		if selected[tool.GetTool().Name] {
+			result = append(result, tool)
+		}
+	}
+	return result, nil
+}
+
+// ProfileNames returns the names of every profile, sorted
+func ProfileNames() []string {
+	names := []string{ProfileAll}
+	for name := range Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// unknownTool returns the error for a tool name that is not known
+func unknownTool(name string, known map[string]bool) error {
+	names := make([]string, 0, len(known))
+	for n := range known {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return fmt.Errorf("unknown tool %q (known tools: %s)", name, strings.Join(names, ", "))
+}
@@ -0,0 +1,87 @@
+package tools
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSelect(t *testing.T) {
+	// package_vulnerabilities is known from the profiles but not available
+	var available []Tool
+	for _, name := range []string{"search_packages", "package_info", "package_graph", "audit_index", "inspect_apk", "custom_tool"} {
+		available = append(available, newMockTool(name))
+	}
+
+	testCases := []struct {
+		name      string
+		profile   string
+		enable    []string
+		disable   []string
+		expected  []string
+		errorText string
+	}{
+		{
+			name:     "default is every tool",
+			expected: []string{"search_packages", "package_info", "package_graph", "audit_index", "inspect_apk", "custom_tool"},
+		},
+		{
+			name:     "minimal",
+			profile:  "minimal",
+			expected: []string{"search_packages", "package_info"},
+		},
+		{
+			name:     "analysis leaves out the local archive tools",
+			profile:  "analysis",
+			expected: []string{"search_packages", "package_info", "package_graph", "audit_index"},
+		},
+		{
+			name:     "local",
+			profile:  "local",
+			expected: []string{"search_packages", "package_info", "inspect_apk"},
+		},
+		{
+			name:     "security skips unavailable tools",
+			profile:  "security",
+			expected: []string{"search_packages", "package_info", "inspect_apk"},
+		},
+		{
+			name:     "enable and disable",
+			profile:  "minimal",
+			enable:   []string{"package_graph", "custom_tool"},
+			disable:  []string{"package_info", "package_vulnerabilities"},
+			expected: []string{"search_packages", "package_graph", "custom_tool"},
+		},
+		{
+			name:     "disable from all",
+			disable:  []string{"audit_index"},
+			expected: []string{"search_packages", "package_info", "package_graph", "inspect_apk", "custom_tool"},
+		},
+		{name: "unknown profile", profile: "tiny", errorText: `unknown tool profile "tiny"`},
+		{name: "unknown tool", enable: []string{"search_package"}, errorText: `unknown tool "search_package"`},
+		{name: "unavailable tool", enable: []string{"package_vulnerabilities"}, errorText: "not available"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			selected, err := Select(available, tc.profile, tc.enable, tc.disable)
+			if tc.errorText != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errorText) {
+					t.Fatalf("Expected error containing %q, got %v", tc.errorText, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Select() error = %v", err)
+			}
+
+			var names []string
+			for _, tool := range selected {
+				names = append(names, tool.GetTool().Name)
+			}
+			if !reflect.DeepEqual(names, tc.expected) {
+				t.Errorf("Select() = %v, want %v", names, tc.expected)
+			}
+		})
+	}
+}
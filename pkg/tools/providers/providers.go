@@ -0,0 +1,93 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/dlorenc/wolfi-mcp/pkg/resolve"
+	"github.com/dlorenc/wolfi-mcp/pkg/sources"
+	"github.com/dlorenc/wolfi-mcp/pkg/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Tool implements the provider audit tool
+type Tool struct {
+	tools.BaseTool
+}
+
+// arguments are the arguments of the provider audit tool
+type arguments struct {
+	Prefix        string `arg:"prefix" description:"Only audit capabilities starting with this prefix, e.g. 'so:' or 'cmd:'"`
+	AmbiguousOnly bool   `arg:"ambiguous_only" description:"Only list capabilities whose providers tie, leaving apk without a clear winner"`
+}
+
+// New creates a new provider audit tool
+func New() *Tool {
+	tool := mcp.NewTool("audit_providers",
+		mcp.WithDescription("List capabilities such as so: and cmd: names that are provided by several packages, flagging those without a clear winner for apk"),
+		tools.WithArguments(arguments{}),
+	)
+
+	return &Tool{
+		BaseTool: tools.BaseTool{Tool: tool},
+	}
+}
+
+// GetHandler returns the handler function for the provider audit tool
+func (t *Tool) GetHandler(repo *apkindex.Repository) tools.ToolHandler {
+	return tools.Bind(func(ctx context.Context, args arguments) (*mcp.CallToolResult, error) {
+		provenance := sources.FromContext(ctx)
+		prefix := args.Prefix
+		ambiguousOnly := args.AmbiguousOnly
+
+		var ambiguous, resolved []resolve.Contested
+		for _, contested := range resolve.New(repo).Contested() {
+			if !strings.HasPrefix(contested.Name, prefix) {
+				continue
+			}
+			if contested.Choice.Ambiguous {
+				ambiguous = append(ambiguous, contested)
+			} else if !ambiguousOnly {
+				resolved = append(resolved, contested)
+			}
+		}
+
+		var sb strings.Builder
+		if prefix != "" {
+			sb.WriteString(fmt.Sprintf("Provider audit of capabilities starting with %q:\n", prefix))
+		} else {
+			sb.WriteString("Provider audit of all capabilities:\n")
+		}
+
+		if len(ambiguous) == 0 && len(resolved) == 0 {
+			sb.WriteString("\nNo capabilities with several providers found.\n")
+			return mcp.NewToolResultText(sb.String()), nil
+		}
+
+		if len(ambiguous) > 0 {
+			sb.WriteString(fmt.Sprintf("\nAmbiguous, no clear winner (%d):\n", len(ambiguous)))
+			for _, contested := range ambiguous {
+				writeContested(&sb, provenance, contested)
+			}
+		}
+		if len(resolved) > 0 {
+			sb.WriteString(fmt.Sprintf("\nSeveral providers, resolved by name or provider_priority (%d):\n", len(resolved)))
+			for _, contested := range resolved {
+				writeContested(&sb, provenance, contested)
+			}
+		}
+
+		return mcp.NewToolResultText(sb.String()), nil
+	})
+}
+
+func writeContested(sb *strings.Builder, provenance *sources.Index, contested resolve.Contested) {
+	var candidates []string
+	for _, pkg := range contested.Choice.Candidates {
+		candidates = append(candidates, fmt.Sprintf("%s (%s, priority %d)%s", pkg.Name, pkg.Version, pkg.ProviderPriority, provenance.Suffix(pkg)))
+	}
+	sb.WriteString(fmt.Sprintf("- %s: apk picks %s; providers: %s\n",
+		contested.Name, contested.Choice.Package.Name, strings.Join(candidates, ", ")))
+}
@@ -0,0 +1,93 @@
+package providers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestProvidersTool(t *testing.T) {
+	// Create tool
+	tool := New()
+
+	// Check tool name
+	if tool.GetTool().Name != "audit_providers" {
+		t.Errorf("Expected tool name to be 'audit_providers', got '%s'", tool.GetTool().Name)
+	}
+
+	// Create mock repository
+	mockPackages := []*apk.Package{
+		{Name: "busybox", Version: "1.36-r0", Provides: []string{"cmd:sh=1.36-r0"}, ProviderPriority: 100},
+		{Name: "bash", Version: "5.2-r0", Provides: []string{"cmd:sh=5.2-r0"}, ProviderPriority: 10},
+		{Name: "libfoo-a", Version: "1.0-r0", Provides: []string{"so:libfoo.so.1=1"}},
+		{Name: "libfoo-b", Version: "1.0-r0", Provides: []string{"so:libfoo.so.1=1"}},
+		{Name: "libbar", Version: "1.0-r0", Provides: []string{"so:libbar.so.1=1"}},
+		{Name: "libbar", Version: "1.1-r0", Provides: []string{"so:libbar.so.1=1"}},
+	}
+	repo := apkindex.NewRepository(mockPackages)
+
+	// Get handler
+	handler := tool.GetHandler(repo)
+
+	testCases := []struct {
+		name      string
+		args      map[string]interface{}
+		checkText []string
+		absent    []string
+	}{
+		{
+			name: "all capabilities",
+			args: map[string]interface{}{},
+			checkText: []string{
+				"Ambiguous, no clear winner (1):\n- so:libfoo.so.1: apk picks libfoo-a; providers: libfoo-a (1.0-r0, priority 0), libfoo-b (1.0-r0, priority 0)",
+				"resolved by name or provider_priority (1):\n- cmd:sh: apk picks busybox",
+			},
+			absent: []string{"libbar"},
+		},
+		{
+			name:      "ambiguous only",
+			args:      map[string]interface{}{"ambiguous_only": true},
+			checkText: []string{"so:libfoo.so.1"},
+			absent:    []string{"cmd:sh"},
+		},
+		{
+			name:      "prefix",
+			args:      map[string]interface{}{"prefix": "cmd:"},
+			checkText: []string{"starting with \"cmd:\"", "cmd:sh"},
+			absent:    []string{"so:libfoo.so.1"},
+		},
+		{
+			name:      "nothing found",
+			args:      map[string]interface{}{"prefix": "pc:"},
+			checkText: []string{"No capabilities with several providers found."},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = tc.args
+
+			result, err := handler(context.Background(), request)
+			if err != nil {
+				t.Fatalf("Handler returned error: %v", err)
+			}
+
+			text := result.Content[0].(mcp.TextContent).Text
+			for _, check := range tc.checkText {
+				if !strings.Contains(text, check) {
+					t.Errorf("Expected result to contain %q, got: %s", check, text)
+				}
+			}
+			for _, check := range tc.absent {
+				if strings.Contains(text, check) {
+					t.Errorf("Expected result not to contain %q, got: %s", check, text)
+				}
+			}
+		})
+	}
+}
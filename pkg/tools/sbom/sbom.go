@@ -0,0 +1,94 @@
+package sbom
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/dlorenc/wolfi-mcp/pkg/apkoconfig"
+	"github.com/dlorenc/wolfi-mcp/pkg/resolve"
+	bom "github.com/dlorenc/wolfi-mcp/pkg/sbom"
+	"github.com/dlorenc/wolfi-mcp/pkg/sources"
+	"github.com/dlorenc/wolfi-mcp/pkg/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Tool implements the SBOM generation tool
+type Tool struct {
+	tools.BaseTool
+}
+
+// arguments are the arguments of the SBOM generation tool
+type arguments struct {
+	Packages []string `arg:"packages" description:"The packages, as a list or a comma separated string"`
+	Config   string   `arg:"config" description:"An apko YAML configuration whose packages are used, inline (used when packages is not given)"`
+	Path     string   `arg:"path" description:"Path to a local apko YAML configuration (used when neither packages nor config is given)"`
+	Format   string   `arg:"format" enum:"spdx,cyclonedx" default:"spdx" description:"SBOM format"`
+}
+
+// New creates a new SBOM generation tool
+func New() *Tool {
+	tool := mcp.NewTool("generate_sbom",
+		mcp.WithDescription("Resolve a list of packages or an apko configuration and generate an SPDX 2.3 or CycloneDX 1.5 JSON SBOM of the install closure, without building the image. Packages carry the APKINDEX checksum of their control segment as apk:control-checksum, not a checksum of the .apk file"),
+		tools.WithArguments(arguments{}),
+	)
+
+	return &Tool{
+		BaseTool: tools.BaseTool{Tool: tool},
+	}
+}
+
+// GetHandler returns the handler function for the SBOM generation tool
+func (t *Tool) GetHandler(repo *apkindex.Repository) tools.ToolHandler {
+	return tools.Bind(func(ctx context.Context, args arguments) (*mcp.CallToolResult, error) {
+		requests := args.Packages
+		name := strings.Join(requests, ",")
+		if len(requests) == 0 {
+			cfg, err := apkoconfig.Read(args.Config, args.Path)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Either packages or an apko configuration must be provided: %v", err)), nil
+			}
+			requests = cfg.Contents.Packages
+			name = "apko-image"
+			if args.Path != "" && args.Config == "" {
+				name = args.Path
+			}
+		}
+
+		data, err := Generate(repo, sources.FromContext(ctx), requests, args.Format, name, time.Now())
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return mcp.NewToolResultText(string(data)), nil
+	})
+}
+
+// Generate resolves the requested packages and renders their install closure
+// as an SBOM. The packages are attributed to the distribution of the
+// repository provenance records for them. It fails when any dependency cannot
+// be resolved, since the document would silently miss packages.
+func Generate(repo *apkindex.Repository, provenance *sources.Index, requests []string, format, name string, created time.Time) ([]byte, error) {
+	if len(requests) == 0 {
+		return nil, fmt.Errorf("no packages to resolve")
+	}
+
+	closure := resolve.New(repo).Closure(requests)
+	if len(closure.Problems) > 0 {
+		problems := make([]string, 0, len(closure.Problems))
+		for _, problem := range closure.Problems {
+			problems = append(problems, problem.String())
+		}
+		return nil, fmt.Errorf("could not resolve the install closure:\n- %s", strings.Join(problems, "\n- "))
+	}
+
+	vendor := func(pkg *apk.Package) bom.Vendor {
+		if src, ok := provenance.Of(pkg); ok {
+			return bom.VendorOf(src.Name())
+		}
+		return bom.Vendor{}
+	}
+	return bom.Generate(format, closure, bom.Options{Name: name, Created: created, Vendor: vendor})
+}
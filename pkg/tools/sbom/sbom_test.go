@@ -0,0 +1,132 @@
+package sbom
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/dlorenc/wolfi-mcp/pkg/sources"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestSBOMTool(t *testing.T) {
+	// Create tool
+	tool := New()
+
+	// Check tool name
+	if tool.GetTool().Name != "generate_sbom" {
+		t.Errorf("Expected tool name to be 'generate_sbom', got '%s'", tool.GetTool().Name)
+	}
+
+	// Create mock repository
+	mockPackages := []*apk.Package{
+		{Name: "app", Version: "1.0-r0", Arch: "x86_64", License: "MIT", Dependencies: []string{"lib"}},
+		{Name: "lib", Version: "2.0-r0", Arch: "x86_64", License: "Apache-2.0"},
+		{Name: "broken", Version: "1.0-r0", Dependencies: []string{"missing"}},
+	}
+	repo := apkindex.NewRepository(mockPackages)
+
+	// Get handler
+	handler := tool.GetHandler(repo)
+
+	testCases := []struct {
+		name      string
+		args      map[string]interface{}
+		isError   bool
+		checkText []string
+	}{
+		{
+			name: "spdx from packages",
+			args: map[string]interface{}{"packages": "app"},
+			checkText: []string{
+				`"spdxVersion": "SPDX-2.3"`,
+				`"name": "app"`,
+				`"referenceLocator": "pkg:apk/wolfi/lib@2.0-r0?arch=x86_64"`,
+				`"relationshipType": "DEPENDS_ON"`,
+			},
+		},
+		{
+			name: "cyclonedx from config",
+			args: map[string]interface{}{
+				"config": "contents:\n  packages:\n    - app\n",
+				"format": "cyclonedx",
+			},
+			checkText: []string{
+				`"specVersion": "1.5"`,
+				`"purl": "pkg:apk/wolfi/app@1.0-r0?arch=x86_64"`,
+				`"expression": "Apache-2.0"`,
+			},
+		},
+		{
+			name:      "unresolvable dependency",
+			args:      map[string]interface{}{"packages": "broken"},
+			isError:   true,
+			checkText: []string{"missing (required by broken)"},
+		},
+		{
+			name:      "unknown format",
+			args:      map[string]interface{}{"packages": "app", "format": "swid"},
+			isError:   true,
+			checkText: []string{"must be one of spdx, cyclonedx"},
+		},
+		{
+			name:      "nothing to resolve",
+			args:      map[string]interface{}{},
+			isError:   true,
+			checkText: []string{"Either packages or an apko configuration must be provided"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = tc.args
+
+			result, err := handler(context.Background(), request)
+			if err != nil {
+				t.Fatalf("Handler returned error: %v", err)
+			}
+			if result.IsError != tc.isError {
+				t.Errorf("Expected IsError to be %v, got %v", tc.isError, result.IsError)
+			}
+
+			text := result.Content[0].(mcp.TextContent).Text
+			for _, check := range tc.checkText {
+				if !strings.Contains(text, check) {
+					t.Errorf("Expected result to contain %q, got: %s", check, text)
+				}
+			}
+		})
+	}
+}
+
+func TestSBOMVendor(t *testing.T) {
+	app := &apk.Package{Name: "app", Version: "1.0-r0", Arch: "x86_64", Dependencies: []string{"musl"}}
+	musl := &apk.Package{Name: "musl", Version: "1.2.5-r0", Arch: "x86_64"}
+	repo := apkindex.NewRepository([]*apk.Package{app, musl})
+	provenance := sources.NewIndex([]sources.Source{
+		{Location: "https://apk.example.com/overlay/x86_64/APKINDEX.tar.gz", Packages: []*apk.Package{app}},
+		{Location: "https://dl-cdn.alpinelinux.org/alpine/v3.20/main/x86_64/APKINDEX.tar.gz", Packages: []*apk.Package{musl}},
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"packages": "app"}
+	result, err := New().GetHandler(repo)(sources.NewContext(context.Background(), provenance), request)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	text := result.Content[0].(mcp.TextContent).Text
+	for _, check := range []string{
+		`"referenceLocator": "pkg:apk/apk.example.com/app@1.0-r0?arch=x86_64"`,
+		`"supplier": "NOASSERTION"`,
+		`"referenceLocator": "pkg:apk/alpine/musl@1.2.5-r0?arch=x86_64"`,
+		`"supplier": "Organization: Alpine Linux"`,
+	} {
+		if !strings.Contains(text, check) {
+			t.Errorf("Expected result to contain %q, got: %s", check, text)
+		}
+	}
+}
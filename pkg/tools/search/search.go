@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/dlorenc/wolfi-mcp/pkg/sources"
 	"github.com/dlorenc/wolfi-mcp/pkg/tools"
 	"github.com/mark3labs/mcp-go/mcp"
 )
@@ -15,14 +16,16 @@ type Tool struct {
 	tools.BaseTool
 }
 
+// arguments are the arguments of the search tool
+type arguments struct {
+	Query string `arg:"query" required:"true" description:"The package name to search for (supports partial matches)"`
+}
+
 // New creates a new search tool
 func New() *Tool {
 	tool := mcp.NewTool("search_packages",
 		mcp.WithDescription("Search for packages in the Alpine package database"),
-		mcp.WithString("query",
-			mcp.Required(),
-			mcp.Description("The package name to search for (supports partial matches)"),
-		),
+		tools.WithArguments(arguments{}),
 	)
 
 	return &Tool{
@@ -32,8 +35,9 @@ func New() *Tool {
 
 // GetHandler returns the handler function for the search tool
 func (t *Tool) GetHandler(repo *apkindex.Repository) tools.ToolHandler {
-	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		query := strings.ToLower(request.Params.Arguments["query"].(string))
+	return tools.Bind(func(ctx context.Context, args arguments) (*mcp.CallToolResult, error) {
+		provenance := sources.FromContext(ctx)
+		query := strings.ToLower(args.Query)
 		results := repo.Search(query)
 
 		if len(results) == 0 {
@@ -45,7 +49,7 @@ func (t *Tool) GetHandler(repo *apkindex.Repository) tools.ToolHandler {
 		sb.WriteString(fmt.Sprintf("Found %d packages matching '%s':\n\n", len(results), query))
 
 		for i, pkg := range results {
-			sb.WriteString(fmt.Sprintf("%d. %s (%s)\n", i+1, pkg.Name, pkg.Version))
+			sb.WriteString(fmt.Sprintf("%d. %s (%s)%s\n", i+1, pkg.Name, pkg.Version, provenance.Suffix(pkg)))
 			if pkg.Description != "" {
 				sb.WriteString(fmt.Sprintf("   Description: %s\n", pkg.Description))
 			}
@@ -53,5 +57,5 @@ func (t *Tool) GetHandler(repo *apkindex.Repository) tools.ToolHandler {
 		}
 
 		return mcp.NewToolResultText(sb.String()), nil
-	}
+	})
 }
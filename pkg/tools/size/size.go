@@ -0,0 +1,161 @@
+package size
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/dlorenc/wolfi-mcp/pkg/resolve"
+	"github.com/dlorenc/wolfi-mcp/pkg/sources"
+	"github.com/dlorenc/wolfi-mcp/pkg/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Tool implements the install closure size tool
+type Tool struct {
+	tools.BaseTool
+}
+
+// arguments are the arguments of the closure size tool
+type arguments struct {
+	Packages    []string `arg:"packages" required:"true" description:"The packages, as a list or a comma separated string (version constraints such as 'python-3.12>=3.12.1' are allowed)"`
+	CompareWith []string `arg:"compare_with" description:"An alternative package set to compare against"`
+}
+
+// New creates a new closure size tool
+func New() *Tool {
+	tool := mcp.NewTool("closure_size",
+		mcp.WithDescription("Compute the total download and installed size of the transitive closure of a set of packages, optionally comparing it with another set"),
+		tools.WithArguments(arguments{}),
+	)
+
+	return &Tool{
+		BaseTool: tools.BaseTool{Tool: tool},
+	}
+}
+
+// GetHandler returns the handler function for the closure size tool
+func (t *Tool) GetHandler(repo *apkindex.Repository) tools.ToolHandler {
+	return tools.Bind(func(ctx context.Context, args arguments) (*mcp.CallToolResult, error) {
+		provenance := sources.FromContext(ctx)
+		packages := args.Packages
+		if len(packages) == 0 {
+			return mcp.NewToolResultError("At least one package must be provided"), nil
+		}
+
+		resolver := resolve.New(repo)
+		closure := resolver.Closure(packages)
+
+		var sb strings.Builder
+
+		alternative := args.CompareWith
+		if len(alternative) == 0 {
+			writeClosure(&sb, provenance, packages, closure)
+			return mcp.NewToolResultText(sb.String()), nil
+		}
+
+		other := resolver.Closure(alternative)
+		writeComparison(&sb, provenance, packages, closure, alternative, other)
+		return mcp.NewToolResultText(sb.String()), nil
+	})
+}
+
+// writeClosure writes the per-package breakdown of a single closure
+func writeClosure(sb *strings.Builder, provenance *sources.Index, packages []string, closure *resolve.Closure) {
+	sb.WriteString(fmt.Sprintf("Install closure of %s (%d packages):\n\n", strings.Join(packages, ", "), len(closure.Packages)))
+
+	// Largest contributors first
+	sorted := append([]*apk.Package{}, closure.Packages...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].InstalledSize > sorted[j].InstalledSize
+	})
+
+	total := closure.InstalledSize()
+	for i, pkg := range sorted {
+		sb.WriteString(fmt.Sprintf("%d. %s (%s)%s\n", i+1, pkg.Name, pkg.Version, provenance.Suffix(pkg)))
+		sb.WriteString(fmt.Sprintf("   Size: %d bytes, Installed: %d bytes (%s)\n", pkg.Size, pkg.InstalledSize, percent(pkg.InstalledSize, total)))
+	}
+
+	writeProblems(sb, "Unresolved dependencies", closure)
+
+	sb.WriteString(fmt.Sprintf("\nTotal download size: %d bytes\n", closure.Size()))
+	sb.WriteString(fmt.Sprintf("Total installed size: %d bytes\n", total))
+}
+
+// writeComparison writes the difference between two closures
+func writeComparison(sb *strings.Builder, provenance *sources.Index, packages []string, closure *resolve.Closure, alternative []string, other *resolve.Closure) {
+	sb.WriteString(fmt.Sprintf("A: %s (%d packages)\n", strings.Join(packages, ", "), len(closure.Packages)))
+	sb.WriteString(fmt.Sprintf("   Download: %d bytes, Installed: %d bytes\n", closure.Size(), closure.InstalledSize()))
+	sb.WriteString(fmt.Sprintf("B: %s (%d packages)\n", strings.Join(alternative, ", "), len(other.Packages)))
+	sb.WriteString(fmt.Sprintf("   Download: %d bytes, Installed: %d bytes\n", other.Size(), other.InstalledSize()))
+
+	sb.WriteString(fmt.Sprintf("\nDifference (B - A): download %+d bytes, installed %+d bytes\n",
+		int64(other.Size())-int64(closure.Size()), int64(other.InstalledSize())-int64(closure.InstalledSize())))
+
+	inA := make(map[string]*apk.Package)
+	for _, pkg := range closure.Packages {
+		inA[pkg.Name] = pkg
+	}
+	inB := make(map[string]*apk.Package)
+	for _, pkg := range other.Packages {
+		inB[pkg.Name] = pkg
+	}
+
+	writeOnly(sb, provenance, "Only in A", closure.Packages, inB)
+	writeOnly(sb, provenance, "Only in B", other.Packages, inA)
+
+	var shared int
+	for name := range inA {
+		if inB[name] != nil {
+			shared++
+		}
+	}
+	sb.WriteString(fmt.Sprintf("\nShared packages: %d\n", shared))
+
+	writeProblems(sb, "Unresolved dependencies of A", closure)
+	writeProblems(sb, "Unresolved dependencies of B", other)
+}
+
+// writeOnly lists the packages of a closure that are missing from the other one
+func writeOnly(sb *strings.Builder, provenance *sources.Index, title string, packages []*apk.Package, other map[string]*apk.Package) {
+	var only []*apk.Package
+	var total uint64
+	for _, pkg := range packages {
+		if other[pkg.Name] == nil {
+			only = append(only, pkg)
+			total += pkg.InstalledSize
+		}
+	}
+
+	sb.WriteString(fmt.Sprintf("\n%s (%d packages, %d bytes installed):\n", title, len(only), total))
+	if len(only) == 0 {
+		sb.WriteString("None\n")
+		return
+	}
+	for i, pkg := range only {
+		sb.WriteString(fmt.Sprintf("%d. %s (%s)%s - %d bytes installed\n", i+1, pkg.Name, pkg.Version, provenance.Suffix(pkg), pkg.InstalledSize))
+	}
+}
+
+// writeProblems lists the dependencies that could not be resolved, since they
+// make the computed sizes an underestimate
+func writeProblems(sb *strings.Builder, title string, closure *resolve.Closure) {
+	if len(closure.Problems) == 0 {
+		return
+	}
+	sb.WriteString(fmt.Sprintf("\n%s (not included in the totals):\n", title))
+	for _, problem := range closure.Problems {
+		sb.WriteString(fmt.Sprintf("- %s\n", problem))
+	}
+}
+
+// percent formats part as a percentage of total
+func percent(part, total uint64) string {
+	if total == 0 {
+		return "0.0%"
+	}
+	return fmt.Sprintf("%.1f%%", float64(part)*100/float64(total))
+}
@@ -0,0 +1,105 @@
+package size
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestSizeTool(t *testing.T) {
+	// Create tool
+	tool := New()
+
+	// Check tool name
+	if tool.GetTool().Name != "closure_size" {
+		t.Errorf("Expected tool name to be 'closure_size', got '%s'", tool.GetTool().Name)
+	}
+
+	// Create mock repository
+	mockPackages := []*apk.Package{
+		{Name: "python-3.12", Version: "3.12.1-r0", Dependencies: []string{"so:libc.so.6"}, Size: 100, InstalledSize: 3000},
+		{Name: "nodejs", Version: "20.1-r0", Dependencies: []string{"so:libc.so.6", "missing-dep"}, Size: 200, InstalledSize: 5000},
+		{Name: "glibc", Version: "2.40-r0", Provides: []string{"so:libc.so.6=6"}, Size: 10, InstalledSize: 1000},
+	}
+	repo := apkindex.NewRepository(mockPackages)
+
+	// Get handler
+	handler := tool.GetHandler(repo)
+
+	testCases := []struct {
+		name              string
+		args              map[string]interface{}
+		checkText         []string
+		expectedErrorFlag bool
+	}{
+		{
+			name: "single set",
+			args: map[string]interface{}{"packages": "python-3.12"},
+			checkText: []string{
+				"Install closure of python-3.12 (2 packages)",
+				"1. python-3.12",
+				"75.0%",
+				"Total download size: 110 bytes",
+				"Total installed size: 4000 bytes",
+			},
+		},
+		{
+			name: "comparison",
+			args: map[string]interface{}{"packages": "python-3.12", "compare_with": "nodejs"},
+			checkText: []string{
+				"Difference (B - A): download +100 bytes, installed +2000 bytes",
+				"Only in A (1 packages, 3000 bytes installed)",
+				"Only in B (1 packages, 5000 bytes installed)",
+				"Shared packages: 1",
+				"Unresolved dependencies of B (not included in the totals)",
+				"missing-dep (required by nodejs)",
+			},
+		},
+		{
+			name: "spaced constraint",
+			args: map[string]interface{}{"packages": "python-3.12 >= 3.12"},
+			checkText: []string{
+				"=3.12 (2 packages)",
+			},
+		},
+		{
+			name:              "empty package list",
+			args:              map[string]interface{}{"packages": " , "},
+			expectedErrorFlag: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := mcp.CallToolRequest{}
+			req.Params.Name = "closure_size"
+			req.Params.Arguments = tc.args
+
+			result, err := handler(context.Background(), req)
+			if err != nil {
+				t.Fatalf("Handler returned error: %v", err)
+			}
+
+			if result.IsError != tc.expectedErrorFlag {
+				t.Fatalf("Expected IsError=%v, got %v", tc.expectedErrorFlag, result.IsError)
+			}
+
+			jsonData, err := json.Marshal(result)
+			if err != nil {
+				t.Fatalf("Failed to marshal result: %v", err)
+			}
+
+			jsonStr := string(jsonData)
+			for _, text := range tc.checkText {
+				if !strings.Contains(jsonStr, text) {
+					t.Errorf("Expected result to contain '%s', got: %s", text, jsonStr)
+				}
+			}
+		})
+	}
+}
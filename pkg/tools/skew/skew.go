@@ -0,0 +1,84 @@
+package skew
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/dlorenc/wolfi-mcp/pkg/sources"
+	"github.com/dlorenc/wolfi-mcp/pkg/tools"
+	"github.com/dlorenc/wolfi-mcp/pkg/versionskew"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Tool implements the version skew tool
+type Tool struct {
+	tools.BaseTool
+	sources []sources.Source
+}
+
+// arguments are the arguments of the version skew tool
+type arguments struct {
+	Packages     []string `arg:"packages" description:"Only compare these packages, wildcards such as 'py3-*' are supported (default: every package)"`
+	Repositories []string `arg:"repositories" description:"Only compare these repositories, by name, base URL or index location (default: every loaded repository)"`
+	Limit        int      `arg:"limit" default:"50" minimum:"0" description:"Maximum number of packages listed per section, 0 for no limit"`
+}
+
+// New creates a new version skew tool comparing the loaded sources
+func New(srcs []sources.Source) *Tool {
+	tool := mcp.NewTool("version_skew",
+		mcp.WithDescription("Compare the versions of packages across the loaded repositories, e.g. an overlay and Wolfi, and report overlay packages older than upstream, packages shadowed by a later repository and packages only present in one repository"),
+		tools.WithArguments(arguments{}),
+	)
+
+	return &Tool{
+		BaseTool: tools.BaseTool{Tool: tool},
+		sources:  srcs,
+	}
+}
+
+// GetHandler returns the handler function for the version skew tool
+func (t *Tool) GetHandler(repo *apkindex.Repository) tools.ToolHandler {
+	return tools.Bind(func(ctx context.Context, args arguments) (*mcp.CallToolResult, error) {
+		srcs, err := selectSources(t.sources, args.Repositories)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		report, err := versionskew.Compare(versionskew.Repositories(srcs), args.Packages)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return mcp.NewToolResultText(report.String(args.Limit)), nil
+	})
+}
+
+// selectSources returns the sources of the named repositories, in load order
+func selectSources(srcs []sources.Source, repositories []string) ([]sources.Source, error) {
+	if len(repositories) == 0 {
+		return srcs, nil
+	}
+
+	selected := make(map[int]bool)
+	for _, repository := range repositories {
+		found := false
+		for i, src := range srcs {
+			if src.Matches(repository) {
+				selected[i] = true
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown repository %q (loaded repositories: %s)", repository, strings.Join(sources.NewIndex(srcs).Names(), ", "))
+		}
+	}
+
+	var result []sources.Source
+	for i, src := range srcs {
+		if selected[i] {
+			result = append(result, src)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,93 @@
+package skew
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/dlorenc/wolfi-mcp/pkg/sources"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestSkewTool(t *testing.T) {
+	srcs := []sources.Source{
+		{Location: "https://packages.wolfi.dev/os/x86_64/APKINDEX.tar.gz", Packages: []*apk.Package{
+			{Name: "curl", Version: "8.10.0-r0"},
+			{Name: "git", Version: "2.46.0-r1"},
+		}},
+		{Location: "https://example.com/overlay/x86_64/APKINDEX.tar.gz", Packages: []*apk.Package{
+			{Name: "curl", Version: "8.9.1-r0"},
+		}},
+		{Location: "https://example.com/extra/x86_64/APKINDEX.tar.gz", Packages: []*apk.Package{
+			{Name: "git", Version: "2.47.0-r0"},
+		}},
+	}
+
+	// Create tool
+	tool := New(srcs)
+
+	// Check tool name
+	if tool.GetTool().Name != "version_skew" {
+		t.Errorf("Expected tool name to be 'version_skew', got '%s'", tool.GetTool().Name)
+	}
+
+	// Get handler
+	handler := tool.GetHandler(apkindex.NewRepository(sources.AllPackages(srcs)))
+
+	testCases := []struct {
+		name      string
+		args      map[string]interface{}
+		isError   bool
+		checkText []string
+	}{
+		{
+			name: "every repository",
+			args: map[string]interface{}{},
+			checkText: []string{
+				"across 3 repositories",
+				"- curl 8.9.1-r0 in example.com/overlay, 8.10.0-r0 in packages.wolfi.dev/os",
+				"- git 2.46.0-r1 in packages.wolfi.dev/os, 2.47.0-r0 in example.com/extra",
+			},
+		},
+		{
+			name:      "selected repositories",
+			args:      map[string]interface{}{"repositories": "https://packages.wolfi.dev/os, example.com/extra"},
+			checkText: []string{"across 2 repositories (packages.wolfi.dev/os, example.com/extra)", "Only in packages.wolfi.dev/os (1):\n- curl"},
+		},
+		{
+			name:      "filtered packages",
+			args:      map[string]interface{}{"packages": []interface{}{"cu*"}},
+			checkText: []string{"Compared 1 packages"},
+		},
+		{
+			name:      "unknown repository",
+			args:      map[string]interface{}{"repositories": "example.org"},
+			isError:   true,
+			checkText: []string{`unknown repository "example.org"`},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = tc.args
+
+			result, err := handler(context.Background(), request)
+			if err != nil {
+				t.Fatalf("Handler returned error: %v", err)
+			}
+			if result.IsError != tc.isError {
+				t.Errorf("IsError = %v, want %v", result.IsError, tc.isError)
+			}
+
+			text := result.Content[0].(mcp.TextContent).Text
+			for _, check := range tc.checkText {
+				if !strings.Contains(text, check) {
+					t.Errorf("Expected result to contain %q, got: %s", check, text)
+				}
+			}
+		})
+	}
+}
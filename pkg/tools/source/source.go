@@ -0,0 +1,263 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/dlorenc/wolfi-mcp/pkg/melange"
+	"github.com/dlorenc/wolfi-mcp/pkg/resolve"
+	"github.com/dlorenc/wolfi-mcp/pkg/sources"
+	"github.com/dlorenc/wolfi-mcp/pkg/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Tool implements the package source tool
+type Tool struct {
+	tools.BaseTool
+	tree *melange.Tree
+}
+
+// arguments are the arguments of the package source tool
+type arguments struct {
+	Package string `arg:"package" description:"An origin or subpackage name (e.g. 'libssl3'). Leave empty to list the definitions whose packages in the index are behind, ahead or missing."`
+	Limit   int    `arg:"limit" default:"50" minimum:"0" description:"Maximum number of definitions listed in each section, 0 for no limit"`
+}
+
+// New creates a new package source tool reading the melange definitions of tree
+func New(tree *melange.Tree) *Tool {
+	tool := mcp.NewTool("package_source",
+		mcp.WithDescription("Show the melange definition a package is built from: upstream source, version, update monitoring, pipeline steps and subpackages, and whether the index is behind the definition. Without a package, list every definition the index does not match."),
+		tools.WithArguments(arguments{}),
+	)
+
+	return &Tool{
+		BaseTool: tools.BaseTool{Tool: tool},
+		tree:     tree,
+	}
+}
+
+// GetHandler returns the handler function for the package source tool
+func (t *Tool) GetHandler(repo *apkindex.Repository) tools.ToolHandler {
+	return tools.Bind(func(ctx context.Context, args arguments) (*mcp.CallToolResult, error) {
+		provenance := sources.FromContext(ctx)
+		if args.Package == "" {
+			return mcp.NewToolResultText(t.drift(repo, provenance, args.Limit)), nil
+		}
+
+		def := t.lookup(repo, args.Package)
+		if def == nil {
+			return mcp.NewToolResultError(fmt.Sprintf("No melange definition in %s builds %s", t.tree.Dir, args.Package)), nil
+		}
+
+		var sb strings.Builder
+		origin := def.Package.Name
+		sb.WriteString(fmt.Sprintf("%s %s is defined in %s\n", origin, def.Version(), def.Path))
+		if def.Package.Description != "" {
+			sb.WriteString(fmt.Sprintf("Description: %s\n", def.Package.Description))
+		}
+		if licenses := def.Licenses(); len(licenses) > 0 {
+			sb.WriteString(fmt.Sprintf("License: %s\n", strings.Join(licenses, " AND ")))
+		}
+		sb.WriteString(fmt.Sprintf("Index: %s\n", status(latest(repo.GetPackageVersions(origin)), def.Version(), provenance)))
+
+		srcs := def.Sources()
+		if len(srcs) == 0 {
+			sb.WriteString("\nSources: none fetched by the pipeline\n")
+		} else {
+			sb.WriteString(fmt.Sprintf("\nSources (%d):\n", len(srcs)))
+			for _, src := range srcs {
+				sb.WriteString(fmt.Sprintf("- %s %s", src.Kind, src.URL))
+				if src.Ref != "" {
+					sb.WriteString(" at " + src.Ref)
+				}
+				if src.Checksum != "" {
+					sb.WriteString(" (expected " + src.Checksum + ")")
+				}
+				sb.WriteString("\n")
+			}
+		}
+
+		sb.WriteString(fmt.Sprintf("\nUpdates: %s\n", updates(def.Update)))
+
+		sb.WriteString(fmt.Sprintf("\nPipeline (%d steps):\n", len(def.Pipeline)))
+		writeSteps(&sb, def.Pipeline, "")
+
+		if len(def.Subpackages) > 0 {
+			sb.WriteString(fmt.Sprintf("\nSubpackages (%d):\n", len(def.Subpackages)))
+			for i, name := range def.SubpackageNames() {
+				sb.WriteString(fmt.Sprintf("- %s", name))
+				if def.Subpackages[i].Range != "" {
+					sb.WriteString(fmt.Sprintf(" (one for each entry of range %s)\n", def.Subpackages[i].Range))
+					continue
+				}
+				sb.WriteString(": " + status(latest(repo.GetPackageVersions(name)), def.Version(), provenance) + "\n")
+			}
+		}
+
+		return mcp.NewToolResultText(sb.String()), nil
+	})
+}
+
+// lookup returns the definition of a package, preferring the origin the index
+// records for it over the subpackages declared in the definitions
+func (t *Tool) lookup(repo *apkindex.Repository, name string) *melange.Definition {
+	if pkg := latest(repo.GetPackageVersions(name)); pkg != nil && pkg.Origin != "" {
+		if def, ok := t.tree.Definitions[pkg.Origin]; ok {
+			return def
+		}
+	}
+	return t.tree.Lookup(name)
+}
+
+// drift lists the definitions whose main package in the index is behind,
+// ahead of or missing from the definition
+func (t *Tool) drift(repo *apkindex.Repository, provenance *sources.Index, limit int) string {
+	// Keep the latest package built from each origin
+	built := make(map[string]*apk.Package)
+	for _, pkg := range repo.GetAllPackages() {
+		origin := pkg.Origin
+		if origin == "" {
+			origin = pkg.Name
+		}
+		if current, ok := built[origin]; !ok || resolve.CompareVersions(pkg.Version, current.Version) > 0 {
+			built[origin] = pkg
+		}
+	}
+
+	var behind, ahead, missing []string
+	for _, origin := range t.tree.Origins() {
+		def := t.tree.Definitions[origin]
+		pkg, ok := built[origin]
+		switch {
+		case !ok:
+			missing = append(missing, fmt.Sprintf("%s %s", origin, def.Version()))
+		case resolve.CompareVersions(pkg.Version, def.Version()) < 0:
+			behind = append(behind, fmt.Sprintf("%s: index %s%s, definition %s", origin, pkg.Version, provenance.Suffix(pkg), def.Version()))
+		case resolve.CompareVersions(pkg.Version, def.Version()) > 0:
+			ahead = append(ahead, fmt.Sprintf("%s: index %s%s, definition %s", origin, pkg.Version, provenance.Suffix(pkg), def.Version()))
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Compared %d melange definitions in %s with the index: %d behind, %d ahead, %d not in the index\n",
+		len(t.tree.Definitions), t.tree.Dir, len(behind), len(ahead), len(missing)))
+	writeSection(&sb, "Index behind the definition", behind, limit)
+	writeSection(&sb, "Index ahead of the definition, the checkout may be outdated", ahead, limit)
+	writeSection(&sb, "Defined but not in the index", missing, limit)
+	return sb.String()
+}
+
+// writeSection writes a titled list, unless it is empty
+func writeSection(sb *strings.Builder, title string, lines []string, limit int) {
+	if len(lines) == 0 {
+		return
+	}
+	sb.WriteString(fmt.Sprintf("\n%s (%d):\n", title, len(lines)))
+	for i, line := range lines {
+		if limit > 0 && i == limit {
+			sb.WriteString(fmt.Sprintf("... and %d more\n", len(lines)-limit))
+			break
+		}
+		sb.WriteString("- " + line + "\n")
+	}
+}
+
+// writeSteps lists pipeline steps and the steps nested in them
+func writeSteps(sb *strings.Builder, steps []melange.Step, indent string) {
+	for i, step := range steps {
+		sb.WriteString(fmt.Sprintf("%s%d. ", indent, i+1))
+		switch {
+		case step.Uses != "":
+			sb.WriteString("uses " + step.Uses)
+		case step.Runs != "":
+			script := strings.TrimSpace(step.Runs)
+			first, _, _ := strings.Cut(script, "\n")
+			sb.WriteString("runs: " + first)
+			if lines := strings.Count(script, "\n") + 1; lines > 1 {
+				sb.WriteString(fmt.Sprintf(" (%d lines)", lines))
+			}
+		default:
+			sb.WriteString("pipeline")
+		}
+		if step.Name != "" {
+			sb.WriteString(fmt.Sprintf(" [%s]", step.Name))
+		}
+		sb.WriteString("\n")
+		writeSteps(sb, step.Pipeline, indent+"   ")
+	}
+}
+
+// updates describes how new upstream versions are detected
+func updates(update melange.Update) string {
+	if !update.Enabled {
+		return "disabled"
+	}
+
+	var monitors []string
+	if gh := update.GitHub; gh != nil {
+		monitor := "GitHub " + gh.Identifier
+		if gh.UseTag {
+			monitor += " tags"
+		} else {
+			monitor += " releases"
+		}
+		if gh.TagFilter != "" {
+			monitor += fmt.Sprintf(" matching %q", gh.TagFilter)
+		}
+		if gh.StripPrefix != "" {
+			monitor += fmt.Sprintf(" without prefix %q", gh.StripPrefix)
+		}
+		monitors = append(monitors, monitor)
+	}
+	if rm := update.ReleaseMonitor; rm != nil {
+		monitors = append(monitors, fmt.Sprintf("release-monitoring.org project %d", rm.Identifier))
+	}
+	if git := update.Git; git != nil {
+		monitor := "git tags"
+		if git.TagFilter != "" {
+			monitor += fmt.Sprintf(" matching %q", git.TagFilter)
+		}
+		monitors = append(monitors, monitor)
+	}
+
+	description := "enabled"
+	if update.Manual {
+		description += ", updated manually"
+	}
+	if len(monitors) > 0 {
+		description += ", monitoring " + strings.Join(monitors, " and ")
+	}
+	if len(update.IgnoreRegexPatterns) > 0 {
+		description += fmt.Sprintf(", ignoring versions matching %s", strings.Join(update.IgnoreRegexPatterns, ", "))
+	}
+	return description
+}
+
+// status compares the index version of a package with the definition
+func status(pkg *apk.Package, defined string, provenance *sources.Index) string {
+	if pkg == nil {
+		return fmt.Sprintf("not in the index, the definition builds %s", defined)
+	}
+	switch cmp := resolve.CompareVersions(pkg.Version, defined); {
+	case cmp < 0:
+		return fmt.Sprintf("%s%s, BEHIND the definition (%s)", pkg.Version, provenance.Suffix(pkg), defined)
+	case cmp > 0:
+		return fmt.Sprintf("%s%s, ahead of the definition (%s)", pkg.Version, provenance.Suffix(pkg), defined)
+	default:
+		return fmt.Sprintf("%s%s, matches the definition", pkg.Version, provenance.Suffix(pkg))
+	}
+}
+
+// latest returns the highest version of the packages, or nil
+func latest(packages []*apk.Package) *apk.Package {
+	var result *apk.Package
+	for _, pkg := range packages {
+		if result == nil || resolve.CompareVersions(pkg.Version, result.Version) > 0 {
+			result = pkg
+		}
+	}
+	return result
+}
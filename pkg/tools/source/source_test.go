@@ -0,0 +1,165 @@
+package source
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/dlorenc/wolfi-mcp/pkg/melange"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// definition parses a melange definition for the tests
+func definition(t *testing.T, data string) *melange.Definition {
+	def, err := melange.Parse([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	def.Path = "/src/os/" + def.Package.Name + ".yaml"
+	return def
+}
+
+func TestSourceTool(t *testing.T) {
+	tree := &melange.Tree{Dir: "/src/os"}
+	tree.Add(definition(t, `package:
+  name: openssl
+  version: 3.4.1
+  epoch: 0
+  copyright:
+    - license: Apache-2.0
+pipeline:
+  - uses: fetch
+    with:
+      uri: https://www.openssl.org/source/openssl-${{package.version}}.tar.gz
+      expected-sha256: 0123abcd
+  - runs: |
+      ./Configure
+      make
+  - uses: strip
+subpackages:
+  - name: libssl3
+  - name: openssl-dev
+update:
+  enabled: true
+  release-monitor:
+    identifier: 2566
+`))
+	tree.Add(definition(t, `package:
+  name: curl
+  version: 8.10.0
+  epoch: 0
+pipeline:
+  - uses: git-checkout
+    with:
+      repository: https://github.com/curl/curl
+      tag: curl-${{package.version}}
+update:
+  enabled: false
+`))
+	tree.Add(definition(t, `package:
+  name: zlib
+  version: 1.3.1
+  epoch: 0
+`))
+	tree.Add(definition(t, `package:
+  name: newpkg
+  version: 0.1.0
+  epoch: 0
+`))
+
+	tool := New(tree)
+	if tool.GetTool().Name != "package_source" {
+		t.Errorf("Expected tool name to be 'package_source', got '%s'", tool.GetTool().Name)
+	}
+
+	repo := apkindex.NewRepository([]*apk.Package{
+		{Name: "openssl", Version: "3.4.0-r1", Origin: "openssl"},
+		{Name: "libssl3", Version: "3.4.0-r1", Origin: "openssl"},
+		{Name: "curl", Version: "8.10.0-r0", Origin: "curl"},
+		{Name: "libcurl4", Version: "8.10.0-r0", Origin: "curl"},
+		{Name: "zlib", Version: "1.3.2-r0", Origin: "zlib"},
+	})
+	handler := tool.GetHandler(repo)
+
+	testCases := []struct {
+		name      string
+		args      map[string]interface{}
+		isError   bool
+		checkText []string
+	}{
+		{
+			name: "origin behind the definition",
+			args: map[string]interface{}{"package": "openssl"},
+			checkText: []string{
+				"openssl 3.4.1-r0 is defined in /src/os/openssl.yaml",
+				"License: Apache-2.0",
+				"Index: 3.4.0-r1, BEHIND the definition (3.4.1-r0)",
+				"- fetch https://www.openssl.org/source/openssl-3.4.1.tar.gz (expected 0123abcd)",
+				"Updates: enabled, monitoring release-monitoring.org project 2566",
+				"Pipeline (3 steps):",
+				"2. runs: ./Configure (2 lines)",
+				"- libssl3: 3.4.0-r1, BEHIND the definition",
+				"- openssl-dev: not in the index",
+			},
+		},
+		{
+			name: "subpackage only in the index",
+			args: map[string]interface{}{"package": "libcurl4"},
+			checkText: []string{
+				"curl 8.10.0-r0 is defined in /src/os/curl.yaml",
+				"Index: 8.10.0-r0, matches the definition",
+				"- git-checkout https://github.com/curl/curl at curl-8.10.0",
+				"Updates: disabled",
+			},
+		},
+		{
+			name: "drift",
+			args: map[string]interface{}{},
+			checkText: []string{
+				"Compared 4 melange definitions in /src/os with the index: 1 behind, 1 ahead, 1 not in the index",
+				"- openssl: index 3.4.0-r1, definition 3.4.1-r0",
+				"- zlib: index 1.3.2-r0, definition 1.3.1-r0",
+				"Defined but not in the index (1):\n- newpkg 0.1.0-r0",
+			},
+		},
+		{
+			name: "drift limit",
+			args: map[string]interface{}{"limit": float64(1)},
+			checkText: []string{
+				"Index behind the definition (1):",
+			},
+		},
+		{
+			name:    "no definition",
+			args:    map[string]interface{}{"package": "busybox"},
+			isError: true,
+			checkText: []string{
+				"No melange definition in /src/os builds busybox",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = tc.args
+
+			result, err := handler(context.Background(), request)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result.IsError != tc.isError {
+				t.Fatalf("Expected isError=%v, got %v", tc.isError, result.IsError)
+			}
+
+			text := result.Content[0].(mcp.TextContent).Text
+			for _, check := range tc.checkText {
+				if !strings.Contains(text, check) {
+					t.Errorf("Expected result to contain '%s', got '%s'", check, text)
+				}
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package tools
 
 import (
 	"context"
+	"strings"
 
 	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
 	"github.com/mark3labs/mcp-go/mcp"
@@ -29,11 +30,46 @@ func (b *BaseTool) GetTool() mcp.Tool {
 	return b.Tool
 }
 
-// RegisterAll registers all available tools with the server
+// RegisterAll registers all available tools with the server, wrapping each
+// handler in middleware when it is not nil
 func RegisterAll(srv interface {
 	AddTool(mcp.Tool, ToolHandler)
-}, repo *apkindex.Repository, tools ...Tool) {
+}, repo *apkindex.Repository, middleware Middleware, tools ...Tool) {
 	for _, tool := range tools {
-		srv.AddTool(tool.GetTool(), tool.GetHandler(repo))
+		handler := tool.GetHandler(repo)
+		if middleware != nil {
+			handler = middleware(tool.GetTool(), handler)
+		}
+		srv.AddTool(tool.GetTool(), handler)
 	}
 }
+
+// SplitList splits a comma or whitespace separated argument into its
+// non-empty items. Constraints written with spaces around the operator, such
+// as "foo >= 1.2", are kept as one item.
+func SplitList(value string) []string {
+	var items []string
+	for _, part := range strings.Split(value, ",") {
+		var item string
+		for _, field := range strings.Fields(part) {
+			switch {
+			case item == "":
+				item = field
+			case isOperator(field[0]) || isOperator(item[len(item)-1]):
+				item += field
+			default:
+				items = append(items, item)
+				item = field
+			}
+		}
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// isOperator reports whether c is part of a version constraint operator
+func isOperator(c byte) bool {
+	return c == '<' || c == '>' || c == '=' || c == '~'
+}
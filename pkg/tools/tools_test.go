@@ -70,7 +70,7 @@ func TestRegisterAll(t *testing.T) {
 	tool3 := newMockTool("tool3")
 
 	// Register all tools
-	RegisterAll(srv, repo, tool1, tool2, tool3)
+	RegisterAll(srv, repo, nil, tool1, tool2, tool3)
 
 	// Verify all tools were registered
 	for _, name := range []string{"tool1", "tool2", "tool3"} {
@@ -79,3 +79,34 @@ func TestRegisterAll(t *testing.T) {
 		}
 	}
 }
+
+func TestSplitList(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected []string
+	}{
+		{"", nil},
+		{"curl", []string{"curl"}},
+		{"curl,bash", []string{"curl", "bash"}},
+		{" curl, bash\ngit  ", []string{"curl", "bash", "git"}},
+		{"so:libc.so.6, cmd:sh>1", []string{"so:libc.so.6", "cmd:sh>1"}},
+		{"foo >= 1.2, bar", []string{"foo>=1.2", "bar"}},
+		{"curl bash git", []string{"curl", "bash", "git"}},
+		{"foo>= 1.2 bar =1.0 baz", []string{"foo>=1.2", "bar=1.0", "baz"}},
+		{" , ,\n", nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			got := SplitList(tc.input)
+			if len(got) != len(tc.expected) {
+				t.Fatalf("SplitList(%q) = %v, want %v", tc.input, got, tc.expected)
+			}
+			for i := range got {
+				if got[i] != tc.expected[i] {
+					t.Errorf("SplitList(%q)[%d] = %q, want %q", tc.input, i, got[i], tc.expected[i])
+				}
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/dlorenc/wolfi-mcp/pkg/sources"
 	"github.com/dlorenc/wolfi-mcp/pkg/tools"
 	"github.com/mark3labs/mcp-go/mcp"
 )
@@ -16,14 +17,16 @@ type Tool struct {
 	tools.BaseTool
 }
 
+// arguments are the arguments of the versions tool
+type arguments struct {
+	Package string `arg:"package" required:"true" description:"The package name to compare versions for"`
+}
+
 // New creates a new versions tool
 func New() *Tool {
 	tool := mcp.NewTool("compare_versions",
 		mcp.WithDescription("Compare versions of packages"),
-		mcp.WithString("package",
-			mcp.Required(),
-			mcp.Description("The package name to compare versions for"),
-		),
+		tools.WithArguments(arguments{}),
 	)
 
 	return &Tool{
@@ -33,8 +36,9 @@ func New() *Tool {
 
 // GetHandler returns the handler function for the versions tool
 func (t *Tool) GetHandler(repo *apkindex.Repository) tools.ToolHandler {
-	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		packageName := request.Params.Arguments["package"].(string)
+	return tools.Bind(func(ctx context.Context, args arguments) (*mcp.CallToolResult, error) {
+		provenance := sources.FromContext(ctx)
+		packageName := args.Package
 		versions := repo.GetPackageVersions(packageName)
 
 		if len(versions) == 0 {
@@ -55,6 +59,9 @@ func (t *Tool) GetHandler(repo *apkindex.Repository) tools.ToolHandler {
 		for i, pkg := range versions {
 			sb.WriteString(fmt.Sprintf("%d. Version: %s\n", i+1, pkg.Version))
 			sb.WriteString(fmt.Sprintf("   Architecture: %s\n", pkg.Arch))
+			if repository := provenance.Describe(pkg); repository != "" {
+				sb.WriteString(fmt.Sprintf("   Repository: %s\n", repository))
+			}
 			sb.WriteString(fmt.Sprintf("   Size: %d bytes\n", pkg.Size))
 			if pkg.Origin != "" {
 				sb.WriteString(fmt.Sprintf("   Origin: %s\n", pkg.Origin))
@@ -63,5 +70,5 @@ func (t *Tool) GetHandler(repo *apkindex.Repository) tools.ToolHandler {
 		}
 
 		return mcp.NewToolResultText(sb.String()), nil
-	}
+	})
 }
@@ -0,0 +1,208 @@
+package vulnerabilities
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/dlorenc/wolfi-mcp/pkg/resolve"
+	"github.com/dlorenc/wolfi-mcp/pkg/secdb"
+	"github.com/dlorenc/wolfi-mcp/pkg/sources"
+	"github.com/dlorenc/wolfi-mcp/pkg/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Tool implements the package vulnerabilities tool
+type Tool struct {
+	tools.BaseTool
+	db *secdb.Database
+}
+
+// arguments are the arguments of the package vulnerabilities tool
+type arguments struct {
+	Package string `arg:"package" required:"true" description:"The exact package name"`
+	Version string `arg:"version" description:"The package version to check (default: the latest version in the index)"`
+	Closure bool   `arg:"closure" description:"Scan the whole runtime closure of the package for unfixed vulnerabilities instead"`
+}
+
+// New creates a new package vulnerabilities tool matching packages against db
+func New(db *secdb.Database) *Tool {
+	tool := mcp.NewTool("package_vulnerabilities",
+		mcp.WithDescription("Report known vulnerabilities of a package version from the local advisory feed, and the fixed versions available in the index"),
+		tools.WithArguments(arguments{}),
+	)
+
+	return &Tool{
+		BaseTool: tools.BaseTool{Tool: tool},
+		db:       db,
+	}
+}
+
+// GetHandler returns the handler function for the package vulnerabilities tool
+func (t *Tool) GetHandler(repo *apkindex.Repository) tools.ToolHandler {
+	return tools.Bind(func(ctx context.Context, args arguments) (*mcp.CallToolResult, error) {
+		packageName := args.Package
+		version := args.Version
+		closure := args.Closure
+
+		if closure {
+			return t.scanClosure(repo, sources.FromContext(ctx), packageName, version), nil
+		}
+
+		pkg := findVersion(repo, packageName, version)
+		if pkg == nil {
+			if version == "" {
+				return mcp.NewToolResultError(fmt.Sprintf("Package '%s' not found", packageName)), nil
+			}
+			// The version may no longer be in the index, match it by name alone
+			pkg = &apk.Package{Name: packageName, Version: version}
+		}
+
+		var affecting, fixed, notAffected []secdb.Advisory
+		for _, advisory := range t.advisories(pkg) {
+			switch {
+			case advisory.NotAffected:
+				notAffected = append(notAffected, advisory)
+			case advisory.Affects(pkg.Version):
+				affecting = append(affecting, advisory)
+			default:
+				fixed = append(fixed, advisory)
+			}
+		}
+
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("Vulnerabilities of %s %s: %d affecting, %d fixed, %d not affected\n",
+			pkg.Name, pkg.Version, len(affecting), len(fixed), len(notAffected)))
+
+		if len(affecting) > 0 {
+			sb.WriteString("\nAffecting this version:\n")
+			for _, advisory := range affecting {
+				sb.WriteString(fmt.Sprintf("- %s: %s\n", title(advisory), remediation(repo, pkg, advisory)))
+			}
+		}
+		if len(fixed) > 0 {
+			sb.WriteString("\nFixed in this version:\n")
+			for _, advisory := range fixed {
+				if advisory.Fixed != "" {
+					sb.WriteString(fmt.Sprintf("- %s: fixed in %s\n", title(advisory), advisory.Fixed))
+				} else {
+					sb.WriteString(fmt.Sprintf("- %s\n", title(advisory)))
+				}
+			}
+		}
+		if len(notAffected) > 0 {
+			sb.WriteString("\nNot affected:\n")
+			for _, advisory := range notAffected {
+				sb.WriteString(fmt.Sprintf("- %s\n", title(advisory)))
+			}
+		}
+
+		return mcp.NewToolResultText(sb.String()), nil
+	})
+}
+
+// scanClosure reports the unfixed vulnerabilities of every package in the runtime closure
+func (t *Tool) scanClosure(repo *apkindex.Repository, provenance *sources.Index, packageName, version string) *mcp.CallToolResult {
+	dependency := packageName
+	if version != "" {
+		dependency = packageName + "=" + version
+	}
+	closure := resolve.New(repo).Closure([]string{dependency})
+	if len(closure.Packages) == 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("Package '%s' not found", dependency))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Vulnerability scan of the runtime closure of %s (%d packages):\n", dependency, len(closure.Packages)))
+
+	vulnerable := 0
+	for _, pkg := range closure.Packages {
+		var affecting []secdb.Advisory
+		for _, advisory := range t.advisories(pkg) {
+			if advisory.Affects(pkg.Version) {
+				affecting = append(affecting, advisory)
+			}
+		}
+		if len(affecting) == 0 {
+			continue
+		}
+
+		vulnerable++
+		sb.WriteString(fmt.Sprintf("\n%s %s%s (%d unfixed)", pkg.Name, pkg.Version, provenance.Suffix(pkg), len(affecting)))
+		if path := closure.Path(pkg.Name); len(path) > 1 {
+			sb.WriteString(fmt.Sprintf(" - pulled in by: %s", strings.Join(path, " -> ")))
+		}
+		sb.WriteString(":\n")
+		for _, advisory := range affecting {
+			sb.WriteString(fmt.Sprintf("- %s: %s\n", title(advisory), remediation(repo, pkg, advisory)))
+		}
+	}
+
+	if vulnerable == 0 {
+		sb.WriteString("\nNo unfixed vulnerabilities found.\n")
+	}
+	for _, problem := range closure.Problems {
+		sb.WriteString(fmt.Sprintf("\nUnresolved dependency: %s\n", problem))
+	}
+
+	return mcp.NewToolResultText(sb.String())
+}
+
+// advisories returns the advisories of a package, including those filed
+// against the origin package it was built from
+func (t *Tool) advisories(pkg *apk.Package) []secdb.Advisory {
+	result := t.db.Advisories(pkg.Name)
+	if pkg.Origin != "" && pkg.Origin != pkg.Name {
+		result = append(append([]secdb.Advisory{}, result...), t.db.Advisories(pkg.Origin)...)
+	}
+	return result
+}
+
+// findVersion returns the given version of a package from the index, or the
+// latest version when version is empty
+func findVersion(repo *apkindex.Repository, name, version string) *apk.Package {
+	var found *apk.Package
+	for _, pkg := range repo.GetPackageVersions(name) {
+		if version != "" {
+			if pkg.Version == version {
+				return pkg
+			}
+			continue
+		}
+		if found == nil || resolve.CompareVersions(pkg.Version, found.Version) > 0 {
+			found = pkg
+		}
+	}
+	return found
+}
+
+// remediation describes how an affecting advisory can be fixed with the
+// versions available in the index
+func remediation(repo *apkindex.Repository, pkg *apk.Package, advisory secdb.Advisory) string {
+	if advisory.Fixed == "" {
+		return "no fix released"
+	}
+
+	// Pick the lowest version of the index that is not affected any more
+	versions := append([]*apk.Package{}, repo.GetPackageVersions(pkg.Name)...)
+	sort.Slice(versions, func(i, j int) bool {
+		return resolve.CompareVersions(versions[i].Version, versions[j].Version) < 0
+	})
+	for _, candidate := range versions {
+		if resolve.CompareVersions(candidate.Version, pkg.Version) > 0 && !advisory.Affects(candidate.Version) {
+			return fmt.Sprintf("fixed in %s, available in the index as %s", advisory.Fixed, candidate.Version)
+		}
+	}
+	return fmt.Sprintf("fixed in %s, not available in the index yet", advisory.Fixed)
+}
+
+// title returns the advisory ID along with its aliases
+func title(advisory secdb.Advisory) string {
+	if len(advisory.Aliases) == 0 {
+		return advisory.ID
+	}
+	return fmt.Sprintf("%s (%s)", advisory.ID, strings.Join(advisory.Aliases, ", "))
+}
@@ -0,0 +1,124 @@
+package vulnerabilities
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"github.com/dlorenc/wolfi-mcp/pkg/apkindex"
+	"github.com/dlorenc/wolfi-mcp/pkg/secdb"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const testSecDB = `{"packages": [
+  {"pkg": {"name": "openssl", "secfixes": {
+    "3.1.4-r0": ["CVE-2023-5678"],
+    "3.2.0-r0": ["CVE-2024-0727"],
+    "0": ["CVE-2022-0001"]
+  }}},
+  {"pkg": {"name": "app", "secfixes": {"1.0-r0": ["CVE-2020-0001"]}}}
+]}`
+
+func TestVulnerabilitiesTool(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "security.json")
+	if err := os.WriteFile(path, []byte(testSecDB), 0644); err != nil {
+		t.Fatal(err)
+	}
+	db := secdb.New()
+	if err := db.Load(path); err != nil {
+		t.Fatalf("Failed to load the advisories: %v", err)
+	}
+
+	// Create tool
+	tool := New(db)
+
+	// Check tool name
+	if tool.GetTool().Name != "package_vulnerabilities" {
+		t.Errorf("Expected tool name to be 'package_vulnerabilities', got '%s'", tool.GetTool().Name)
+	}
+
+	// Create mock repository
+	mockPackages := []*apk.Package{
+		{Name: "app", Version: "1.0-r0", Dependencies: []string{"libssl3"}},
+		{Name: "libssl3", Version: "3.1.3-r0", Origin: "openssl"},
+		{Name: "libssl3", Version: "3.1.5-r0", Origin: "openssl"},
+		{Name: "openssl", Version: "3.1.3-r0", Origin: "openssl"},
+		{Name: "openssl", Version: "3.1.5-r0", Origin: "openssl"},
+	}
+	repo := apkindex.NewRepository(mockPackages)
+
+	// Get handler
+	handler := tool.GetHandler(repo)
+
+	testCases := []struct {
+		name      string
+		args      map[string]interface{}
+		isError   bool
+		checkText []string
+	}{
+		{
+			name: "old version",
+			args: map[string]interface{}{"package": "openssl", "version": "3.1.3-r0"},
+			checkText: []string{
+				"openssl 3.1.3-r0: 2 affecting, 0 fixed, 1 not affected",
+				"- CVE-2023-5678: fixed in 3.1.4-r0, available in the index as 3.1.5-r0",
+				"- CVE-2024-0727: fixed in 3.2.0-r0, not available in the index yet",
+				"Not affected:\n- CVE-2022-0001",
+			},
+		},
+		{
+			name: "latest version",
+			args: map[string]interface{}{"package": "openssl"},
+			checkText: []string{
+				"openssl 3.1.5-r0: 1 affecting, 1 fixed, 1 not affected",
+				"Fixed in this version:\n- CVE-2023-5678: fixed in 3.1.4-r0",
+			},
+		},
+		{
+			name:      "subpackage matched through its origin",
+			args:      map[string]interface{}{"package": "libssl3", "version": "3.1.5-r0"},
+			checkText: []string{"libssl3 3.1.5-r0: 1 affecting"},
+		},
+		{
+			name: "closure scan",
+			args: map[string]interface{}{"package": "app", "closure": true},
+			checkText: []string{
+				"runtime closure of app (2 packages)",
+				"libssl3 3.1.5-r0 (1 unfixed) - pulled in by: app -> libssl3:\n- CVE-2024-0727",
+			},
+		},
+		{
+			name:    "unknown package",
+			args:    map[string]interface{}{"package": "missing"},
+			isError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = tc.args
+
+			result, err := handler(context.Background(), request)
+			if err != nil {
+				t.Fatalf("Handler returned error: %v", err)
+			}
+			if result.IsError != tc.isError {
+				t.Errorf("Expected IsError to be %v, got %v", tc.isError, result.IsError)
+			}
+
+			text := result.Content[0].(mcp.TextContent).Text
+			for _, check := range tc.checkText {
+				if !strings.Contains(text, check) {
+					t.Errorf("Expected result to contain %q, got: %s", check, text)
+				}
+			}
+			if strings.Contains(tc.name, "closure") && strings.Contains(text, "app 1.0-r0") {
+				t.Errorf("Expected app to be fixed, got: %s", text)
+			}
+		})
+	}
+}
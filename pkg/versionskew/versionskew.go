@@ -0,0 +1,231 @@
+package versionskew
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"github.com/dlorenc/wolfi-mcp/pkg/resolve"
+	"github.com/dlorenc/wolfi-mcp/pkg/sources"
+)
+
+// Repository is the latest version of each package name in one repository.
+// Repositories are ordered like the sources they were built from, so later
+// repositories are the overlays of earlier ones.
+type Repository struct {
+	Name     string
+	Packages map[string]*apk.Package
+}
+
+// Repositories groups the sources by repository name, keeping the latest
+// version of each package name across the architectures of a repository
+func Repositories(srcs []sources.Source) []Repository {
+	var result []Repository
+	index := make(map[string]int)
+	for _, src := range srcs {
+		name := src.Name()
+		i, ok := index[name]
+		if !ok {
+			i = len(result)
+			index[name] = i
+			result = append(result, Repository{Name: name, Packages: make(map[string]*apk.Package)})
+		}
+		for _, pkg := range src.Packages {
+			if existing, ok := result[i].Packages[pkg.Name]; !ok || resolve.CompareVersions(pkg.Version, existing.Version) > 0 {
+				result[i].Packages[pkg.Name] = pkg
+			}
+		}
+	}
+	return result
+}
+
+// Version is the version of a package in one repository
+type Version struct {
+	Repository string
+	Version    string
+}
+
+// Skew is a package whose version in one repository is hidden by the version
+// of another repository when the indexes are merged
+type Skew struct {
+	Name string
+
+	// Hidden is the version that loses the merge
+	Hidden Version
+
+	// Winner is the version apk installs
+	Winner Version
+}
+
+// Report compares the versions of each package name across repositories
+type Report struct {
+	Repositories []string
+
+	// Compared is the number of package names compared
+	Compared int
+
+	// Shared is the number of package names present in several repositories
+	Shared int
+
+	// Stale holds versions of a later repository, usually an overlay, that
+	// are older than the version of an earlier repository
+	Stale []Skew
+
+	// Shadowed holds versions of an earlier repository that a later
+	// repository replaces with a newer or the same version
+	Shadowed []Skew
+
+	// Unique holds the package names only present in one repository, by
+	// repository name
+	Unique map[string][]*apk.Package
+}
+
+// Compare compares the repositories, restricted to the package names matching
+// one of the patterns when patterns are given. Patterns use path.Match syntax.
+func Compare(repositories []Repository, patterns []string) (*Report, error) {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid package pattern %q: %w", pattern, err)
+		}
+	}
+
+	report := &Report{Unique: make(map[string][]*apk.Package)}
+	names := make(map[string]bool)
+	for _, repository := range repositories {
+		report.Repositories = append(report.Repositories, repository.Name)
+		for name := range repository.Packages {
+			if matchAny(patterns, name) {
+				names[name] = true
+			}
+		}
+	}
+	report.Compared = len(names)
+
+	for name := range names {
+		// The merge keeps the highest version, and the later repository on ties
+		var present []int
+		winner := -1
+		for i, repository := range repositories {
+			pkg, ok := repository.Packages[name]
+			if !ok {
+				continue
+			}
+			present = append(present, i)
+			if winner == -1 || resolve.CompareVersions(pkg.Version, repositories[winner].Packages[name].Version) >= 0 {
+				winner = i
+			}
+		}
+
+		if len(present) == 1 {
+			repository := repositories[present[0]]
+			report.Unique[repository.Name] = append(report.Unique[repository.Name], repository.Packages[name])
+			continue
+		}
+		report.Shared++
+
+		for _, i := range present {
+			if i == winner {
+				continue
+			}
+			skew := Skew{
+				Name:   name,
+				Hidden: Version{Repository: repositories[i].Name, Version: repositories[i].Packages[name].Version},
+				Winner: Version{Repository: repositories[winner].Name, Version: repositories[winner].Packages[name].Version},
+			}
+			if i > winner {
+				report.Stale = append(report.Stale, skew)
+			} else {
+				report.Shadowed = append(report.Shadowed, skew)
+			}
+		}
+	}
+
+	sortSkews(report.Stale)
+	sortSkews(report.Shadowed)
+	for _, packages := range report.Unique {
+		sort.Slice(packages, func(i, j int) bool {
+			return packages[i].Name < packages[j].Name
+		})
+	}
+
+	return report, nil
+}
+
+// String formats the report as text, listing at most limit packages per
+// section when limit is positive
+func (r *Report) String(limit int) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Compared %d packages across %d repositories (%s), %d of them in several repositories.\n",
+		r.Compared, len(r.Repositories), strings.Join(r.Repositories, ", "), r.Shared))
+
+	if len(r.Repositories) < 2 {
+		sb.WriteString("\nOnly one repository is loaded, load an overlay or another index to compare versions.\n")
+		return sb.String()
+	}
+
+	writeSkews(&sb, "Older than upstream, apk installs the upstream version instead", r.Stale, limit)
+	writeSkews(&sb, "Shadowed by a later repository", r.Shadowed, limit)
+	for _, repository := range r.Repositories {
+		packages := r.Unique[repository]
+		if len(packages) == 0 {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("\nOnly in %s (%d):\n", repository, len(packages)))
+		for i, pkg := range packages {
+			if limit > 0 && i == limit {
+				sb.WriteString(fmt.Sprintf("... and %d more\n", len(packages)-limit))
+				break
+			}
+			sb.WriteString(fmt.Sprintf("- %s (%s)\n", pkg.Name, pkg.Version))
+		}
+	}
+
+	if len(r.Stale) == 0 && len(r.Shadowed) == 0 && r.Shared > 0 {
+		sb.WriteString("\nNo version skew found between the repositories.\n")
+	}
+
+	return sb.String()
+}
+
+func writeSkews(sb *strings.Builder, title string, skews []Skew, limit int) {
+	if len(skews) == 0 {
+		return
+	}
+	sb.WriteString(fmt.Sprintf("\n%s (%d):\n", title, len(skews)))
+	for i, skew := range skews {
+		if limit > 0 && i == limit {
+			sb.WriteString(fmt.Sprintf("... and %d more\n", len(skews)-limit))
+			break
+		}
+		if skew.Hidden.Version == skew.Winner.Version {
+			sb.WriteString(fmt.Sprintf("- %s %s in %s, same version in %s\n", skew.Name, skew.Hidden.Version, skew.Hidden.Repository, skew.Winner.Repository))
+		} else {
+			sb.WriteString(fmt.Sprintf("- %s %s in %s, %s in %s\n", skew.Name, skew.Hidden.Version, skew.Hidden.Repository, skew.Winner.Version, skew.Winner.Repository))
+		}
+	}
+}
+
+// matchAny reports whether a name matches one of the patterns, or whether
+// there are no patterns
+func matchAny(patterns []string, name string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func sortSkews(skews []Skew) {
+	sort.Slice(skews, func(i, j int) bool {
+		if skews[i].Name != skews[j].Name {
+			return skews[i].Name < skews[j].Name
+		}
+		return skews[i].Hidden.Repository < skews[j].Hidden.Repository
+	})
+}
@@ -0,0 +1,129 @@
+package versionskew
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"github.com/dlorenc/wolfi-mcp/pkg/sources"
+)
+
+func testSources() []sources.Source {
+	return []sources.Source{
+		{Location: "https://packages.wolfi.dev/os/x86_64/APKINDEX.tar.gz", Packages: []*apk.Package{
+			{Name: "curl", Version: "8.10.0-r0"},
+			{Name: "git", Version: "2.46.0-r1"},
+			{Name: "jq", Version: "1.7.1-r0"},
+			{Name: "py3-yaml", Version: "6.0.1-r0"},
+			{Name: "wolfi-only", Version: "1.0-r0"},
+		}},
+		{Location: "https://packages.wolfi.dev/os/aarch64/APKINDEX.tar.gz", Packages: []*apk.Package{
+			{Name: "git", Version: "2.46.0-r2"},
+		}},
+		{Location: "https://example.com/overlay/x86_64/APKINDEX.tar.gz", Packages: []*apk.Package{
+			{Name: "curl", Version: "8.9.1-r0"},
+			{Name: "git", Version: "2.47.0-r0"},
+			{Name: "jq", Version: "1.7.1-r0"},
+			{Name: "py3-yaml", Version: "6.0.2-r0"},
+			{Name: "overlay-only", Version: "1.0-r0"},
+		}},
+	}
+}
+
+func TestRepositories(t *testing.T) {
+	repositories := Repositories(testSources())
+	if len(repositories) != 2 || repositories[0].Name != "packages.wolfi.dev/os" || repositories[1].Name != "example.com/overlay" {
+		t.Fatalf("Unexpected repositories: %+v", repositories)
+	}
+	// The architectures of a repository are merged, keeping the latest version
+	if got := repositories[0].Packages["git"].Version; got != "2.46.0-r2" {
+		t.Errorf("Expected the latest git of the repository, got %s", got)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	report, err := Compare(Repositories(testSources()), nil)
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+
+	if report.Compared != 6 || report.Shared != 4 {
+		t.Errorf("Compared %d packages with %d shared, want 6 and 4", report.Compared, report.Shared)
+	}
+
+	expectedStale := []Skew{
+		{Name: "curl", Hidden: Version{"example.com/overlay", "8.9.1-r0"}, Winner: Version{"packages.wolfi.dev/os", "8.10.0-r0"}},
+	}
+	if !reflect.DeepEqual(report.Stale, expectedStale) {
+		t.Errorf("Stale =\n%+v\nwant\n%+v", report.Stale, expectedStale)
+	}
+
+	expectedShadowed := []Skew{
+		{Name: "git", Hidden: Version{"packages.wolfi.dev/os", "2.46.0-r2"}, Winner: Version{"example.com/overlay", "2.47.0-r0"}},
+		{Name: "jq", Hidden: Version{"packages.wolfi.dev/os", "1.7.1-r0"}, Winner: Version{"example.com/overlay", "1.7.1-r0"}},
+		{Name: "py3-yaml", Hidden: Version{"packages.wolfi.dev/os", "6.0.1-r0"}, Winner: Version{"example.com/overlay", "6.0.2-r0"}},
+	}
+	if !reflect.DeepEqual(report.Shadowed, expectedShadowed) {
+		t.Errorf("Shadowed =\n%+v\nwant\n%+v", report.Shadowed, expectedShadowed)
+	}
+
+	if len(report.Unique["packages.wolfi.dev/os"]) != 1 || len(report.Unique["example.com/overlay"]) != 1 {
+		t.Errorf("Unexpected unique packages: %v", report.Unique)
+	}
+
+	text := report.String(2)
+	for _, check := range []string{
+		"Compared 6 packages across 2 repositories (packages.wolfi.dev/os, example.com/overlay), 4 of them in several repositories.",
+		"Older than upstream, apk installs the upstream version instead (1):\n- curl 8.9.1-r0 in example.com/overlay, 8.10.0-r0 in packages.wolfi.dev/os",
+		"Shadowed by a later repository (3):",
+		"- jq 1.7.1-r0 in packages.wolfi.dev/os, same version in example.com/overlay",
+		"... and 1 more",
+		"Only in example.com/overlay (1):\n- overlay-only (1.0-r0)",
+	} {
+		if !strings.Contains(text, check) {
+			t.Errorf("Expected report to contain %q, got:\n%s", check, text)
+		}
+	}
+}
+
+// TestCompareAgreesWithMerge checks that the winners of the report are the
+// versions the loaded indexes are merged to
+func TestCompareAgreesWithMerge(t *testing.T) {
+	srcs := testSources()
+	var merged []*apk.Package
+	for _, src := range srcs {
+		merged = sources.MergePackages(merged, src.Packages)
+	}
+	versions := make(map[string]string)
+	for _, pkg := range merged {
+		versions[pkg.Name] = pkg.Version
+	}
+	if versions["curl"] != "8.10.0-r0" {
+		t.Errorf("Expected the merge to keep curl 8.10.0-r0, got %s", versions["curl"])
+	}
+
+	report, err := Compare(Repositories(srcs), nil)
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	for _, skew := range append(report.Stale, report.Shadowed...) {
+		if versions[skew.Name] != skew.Winner.Version {
+			t.Errorf("Report has %s %s winning, the merge kept %s", skew.Name, skew.Winner.Version, versions[skew.Name])
+		}
+	}
+}
+
+func TestComparePatterns(t *testing.T) {
+	report, err := Compare(Repositories(testSources()), []string{"py3-*", "curl"})
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	if report.Compared != 2 || len(report.Stale) != 1 || len(report.Shadowed) != 1 {
+		t.Errorf("Unexpected report for the patterns: %+v", report)
+	}
+
+	if _, err := Compare(Repositories(testSources()), []string{"[py3"}); err == nil {
+		t.Errorf("Expected an error for an invalid pattern")
+	}
+}